@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/internals/auth"
+	"backend/internals/middleware"
+	"backend/internals/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apikeys.go - Handles personal API key management for the authenticated user.
+// Keys are sent in the X-API-Key header (or as a Bearer credential) by scripts and CI pipelines.
+//
+// Endpoints:
+//   - GET /api-keys: List your keys (admins may pass ?username= or ?all=true)
+//   - POST /api-keys: Create a key; the raw key is only returned once
+//   - DELETE /api-keys/:id: Delete one of your keys (admins may delete any key)
+
+// maxAPIKeyNameLength bounds the length of key names.
+const maxAPIKeyNameLength = 100
+
+// ListAPIKeys returns the API keys of the current user.
+// Admins may list another user's keys with ?username=<name> or every key with ?all=true.
+// Response: 200 OK with JSON array of keys, 403 Forbidden or 500 Internal Server Error.
+func ListAPIKeys(c *gin.Context) {
+	subject := policy.SubjectFromContext(c)
+	username := subject.Username
+	if other := c.Query("username"); other != "" || c.Query("all") == "true" {
+		if !middleware.HasPermission(subject.Role, middleware.PermClusterAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions", "permission": middleware.PermClusterAdmin})
+			return
+		}
+		username = other
+	}
+
+	keys, err := auth.ListAPIKeys(username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey creates an API key for the current user, which must be a local account.
+// Each scope must be a permission the user's role grants.
+// Request JSON body:
+//
+//	{
+//	  "name": "<description>",
+//	  "scopes": ["read", "produce", "topic-admin", "cluster-admin"],
+//	  "expiresInDays": <days, 0 or omitted for no expiry>
+//	}
+//
+// Response: 201 Created with the key metadata and { "key": "<raw_key>" }, 400 Bad Request or 500 Internal Server Error.
+func CreateAPIKey(c *gin.Context) {
+	if !isLocalSession(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API keys are only available for local accounts"})
+		return
+	}
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expiresInDays"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required and must be at most 100 characters"})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays cannot be negative"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+
+	subject := policy.SubjectFromContext(c)
+	for _, scope := range req.Scopes {
+		if !middleware.IsAPIKeyScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + scope})
+			return
+		}
+		if !middleware.HasPermission(subject.Role, middleware.Permission(scope)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Your role does not grant scope: " + scope})
+			return
+		}
+	}
+
+	identity := &auth.Identity{Username: subject.Username, Role: subject.Role, Source: auth.CSVBackendName}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, raw, err := auth.CreateAPIKey(identity, req.Name, req.Scopes, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"apiKey": key, "key": raw})
+}
+
+// DeleteAPIKey deletes an API key. Users may delete their own keys; admins may delete any key.
+// Response: 200 OK, 404 Not Found or 500 Internal Server Error.
+func DeleteAPIKey(c *gin.Context) {
+	subject := policy.SubjectFromContext(c)
+	key, err := auth.GetAPIKey(c.Param("id"))
+	if err == nil && key.Username != subject.Username && !middleware.HasPermission(subject.Role, middleware.PermClusterAdmin) {
+		// Do not reveal other users' key IDs
+		err = auth.ErrAPIKeyNotFound
+	}
+	if err == nil {
+		err = auth.DeleteAPIKey(key.ID)
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	}
+}
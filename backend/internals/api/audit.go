@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internals/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audit.go - Handles the admin-only audit log query endpoint.
+//
+// Endpoints:
+//   - GET /audit: Query audit entries, newest first
+
+const (
+	// defaultAuditLimit is the number of entries returned when no limit is given.
+	defaultAuditLimit = 100
+
+	// maxAuditLimit bounds the number of entries returned by one query.
+	maxAuditLimit = 1000
+)
+
+// GetAuditLog returns audit entries matching the query filters, newest first.
+//
+// Query parameters (all optional):
+//
+//	user:   exact username
+//	action: exact action, e.g. "topic.delete"
+//	from:   earliest entry time (RFC 3339)
+//	to:     latest entry time (RFC 3339)
+//	limit:  maximum number of entries (default 100, at most 1000)
+//
+// Response: 200 OK with JSON array of entries, 400 Bad Request, 503 Service Unavailable or 500 Internal Server Error.
+func GetAuditLog(c *gin.Context) {
+	logger := audit.Default()
+	if logger == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit log is not configured"})
+		return
+	}
+
+	filter := audit.Filter{
+		User:   c.Query("user"),
+		Action: c.Query("action"),
+		Limit:  defaultAuditLimit,
+	}
+	for name, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " time, expected RFC 3339"})
+				return
+			}
+			*t = parsed
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := logger.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
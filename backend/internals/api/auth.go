@@ -1,33 +1,47 @@
 package api
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
+	"backend/internals/audit"
+	"backend/internals/auth"
+	"backend/internals/store"
 	"backend/internals/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // auth.go - Handles authentication-related API endpoints for login and password management.
 // Provides JWT-based authentication and password change functionality.
 //
 // Endpoints:
-//   - POST /login: Authenticate user and return JWT token
+//   - POST /login: Authenticate user and return access and refresh tokens (or a 2FA challenge)
+//   - POST /token/refresh: Exchange a refresh token for new tokens
+//   - POST /logout: Revoke the current access token and its refresh token (requires authentication)
 //   - POST /change-password: Change user password (requires authentication)
 //
 // Author: [Your Name]
 // Date: [Date]
 
-// Use env var for JWT secret, fallback to constant
-func getJWTSecret() []byte {
-	secret := os.Getenv(utils.JWTSecretKeyEnv)
-	if secret == "" {
-		secret = utils.DefaultJWTSecret
-	}
-	return []byte(secret)
+// authenticator verifies credentials for Login; defaults to the local user store.
+var authenticator auth.Authenticator = auth.NewCSVAuthenticator()
+
+// InitializeAuthenticator sets the authentication backend used by Login.
+func InitializeAuthenticator(a auth.Authenticator) {
+	authenticator = a
+}
+
+// loginGuard throttles failed logins per client IP and username.
+var loginGuard = auth.NewLoginGuard(auth.DefaultLoginGuardConfig())
+
+// InitializeLoginGuard sets the brute-force protection used by Login.
+func InitializeLoginGuard(g *auth.LoginGuard) {
+	loginGuard = g
 }
 
 // Login handles user authentication. It validates credentials and returns a JWT token if successful.
@@ -41,9 +55,14 @@ func getJWTSecret() []byte {
 //
 // Response:
 //
-//	200 OK: { "token": "<jwt_token>" }
+//	200 OK: { "token": "<jwt_token>", "refreshToken": "<refresh_token>", "expiresIn": <seconds> }
+//	200 OK: { "mfaRequired": true, "mfaToken": "<challenge>" } when a TOTP code is needed (see LoginMFA)
+//	200 OK: { "mfaEnrollmentRequired": true, "mfaToken": "<challenge>" } when the role requires 2FA but none is set up
 //	400 Bad Request: { "error": "Invalid request body" }
 //	401 Unauthorized: { "error": "Invalid credentials" }
+//	403 Forbidden: { "error": "Account is disabled" }
+//	429 Too Many Requests: { "error": "...", "retryAfter": <seconds> }
+//	503 Service Unavailable: { "error": "Authentication service unavailable" }
 func Login(c *gin.Context) {
 	var creds struct {
 		Username string `json:"username"`
@@ -54,32 +73,138 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Retrieve user from persistent storage (CSV file)
-	user, err := utils.GetUser(creds.Username)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	// Reject attempts while the client or username is throttled, before checking the password
+	clientIP := c.ClientIP()
+	var blocked *auth.LoginBlockedError
+	if err := loginGuard.Check(clientIP, creds.Username); errors.As(err, &blocked) {
+		respondLoginBlocked(c, blocked)
 		return
 	}
 
-	// Compare provided password with stored password
-	if user.Password != creds.Password {
+	// Verify credentials with the configured authentication backend
+	identity, err := authenticator.Authenticate(creds.Username, creds.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.Set(audit.ReasonKey, "invalid credentials")
+		if loginGuard.RecordFailure(clientIP, creds.Username) {
+			log.Printf("Login for %q locked after repeated failures from %s", creds.Username, clientIP)
+			c.Set(audit.ReasonKey, "invalid credentials, account locked")
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		c.Set(audit.ReasonKey, "account disabled")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	case errors.Is(err, auth.ErrNoRoleMapped):
+		c.Set(audit.ReasonKey, "no role mapped")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has no access to this application"})
+		return
+	case err != nil:
+		log.Printf("Authentication backend %s failed: %v", authenticator.Name(), err)
+		c.Set(audit.ReasonKey, "authentication backend unavailable")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication service unavailable"})
+		return
 	}
 
-	// Create JWT token with username as subject and 24h expiration
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": creds.Username,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
+	// Local accounts with two-factor authentication continue with a second step
+	c.Set("user", identity.Username)
+	if identity.IsLocal() && startMFAChallenge(c, identity) {
+		return
+	}
+	loginGuard.RecordSuccess(creds.Username)
 
-	tokenString, err := token.SignedString(getJWTSecret())
+	// Create a short-lived access token and a rotating refresh token
+	tokens, err := auth.IssueTokens(identity)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// respondLoginBlocked rejects a throttled login attempt with 429 Too Many Requests and a Retry-After header.
+func respondLoginBlocked(c *gin.Context, blocked *auth.LoginBlockedError) {
+	retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+	message := "Too many failed login attempts, try again later"
+	if blocked.Reason == auth.BlockedLocked {
+		message = "Account temporarily locked after too many failed login attempts"
+	}
+	c.Set(audit.ReasonKey, "blocked: "+blocked.Reason)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retryAfter": retryAfter})
+}
+
+// RefreshToken exchanges a refresh token for a new access token and refresh token.
+// The presented refresh token is consumed; presenting it again ends the session.
+//
+// Request JSON body:
+//
+//	{
+//	  "refreshToken": "<refresh_token>"
+//	}
+//
+// Response:
+//
+//	200 OK: { "token": "<jwt_token>", "refreshToken": "<refresh_token>", "expiresIn": <seconds> }
+//	400 Bad Request: { "error": "Invalid request body" }
+//	401 Unauthorized: { "error": "Invalid refresh token" }
+func RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tokens, err := auth.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the access token used for the request and, if given, its refresh token.
+//
+// Request JSON body (optional):
+//
+//	{
+//	  "refreshToken": "<refresh_token>"
+//	}
+//
+// Response:
+//
+//	200 OK: { "message": "Logged out" }
+//	500 Internal Server Error: { "error": "Failed to log out" }
+func Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	if req.RefreshToken != "" {
+		if err := auth.RevokeRefreshToken(req.RefreshToken); err != nil && !errors.Is(err, auth.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	jti, _ := c.Get("tokenID")
+	tokenID, _ := jti.(string)
+	expiry, _ := c.Get("tokenExpiry")
+	expiresAt, _ := expiry.(time.Time)
+	if err := auth.RevokeToken(tokenID, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
 
 // ChangePassword allows an authenticated user to change their password.
@@ -93,8 +218,8 @@ func Login(c *gin.Context) {
 //
 // Response:
 //
-//	200 OK: { "message": "Password changed successfully" }
-//	400 Bad Request: { "error": "Invalid request body" }
+//	200 OK: { "message": "Password changed successfully", "token": "<jwt_token>", "refreshToken": "<refresh_token>", "expiresIn": <seconds> }
+//	400 Bad Request: { "error": "Invalid request body" / "invalid password: ..." }
 //	401 Unauthorized: { "error": "User not authenticated" / "Current password is incorrect" }
 //	500 Internal Server Error: { "error": "Failed to update password" }
 func ChangePassword(c *gin.Context) {
@@ -107,6 +232,16 @@ func ChangePassword(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Passwords of external accounts are managed by their backend
+	if !isLocalSession(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is managed by the " + c.GetString("source") + " backend"})
+		return
+	}
 
 	// Extract username from JWT token (set by authentication middleware)
 	username, exists := c.Get("user")
@@ -115,24 +250,46 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Retrieve user from persistent storage (CSV file)
-	user, err := utils.GetUser(username.(string))
+	// Retrieve user from the local user store
+	user, err := store.Users().GetUser(username.(string))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
 
 	// Verify current password matches stored password
-	if user.Password != req.CurrentPassword {
+	if ok, _ := utils.VerifyPassword(user.Password, req.CurrentPassword); !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
 		return
 	}
 
 	// Update password in persistent storage
-	if err := utils.UpdateUserPassword(username.(string), req.NewPassword); err != nil {
+	if err := store.Users().UpdateUserPassword(username.(string), req.NewPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	// Invalidate every existing session, then issue fresh tokens for this one
+	if err := auth.RevokeUser(user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke existing sessions"})
+		return
+	}
+	tokens, err := auth.IssueTokens(&auth.Identity{Username: user.Username, Role: user.Role, Source: auth.CSVBackendName})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Password changed successfully",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+// isLocalSession reports whether the request is authenticated as an account of the local user store,
+// as opposed to an LDAP, static file or single sign-on user that may share its name.
+func isLocalSession(c *gin.Context) bool {
+	return c.GetString("source") == auth.CSVBackendName
 }
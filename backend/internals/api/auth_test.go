@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend/internals/auth"
+	"backend/internals/store"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useTestUsers points the local user store at a users.csv with the given content.
+func useTestUsers(t *testing.T, csv string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, utils.UsersFileName)
+	if err := os.WriteFile(path, []byte(csv), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store.Initialize(&store.Backend{Users: store.NewCSVUserStore(path), Documents: store.NewFileDocumentStore(dir)})
+}
+
+// serveAs calls handler with a JSON body as the given user, authenticated by source.
+func serveAs(handler gin.HandlerFunc, username, role, source, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", username)
+	c.Set("role", role)
+	c.Set("source", source)
+	handler(c)
+	return w
+}
+
+func TestLocalOnlyEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hash, err := utils.HashPassword("admin-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useTestUsers(t, "username,password,role,disabled\nadmin,"+hash+",admin,false\n")
+
+	endpoints := []struct {
+		name      string
+		handler   gin.HandlerFunc
+		body      string
+		wantLocal int
+	}{
+		{"2FA status", GetMFAStatus, "", http.StatusOK},
+		{"2FA enrollment", EnrollMFA, "", http.StatusOK},
+		{"API key creation", CreateAPIKey, `{"name": "ci", "scopes": ["read"]}`, http.StatusCreated},
+		{"password change", ChangePassword, `{"currentPassword": "admin-pass", "newPassword": "admin-pass-2"}`, http.StatusOK},
+	}
+	// Users of other backends may share the name of a local account but must not act as it
+	for _, source := range []string{auth.OIDCSourceName, auth.LDAPBackendName, auth.StaticBackendName, ""} {
+		for _, tt := range endpoints {
+			if w := serveAs(tt.handler, "admin", utils.RoleAdmin, source, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s as %q session: status %d, want %d", tt.name, source, w.Code, http.StatusBadRequest)
+			}
+		}
+	}
+	for _, tt := range endpoints {
+		if w := serveAs(tt.handler, "admin", utils.RoleAdmin, auth.CSVBackendName, tt.body); w.Code != tt.wantLocal {
+			t.Errorf("%s as local session: status %d, want %d: %s", tt.name, w.Code, tt.wantLocal, w.Body)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/internals/cluster"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clusters.go - Handles the registry of named Kafka clusters.
+// Kafka endpoints are served per cluster under /api/clusters/:cluster/...
+//
+// Endpoints:
+//   - GET /clusters: List cluster definitions
+//   - POST /clusters: Add a cluster (admin only)
+//   - GET /clusters/:cluster: Get one cluster definition
+//   - PUT /clusters/:cluster: Change the connection settings of a cluster (admin only)
+//   - DELETE /clusters/:cluster: Remove a cluster (admin only)
+//   - GET /clusters/:cluster/brokers/history: Broker status history
+//   - GET /connections: Show the pooled Kafka clients (admin only)
+
+// clusterRequest is the request body of CreateCluster and UpdateCluster.
+type clusterRequest struct {
+	Name             string                `json:"name"`
+	BootstrapServers []string              `json:"bootstrapServers"`
+	SASL             *cluster.SASLSettings `json:"sasl"`
+	TLS              *cluster.TLSSettings  `json:"tls"`
+}
+
+// clusterResponse is the API representation of a cluster. Secrets are reported as set, never returned.
+type clusterResponse struct {
+	Name             string               `json:"name"`
+	BootstrapServers []string             `json:"bootstrapServers"`
+	SASL             *saslResponse        `json:"sasl,omitempty"`
+	TLS              *cluster.TLSSettings `json:"tls,omitempty"`
+	CreatedAt        time.Time            `json:"createdAt"`
+	UpdatedAt        time.Time            `json:"updatedAt"`
+}
+
+type saslResponse struct {
+	Mechanism       string   `json:"mechanism"`
+	Username        string   `json:"username,omitempty"`
+	PasswordSet     bool     `json:"passwordSet"`
+	TokenURL        string   `json:"tokenUrl,omitempty"`
+	ClientID        string   `json:"clientId,omitempty"`
+	ClientSecretSet bool     `json:"clientSecretSet"`
+	Scopes          []string `json:"scopes,omitempty"`
+}
+
+// toClusterResponse converts a cluster definition to its API representation.
+func toClusterResponse(def cluster.Definition) clusterResponse {
+	resp := clusterResponse{
+		Name:             def.Name,
+		BootstrapServers: def.BootstrapServers,
+		TLS:              def.TLS,
+		CreatedAt:        def.CreatedAt,
+		UpdatedAt:        def.UpdatedAt,
+	}
+	if s := def.SASL; s != nil {
+		resp.SASL = &saslResponse{
+			Mechanism:       s.Mechanism,
+			Username:        s.Username,
+			PasswordSet:     s.Password != "",
+			TokenURL:        s.TokenURL,
+			ClientID:        s.ClientID,
+			ClientSecretSet: s.ClientSecret != "",
+			Scopes:          s.Scopes,
+		}
+	}
+	return resp
+}
+
+// clusters is the registry used by the cluster handlers.
+var clusters *cluster.Registry
+
+// InitializeClusters sets the cluster registry.
+func InitializeClusters(registry *cluster.Registry) {
+	clusters = registry
+}
+
+// brokerMonitor keeps the broker status history shown by GetBrokerHistory.
+var brokerMonitor *cluster.BrokerMonitor
+
+// InitializeBrokerMonitor sets the broker status monitor.
+func InitializeBrokerMonitor(monitor *cluster.BrokerMonitor) {
+	brokerMonitor = monitor
+}
+
+// ListClusters returns all cluster definitions.
+// Response: 200 OK with JSON array of clusters.
+func ListClusters(c *gin.Context) {
+	defs := clusters.List()
+	resp := make([]clusterResponse, 0, len(defs))
+	for _, def := range defs {
+		resp = append(resp, toClusterResponse(def))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCluster returns one cluster definition.
+// Response: 200 OK with the cluster, or 404 Not Found.
+func GetCluster(c *gin.Context) {
+	def, err := clusters.Get(c.Param("cluster"))
+	if err != nil {
+		respondClusterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toClusterResponse(def))
+}
+
+// CreateCluster adds a cluster definition. The connection is opened on first use.
+// Request JSON body:
+//
+//	{
+//	  "name": "<cluster_name>",
+//	  "bootstrapServers": ["<host:port>", ...],
+//	  "sasl": {
+//	    "mechanism": "PLAIN" | "SCRAM-SHA-256" | "SCRAM-SHA-512" | "OAUTHBEARER",
+//	    "username": "<user>", "password": "<password>",
+//	    "tokenUrl": "<url>", "clientId": "<id>", "clientSecret": "<secret>", "scopes": ["<scope>", ...]
+//	  },
+//	  "tls": { "caFile": "<path>", "certFile": "<path>", "keyFile": "<path>", "insecureSkipVerify": false }
+//	}
+//
+// sasl and tls are optional; a present tls object enables TLS.
+// Response: 201 Created with the cluster, 400 Bad Request, 409 Conflict or 500 Internal Server Error.
+func CreateCluster(c *gin.Context) {
+	var req clusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	def, err := clusters.Create(cluster.Definition{
+		Name:             req.Name,
+		BootstrapServers: req.BootstrapServers,
+		SASL:             req.SASL,
+		TLS:              req.TLS,
+	})
+	if err != nil {
+		respondClusterError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toClusterResponse(def))
+}
+
+// UpdateCluster replaces the connection settings of a cluster; its open connection is closed once
+// in-flight requests have finished.
+// The request body is that of CreateCluster without the name. Omitted or empty passwords and
+// client secrets keep their stored values while the SASL mechanism is unchanged.
+// Response: 200 OK with the cluster, 400 Bad Request, 404 Not Found or 500 Internal Server Error.
+func UpdateCluster(c *gin.Context) {
+	var req clusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	def, err := clusters.Update(cluster.Definition{
+		Name:             c.Param("cluster"),
+		BootstrapServers: req.BootstrapServers,
+		SASL:             req.SASL,
+		TLS:              req.TLS,
+	})
+	if err != nil {
+		respondClusterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toClusterResponse(def))
+}
+
+// DeleteCluster removes a cluster definition and closes its connection.
+// Response: 200 OK, 404 Not Found or 500 Internal Server Error.
+func DeleteCluster(c *gin.Context) {
+	if err := clusters.Delete(c.Param("cluster")); err != nil {
+		respondClusterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// GetConnectionPool returns the state of the Kafka client pool. Credentials are never included.
+// Response: 200 OK with { "idleTtl": "10m0s", "opened": n, "closed": n, "failed": n, "connections": [...] }.
+func GetConnectionPool(c *gin.Context) {
+	stats := clusters.PoolStats()
+	c.JSON(http.StatusOK, gin.H{
+		"idleTtl":     stats.IdleTTL.String(),
+		"opened":      stats.Opened,
+		"closed":      stats.Closed,
+		"failed":      stats.Failed,
+		"connections": stats.Connections,
+	})
+}
+
+// GetBrokerHistory returns the recent liveness of each broker of a cluster. It is served without
+// connecting to the cluster, so the history stays available while the cluster is down.
+// Response: 200 OK with { "pollInterval": "30s", "brokers": [{ "id", "address", "lastSeen", "samples": [...] }] },
+// 404 Not Found.
+func GetBrokerHistory(c *gin.Context) {
+	def, err := clusters.Get(c.Param("cluster"))
+	if err != nil {
+		respondClusterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pollInterval": brokerMonitor.Interval().String(),
+		"brokers":      brokerMonitor.History(def.Name),
+	})
+}
+
+// respondClusterError maps registry errors to HTTP responses.
+func respondClusterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, cluster.ErrClusterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cluster not found"})
+	case errors.Is(err, cluster.ErrClusterExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cluster already exists"})
+	case errors.Is(err, cluster.ErrInvalidCluster):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
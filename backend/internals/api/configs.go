@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internals/middleware"
+	"backend/internals/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configs.go - Handles viewing and changing Kafka configs.
+//
+// Endpoints:
+//   - GET /clusters/:cluster/brokers/:id/config: List the configs of a broker with their sources
+//   - PUT /clusters/:cluster/brokers/:id/config: Change dynamic broker configs (admin only)
+//   - GET /clusters/:cluster/topics/:name/config: List the configs of a topic with their defaults
+//   - PUT /clusters/:cluster/topics/:name/config: Change or reset topic config overrides
+
+// configUpdateRequest is the request body of config updates.
+type configUpdateRequest struct {
+	Configs      []models.ConfigChange `json:"configs"`
+	ValidateOnly bool                  `json:"validateOnly"`
+}
+
+// brokerConfigUpdateRequest is the request body of broker config updates.
+type brokerConfigUpdateRequest struct {
+	configUpdateRequest
+	ClusterWide bool `json:"clusterWide"`
+}
+
+// brokerIDParam parses the :id route parameter, responding with 400 Bad Request if it is not a broker ID.
+func brokerIDParam(c *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || id < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid broker ID"})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// GetBrokerConfig returns every config of a broker with its source (default, static, dynamicBroker
+// or dynamicCluster), whether it is read-only or sensitive, and its value at each source.
+// Values of sensitive configs are null.
+// Response: 200 OK with JSON array of configs, 400 Bad Request, 404 Not Found, 500 Internal Server Error
+// or 504 Gateway Timeout.
+func GetBrokerConfig(c *gin.Context) {
+	id, ok := brokerIDParam(c)
+	if !ok {
+		return
+	}
+	configs, err := middleware.KafkaService(c).GetBrokerConfig(c.Request.Context(), id)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, configs)
+}
+
+// UpdateBrokerConfig changes dynamic configs of a broker, or the cluster-wide default of all brokers
+// with clusterWide. With validateOnly the brokers check the changes without applying them.
+// Request JSON body:
+//
+//	{
+//	  "configs": [{ "name": "<config>", "value": "<value>", "operation": "set" | "delete" | "append" | "subtract" }],
+//	  "clusterWide": false,
+//	  "validateOnly": false
+//	}
+//
+// Response: 200 OK with { "validateOnly": bool, "configs": [...] } holding the broker's configs after the
+// change, 400 Bad Request for invalid or rejected changes, 404 Not Found, 501 Not Implemented before
+// Kafka 2.3, 500 Internal Server Error or 504 Gateway Timeout.
+func UpdateBrokerConfig(c *gin.Context) {
+	id, ok := brokerIDParam(c)
+	if !ok {
+		return
+	}
+	var req brokerConfigUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	service := middleware.KafkaService(c)
+	if err := service.AlterBrokerConfig(c.Request.Context(), id, req.ClusterWide, req.Configs, req.ValidateOnly); err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	configs, err := service.GetBrokerConfig(c.Request.Context(), id)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"validateOnly": req.ValidateOnly, "configs": configs})
+}
+
+// GetTopicConfig returns every config of a topic. Overrides have the source "topic"; the other
+// configs carry the broker or Kafka default the topic inherits. defaultValue is the value a config
+// falls back to when its override is reset.
+// Response: 200 OK with JSON array of configs, 404 Not Found, 500 Internal Server Error or 504 Gateway Timeout.
+func GetTopicConfig(c *gin.Context) {
+	configs, err := middleware.KafkaService(c).GetTopicConfig(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, configs)
+}
+
+// UpdateTopicConfig changes config overrides of a topic. Keys and values are validated before they
+// are sent; the "delete" operation resets a config to its default. With validateOnly the brokers check
+// the changes without applying them.
+// Request JSON body:
+//
+//	{
+//	  "configs": [{ "name": "<config>", "value": "<value>", "operation": "set" | "delete" | "append" | "subtract" }],
+//	  "validateOnly": false
+//	}
+//
+// Response: 200 OK with { "validateOnly": bool, "configs": [...] } holding the topic's configs after the
+// change, 400 Bad Request for unknown keys, invalid values or rejected changes, 404 Not Found,
+// 501 Not Implemented for append and subtract before Kafka 2.3, 500 Internal Server Error or
+// 504 Gateway Timeout.
+func UpdateTopicConfig(c *gin.Context) {
+	var req configUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	topic := c.Param("name")
+	service := middleware.KafkaService(c)
+	if err := service.AlterTopicConfig(c.Request.Context(), topic, req.Configs, req.ValidateOnly); err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	configs, err := service.GetTopicConfig(c.Request.Context(), topic)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"validateOnly": req.ValidateOnly, "configs": configs})
+}
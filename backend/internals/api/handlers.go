@@ -2,7 +2,11 @@ package api
 
 import (
 	"backend/internals/kafka"
+	"backend/internals/middleware"
 	"backend/internals/models"
+	"backend/internals/policy"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -11,23 +15,61 @@ import (
 
 // handlers.go - Contains HTTP handler functions for Kafka-related API endpoints.
 // Provides endpoints for managing topics, messages, brokers, consumers, and connection checks.
+// Handlers run under /api/clusters/:cluster and use the client selected by ClusterMiddleware,
+// passing the request context so operations stop at the route's deadline or when the client disconnects.
 
-var kafkaService kafka.KafkaService
-
-// Initialize sets the Kafka service instance for use by all API handlers.
-func Initialize(service kafka.KafkaService) {
-	kafkaService = service
+// respondKafkaError reports a failed Kafka operation: 400 Bad Request for rejected config or partition changes,
+// 404 Not Found for unknown brokers and topics, 501 Not Implemented when the cluster does not
+// support it, 504 Gateway Timeout when the route's deadline passed, 499 when the client went away
+// and 500 Internal Server Error otherwise.
+func respondKafkaError(c *gin.Context, err error) {
+	switch {
+	case kafka.IsInvalidConfig(err), kafka.IsInvalidPartitions(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case kafka.IsNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case kafka.IsUnsupported(err):
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":        err.Error(),
+			"kafkaVersion": middleware.KafkaService(c).Capabilities().KafkaVersion,
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Kafka request timed out"})
+	case errors.Is(err, context.Canceled):
+		c.JSON(middleware.StatusClientClosedRequest, gin.H{"error": "Request canceled"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
-// GetTopics returns a list of all Kafka topics.
-// Response: 200 OK with JSON array of topics, or 500 Internal Server Error.
+// GetTopics returns a list of all Kafka topics the caller may read.
+// Query params:
+//   - withSize: 'true' to include the on-disk size of each topic, which asks every broker (default false)
+//
+// Response: 200 OK with JSON array of topics, 500 Internal Server Error or 504 Gateway Timeout.
 func GetTopics(c *gin.Context) {
-	topics, err := kafkaService.ListTopics()
+	service := middleware.KafkaService(c)
+	topics, err := service.ListTopics(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, topics)
+	if c.Query("withSize") == "true" {
+		if err := addTopicSizes(c.Request.Context(), service, topics); err != nil {
+			respondKafkaError(c, err)
+			return
+		}
+	}
+
+	// Hide topics the caller has no access to under the topic policy
+	subject := policy.SubjectFromContext(c)
+	visible := make([]models.Topic, 0, len(topics))
+	for _, t := range topics {
+		if policy.Allowed(subject, t.Name, policy.ActionRead) {
+			visible = append(visible, t)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
 }
 
 // GetMessages fetches messages from a given topic.
@@ -35,16 +77,16 @@ func GetTopics(c *gin.Context) {
 //   - limit: number of messages to fetch (default 5)
 //   - sort: 'newest' or 'oldest' (default 'newest')
 //
-// Response: 200 OK with JSON array of messages, or 500 Internal Server Error.
+// Response: 200 OK with JSON array of messages, 500 Internal Server Error or 504 Gateway Timeout.
 func GetMessages(c *gin.Context) {
 	topic := c.Param("name")
 	limitStr := c.DefaultQuery("limit", "5")
 	limit, _ := strconv.Atoi(limitStr)
 	sortOrder := c.DefaultQuery("sort", "newest")
 
-	messages, err := kafkaService.FetchMessages(topic, limit, sortOrder)
+	messages, err := middleware.KafkaService(c).FetchMessages(c.Request.Context(), topic, limit, sortOrder)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, messages)
@@ -61,7 +103,7 @@ func GetMessages(c *gin.Context) {
 //	  "headers": [ { "key": "<key>", "value": "<value>" }, ... ]
 //	}
 //
-// Response: 200 OK on success, 400 Bad Request or 500 Internal Server Error on failure.
+// Response: 200 OK on success, 400 Bad Request, 500 Internal Server Error or 504 Gateway Timeout on failure.
 func ProduceMessage(c *gin.Context) {
 	type reqBody struct {
 		Topic     string `json:"topic"`
@@ -79,6 +121,11 @@ func ProduceMessage(c *gin.Context) {
 		return
 	}
 
+	if !policy.Allowed(policy.SubjectFromContext(c), body.Topic, policy.ActionProduce) {
+		middleware.AbortTopicForbidden(c, body.Topic, policy.ActionProduce)
+		return
+	}
+
 	// If partition is -1, let Kafka choose the partition
 	var partition int32 = -1
 	if body.Partition >= 0 {
@@ -94,20 +141,20 @@ func ProduceMessage(c *gin.Context) {
 		}
 	}
 
-	if err := kafkaService.Produce(body.Topic, body.Key, []byte(body.Value), partition, headers); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := middleware.KafkaService(c).Produce(c.Request.Context(), body.Topic, body.Key, []byte(body.Value), partition, headers); err != nil {
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "sent"})
 }
 
-// DeleteMessages clears all messages from a given topic.
-// Response: 200 OK on success, 500 Internal Server Error on failure.
+// DeleteMessages clears all messages from a given topic. Requires DeleteRecords support.
+// Response: 200 OK on success, 500 Internal Server Error, 501 Not Implemented or 504 Gateway Timeout on failure.
 func DeleteMessages(c *gin.Context) {
 	topic := c.Param("name")
 	// Use improved message clearing method
-	if err := kafkaService.ClearTopicMessages(topic); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := middleware.KafkaService(c).ClearTopicMessages(c.Request.Context(), topic); err != nil {
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
@@ -119,15 +166,19 @@ func DeleteMessages(c *gin.Context) {
 //	{
 //	  "name": "<topic_name>",
 //	  "partitions": <num_partitions>,
-//	  "replicationFactor": <replication_factor>
+//	  "replicationFactor": <replication_factor>,
+//	  "configs": { "<config>": "<value>", ... }
 //	}
 //
-// Response: 200 OK on success, 400 Bad Request or 500 Internal Server Error on failure.
+// configs is optional and sets topic config overrides such as cleanup.policy or retention.ms.
+// Response: 200 OK on success, 400 Bad Request for invalid input or configs, 500 Internal Server Error
+// or 504 Gateway Timeout on failure.
 func CreateTopic(c *gin.Context) {
 	type reqBody struct {
-		Name              string `json:"name"`
-		Partitions        int    `json:"partitions"`
-		ReplicationFactor int    `json:"replicationFactor"`
+		Name              string            `json:"name"`
+		Partitions        int               `json:"partitions"`
+		ReplicationFactor int               `json:"replicationFactor"`
+		Configs           map[string]string `json:"configs"`
 	}
 	var body reqBody
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -146,77 +197,98 @@ func CreateTopic(c *gin.Context) {
 		return
 	}
 
-	if err := kafkaService.CreateTopic(body.Name, body.Partitions, body.ReplicationFactor); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !policy.Allowed(policy.SubjectFromContext(c), body.Name, policy.ActionAdmin) {
+		middleware.AbortTopicForbidden(c, body.Name, policy.ActionAdmin)
+		return
+	}
+
+	if err := middleware.KafkaService(c).CreateTopic(c.Request.Context(), body.Name, body.Partitions, body.ReplicationFactor, body.Configs); err != nil {
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
 // GetPartitionInfo returns partition information for a given topic.
-// Response: 200 OK with partition info, or 500 Internal Server Error.
+// Response: 200 OK with partition info, 500 Internal Server Error or 504 Gateway Timeout.
 func GetPartitionInfo(c *gin.Context) {
 	topic := c.Param("name")
-	partitions, err := kafkaService.GetPartitionInfo(topic)
+	partitions, err := middleware.KafkaService(c).GetPartitionInfo(c.Request.Context(), topic)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, partitions)
 }
 
-// GetBrokers returns a list of Kafka brokers.
-// Response: 200 OK with broker list, or 500 Internal Server Error.
+// GetBrokers returns a list of Kafka brokers with their liveness, probed for this request.
+// Partitions of topics the caller may not read are left out. The result is added to the broker status history.
+// Response: 200 OK with broker list, 500 Internal Server Error or 504 Gateway Timeout.
 func GetBrokers(c *gin.Context) {
-	brokers, err := kafkaService.GetBrokers()
+	brokers, err := middleware.KafkaService(c).GetBrokers(c.Request.Context(), readableTopics(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
+	brokerMonitor.Record(c.GetString(middleware.ClusterKey), brokers)
 	c.JSON(http.StatusOK, brokers)
 }
 
-// GetConsumers returns a list of Kafka consumers.
-// Response: 200 OK with consumer list, or 500 Internal Server Error.
+// GetConsumers returns a list of Kafka consumers, leaving out assignments of topics the caller may not read.
+// Response: 200 OK with consumer list, 500 Internal Server Error or 504 Gateway Timeout.
 func GetConsumers(c *gin.Context) {
-	consumers, err := kafkaService.GetConsumers()
+	consumers, err := middleware.KafkaService(c).GetConsumers(c.Request.Context(), readableTopics(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, consumers)
 }
 
-// CheckConnection checks connectivity to a Kafka broker using the provided bootstrap server.
-// Query param: bootstrapServer (required)
-// Response: 200 OK on success, 400 Bad Request or 500 Internal Server Error on failure.
+// readableTopics returns a filter for the topics the caller may read, or nil when no policy is active.
+func readableTopics(c *gin.Context) kafka.TopicFilter {
+	if !policy.Enabled() {
+		return nil
+	}
+	subject := policy.SubjectFromContext(c)
+	return func(topic string) bool { return policy.Allowed(subject, topic, policy.ActionRead) }
+}
+
+// CheckConnection checks connectivity to the brokers of the requested cluster.
+// Response: 200 OK on success, 404 Not Found, 502 Bad Gateway, 500 Internal Server Error or 504 Gateway Timeout on failure.
 func CheckConnection(c *gin.Context) {
-	bootstrapServer := c.Query("bootstrapServer")
-	if bootstrapServer == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bootstrapServer parameter is required"})
+	if err := middleware.KafkaService(c).CheckConnection(c.Request.Context()); err != nil {
+		respondKafkaError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": "connected"})
+}
 
-	brokers := []string{bootstrapServer}
-	client, err := kafka.NewKafkaClient(brokers, nil)
+// GetClusterOverview returns the cluster ID, controller, brokers, rack layout, topic and partition
+// counts and the number of under-replicated, offline and under-min-ISR partitions.
+// Counts and health only cover the topics the caller may read.
+// Response: 200 OK with the overview, 500 Internal Server Error or 504 Gateway Timeout.
+func GetClusterOverview(c *gin.Context) {
+	overview, err := middleware.KafkaService(c).GetClusterOverview(c.Request.Context(), readableTopics(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	if err := client.CheckConnection(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondKafkaError(c, err)
 		return
 	}
-	kafkaService = client
-	c.JSON(http.StatusOK, gin.H{"status": "connected"})
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetCapabilities reports the negotiated Kafka version and which optional features the cluster supports.
+// Response: 200 OK with { "kafkaVersion": "<version>", "features": { "<feature>": true|false, ... } }.
+func GetCapabilities(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.KafkaService(c).Capabilities())
 }
 
 // DeleteTopic deletes a Kafka topic by name.
-// Response: 200 OK on success, 500 Internal Server Error on failure.
+// Response: 200 OK on success, 500 Internal Server Error or 504 Gateway Timeout on failure.
 func DeleteTopic(c *gin.Context) {
 	topic := c.Param("name")
-	if err := kafkaService.DeleteTopic(topic); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := middleware.KafkaService(c).DeleteTopic(c.Request.Context(), topic); err != nil {
+		respondKafkaError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
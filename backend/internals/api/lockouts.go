@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lockouts.go - Handles admin-only endpoints for login lockouts.
+//
+// Endpoints:
+//   - GET /login-lockouts: List locked usernames and throttled client IPs
+//   - DELETE /login-lockouts/users/:username: Unlock a username and reset its failures
+//   - DELETE /login-lockouts/ips/:ip: Reset the failures of a client IP
+
+// ListLoginLockouts returns the usernames and client IPs currently blocked from logging in.
+// Response: 200 OK with { "users": [...], "ips": [...] }.
+func ListLoginLockouts(c *gin.Context) {
+	users, ips := loginGuard.Lockouts()
+	c.JSON(http.StatusOK, gin.H{"users": users, "ips": ips})
+}
+
+// ClearUserLockout unlocks a username and resets its failed attempts.
+// Response: 200 OK or 404 Not Found.
+func ClearUserLockout(c *gin.Context) {
+	if !loginGuard.ClearUser(c.Param("username")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No failed logins recorded for this user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "cleared"})
+}
+
+// ClearIPLockout resets the failed attempts recorded for a client IP.
+// Response: 200 OK or 404 Not Found.
+func ClearIPLockout(c *gin.Context) {
+	if !loginGuard.ClearIP(c.Param("ip")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No failed logins recorded for this address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "cleared"})
+}
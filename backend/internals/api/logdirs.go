@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"backend/internals/kafka"
+	"backend/internals/middleware"
+	"backend/internals/models"
+	"backend/internals/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logdirs.go - Handles disk usage reporting.
+// Topics the caller may not read under the topic policy are left out of every response.
+//
+// Endpoints:
+//   - GET /clusters/:cluster/log-dirs: Size per broker, log directory, topic and partition
+//   - GET /clusters/:cluster/largest-topics: Topics ordered by size on disk
+
+// defaultLargestTopics is the number of topics returned by GetLargestTopics without a limit.
+const defaultLargestTopics = 10
+
+// GetLogDirs returns the log directories of every broker with the size of each topic and partition
+// and the offset lag of each log. Brokers that could not be described carry an error.
+// Response: 200 OK with JSON array of brokers, 501 Not Implemented before Kafka 1.0,
+// 500 Internal Server Error or 504 Gateway Timeout.
+func GetLogDirs(c *gin.Context) {
+	logDirs, err := middleware.KafkaService(c).GetLogDirs(c.Request.Context())
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+
+	subject := policy.SubjectFromContext(c)
+	for i := range logDirs {
+		for j := range logDirs[i].LogDirs {
+			dir := &logDirs[i].LogDirs[j]
+			visible := make([]models.LogDirTopic, 0, len(dir.Topics))
+			for _, topic := range dir.Topics {
+				if policy.Allowed(subject, topic.Topic, policy.ActionRead) {
+					visible = append(visible, topic)
+				}
+			}
+			dir.Topics = visible
+		}
+	}
+	c.JSON(http.StatusOK, logDirs)
+}
+
+// GetLargestTopics returns the topics using the most disk space over all replicas.
+// Query parameters: limit (default 10).
+// Response: 200 OK with { "topics": [...], "missingBrokers": [<id>, ...] } where missingBrokers lists
+// the brokers whose logs are not counted because they did not answer, 400 Bad Request,
+// 501 Not Implemented before Kafka 1.0, 500 Internal Server Error or 504 Gateway Timeout.
+func GetLargestTopics(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLargestTopics)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+		return
+	}
+	logDirs, err := middleware.KafkaService(c).GetLogDirs(c.Request.Context())
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+
+	sizes, missing := kafka.SumTopicSizes(logDirs)
+	subject := policy.SubjectFromContext(c)
+	topics := make([]models.TopicSize, 0, limit)
+	for _, size := range sizes {
+		if len(topics) == limit {
+			break
+		}
+		if policy.Allowed(subject, size.Topic, policy.ActionRead) {
+			topics = append(topics, size)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"topics": topics, "missingBrokers": missing})
+}
+
+// addTopicSizes sets the on-disk size of each topic. Sizes are left null when the cluster cannot
+// report them or a broker did not answer, since a partial sum would understate them; only an
+// expired deadline or a canceled request is returned as an error.
+func addTopicSizes(ctx context.Context, service kafka.KafkaService, topics []models.Topic) error {
+	logDirs, err := service.GetLogDirs(ctx)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return nil
+	}
+	totals, missing := kafka.SumTopicSizes(logDirs)
+	if len(missing) > 0 {
+		return nil
+	}
+	sizes := make(map[string]int64, len(totals))
+	for _, total := range totals {
+		sizes[total.Topic] = total.SizeBytes
+	}
+	for i := range topics {
+		if size, ok := sizes[topics[i].Name]; ok {
+			topics[i].SizeBytes = &size
+		}
+	}
+	return nil
+}
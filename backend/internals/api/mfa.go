@@ -0,0 +1,362 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"backend/internals/audit"
+	"backend/internals/auth"
+	"backend/internals/store"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mfa.go - Handles TOTP two-factor authentication for local accounts.
+//
+// Endpoints:
+//   - POST /login/mfa: Second login step; exchange a challenge token and a code for session tokens
+//   - POST /login/mfa/enroll: Start enrollment during login when the role requires 2FA
+//   - GET /mfa: Your 2FA status
+//   - POST /mfa/enroll: Start enrollment; returns the secret and otpauth URI
+//   - POST /mfa/activate: Confirm enrollment with a code; returns recovery codes
+//   - POST /mfa/recovery-codes: Replace your recovery codes (requires a code)
+//   - POST /mfa/disable: Turn off 2FA (requires a code)
+//   - GET /mfa/policy, PUT /mfa/policy: Roles that must use 2FA (admin only)
+//   - DELETE /users/:username/mfa: Reset a user's 2FA, e.g. after a lost device (admin only)
+
+// startMFAChallenge answers the password step of a login with a 2FA challenge if the user has 2FA
+// enabled or their role requires it. Returns false if the login can complete without a second step.
+func startMFAChallenge(c *gin.Context, identity *auth.Identity) bool {
+	status, err := auth.GetMFAStatus(identity.Username, identity.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if !status.Enabled && !status.Required {
+		return false
+	}
+
+	challenge, err := auth.IssueMFAChallenge(identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return true
+	}
+	if status.Enabled {
+		c.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaToken": challenge})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"mfaEnrollmentRequired": true, "mfaToken": challenge})
+	}
+	return true
+}
+
+// LoginMFA completes a login that requires a second factor.
+// For users with 2FA enabled the code may be a TOTP code or a recovery code. For users whose role
+// requires 2FA but who have not set it up, the code confirms the enrollment started with
+// LoginMFAEnroll and the response also contains their recovery codes.
+//
+// Request JSON body:
+//
+//	{
+//	  "mfaToken": "<challenge from /login>",
+//	  "code": "<totp or recovery code>"
+//	}
+//
+// Response:
+//
+//	200 OK: { "token": "<jwt_token>", "refreshToken": "<refresh_token>", "expiresIn": <seconds>, "recoveryCodes": [...] }
+//	400 Bad Request: { "error": "Invalid request body" }
+//	401 Unauthorized: { "error": "Invalid authentication code" / "Invalid or expired challenge" }
+//	429 Too Many Requests: { "error": "...", "retryAfter": <seconds> }
+func LoginMFA(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfaToken"`
+		Code     string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	identity, err := auth.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+	c.Set("user", identity.Username)
+
+	// Codes are short, so guess attempts count against the same limits as passwords
+	clientIP := c.ClientIP()
+	var blocked *auth.LoginBlockedError
+	if err := loginGuard.Check(clientIP, identity.Username); errors.As(err, &blocked) {
+		respondLoginBlocked(c, blocked)
+		return
+	}
+
+	var recoveryCodes []string
+	enabled, err := auth.MFAEnabled(identity.Username)
+	if err == nil {
+		if enabled {
+			err = auth.VerifyMFA(identity.Username, req.Code)
+		} else {
+			recoveryCodes, err = auth.ActivateMFA(identity.Username, req.Code)
+		}
+	}
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode), errors.Is(err, auth.ErrMFANotPending):
+		c.Set(audit.ReasonKey, "invalid authentication code")
+		if loginGuard.RecordFailure(clientIP, identity.Username) {
+			log.Printf("Login for %q locked after repeated failures from %s", identity.Username, clientIP)
+			c.Set(audit.ReasonKey, "invalid authentication code, account locked")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	loginGuard.RecordSuccess(identity.Username)
+
+	tokens, err := auth.IssueTokens(identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	resp := gin.H{"token": tokens.AccessToken, "refreshToken": tokens.RefreshToken, "expiresIn": tokens.ExpiresIn}
+	if recoveryCodes != nil {
+		resp["recoveryCodes"] = recoveryCodes
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// LoginMFAEnroll starts 2FA enrollment for a user whose role requires it, using the challenge
+// token from the password step since the user cannot log in yet.
+//
+// Request JSON body:
+//
+//	{
+//	  "mfaToken": "<challenge from /login>"
+//	}
+//
+// Response: 200 OK with { "secret": "<base32>", "otpauthUri": "otpauth://..." }, 400 Bad Request,
+// 401 Unauthorized or 409 Conflict if 2FA is already enabled.
+func LoginMFAEnroll(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfaToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	identity, err := auth.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+	c.Set("user", identity.Username)
+	beginMFAEnrollment(c, identity.Username)
+}
+
+// GetMFAStatus returns the 2FA status of the current user.
+// Response: 200 OK with { "enabled": bool, "required": bool, "recoveryCodesRemaining": n }.
+func GetMFAStatus(c *gin.Context) {
+	username, role, ok := localMFAUser(c)
+	if !ok {
+		return
+	}
+	status, err := auth.GetMFAStatus(username, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollMFA starts 2FA enrollment for the current user. The returned secret only becomes active
+// once confirmed with ActivateMFA.
+// Response: 200 OK with { "secret": "<base32>", "otpauthUri": "otpauth://..." } or 409 Conflict if already enabled.
+func EnrollMFA(c *gin.Context) {
+	username, _, ok := localMFAUser(c)
+	if !ok {
+		return
+	}
+	beginMFAEnrollment(c, username)
+}
+
+func beginMFAEnrollment(c *gin.Context, username string) {
+	secret, uri, err := auth.BeginMFAEnrollment(username)
+	switch {
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"secret": secret, "otpauthUri": uri})
+	}
+}
+
+// ActivateMFA confirms enrollment with a code from the authenticator app and enables 2FA.
+// Request JSON body: { "code": "<totp code>" }
+// Response: 200 OK with { "recoveryCodes": [...] }, 400 Bad Request or 401 Unauthorized for a wrong code.
+func ActivateMFA(c *gin.Context) {
+	username, _, ok := localMFAUser(c)
+	if !ok {
+		return
+	}
+	code, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+	codes, err := auth.ActivateMFA(username, code)
+	switch {
+	case errors.Is(err, auth.ErrMFANotPending):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start enrollment first"})
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+	}
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user.
+// Request JSON body: { "code": "<totp or recovery code>" }
+// Response: 200 OK with { "recoveryCodes": [...] }, 400 Bad Request or 401 Unauthorized for a wrong code.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	username, _, ok := localMFAUser(c)
+	if !ok {
+		return
+	}
+	code, ok := bindMFACode(c)
+	if !ok || !verifyMFACode(c, username, code) {
+		return
+	}
+	codes, err := auth.RegenerateRecoveryCodes(username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DisableMFA turns off 2FA for the current user unless their role requires it.
+// Request JSON body: { "code": "<totp or recovery code>" }
+// Response: 200 OK, 400 Bad Request, 401 Unauthorized for a wrong code or 403 Forbidden if required.
+func DisableMFA(c *gin.Context) {
+	username, role, ok := localMFAUser(c)
+	if !ok {
+		return
+	}
+	code, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+	status, err := auth.GetMFAStatus(username, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if status.Required {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role"})
+		return
+	}
+	if !verifyMFACode(c, username, code) {
+		return
+	}
+	if err := auth.DisableMFA(username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "disabled"})
+}
+
+// GetMFAPolicy returns the roles that must use 2FA.
+// Response: 200 OK with { "requiredRoles": [...] }.
+func GetMFAPolicy(c *gin.Context) {
+	roles, err := auth.MFARequiredRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requiredRoles": roles})
+}
+
+// UpdateMFAPolicy sets the roles that must use 2FA. Affected users without 2FA are asked to
+// enroll at their next login.
+// Request JSON body: { "requiredRoles": ["operator", "admin"] }
+// Response: 200 OK with { "requiredRoles": [...] } or 400 Bad Request.
+func UpdateMFAPolicy(c *gin.Context) {
+	var req struct {
+		RequiredRoles []string `json:"requiredRoles"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := auth.SetMFARequiredRoles(req.RequiredRoles); err != nil {
+		if errors.Is(err, utils.ErrInvalidRole) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	GetMFAPolicy(c)
+}
+
+// ResetUserMFA removes the 2FA setup of a user so they can enroll again.
+// Response: 200 OK or 404 Not Found if the user has no 2FA setup.
+func ResetUserMFA(c *gin.Context) {
+	err := auth.DisableMFA(c.Param("username"))
+	switch {
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Two-factor authentication is not enabled for this user"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "reset"})
+	}
+}
+
+// localMFAUser returns the current user if 2FA applies to them, i.e. they are a local account.
+// Otherwise it writes a 400 response and returns false.
+func localMFAUser(c *gin.Context) (username, role string, ok bool) {
+	user, _ := c.Get("user")
+	username, _ = user.(string)
+	if isLocalSession(c) {
+		if u, err := store.Users().GetUser(username); err == nil {
+			return u.Username, u.Role, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is only available for local accounts"})
+	return "", "", false
+}
+
+// bindMFACode reads { "code": "..." } from the request body.
+func bindMFACode(c *gin.Context) (string, bool) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return "", false
+	}
+	return req.Code, true
+}
+
+// verifyMFACode checks a code of the current user, writing an error response if it is wrong.
+func verifyMFACode(c *gin.Context, username, code string) bool {
+	err := auth.VerifyMFA(username, code)
+	switch {
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		return true
+	}
+	return false
+}
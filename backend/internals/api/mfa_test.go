@@ -0,0 +1,110 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend/internals/auth"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// totpNow computes the current 6-digit RFC 6238 code of a base32 secret, as an authenticator app would.
+func totpNow(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestLoginRequiredMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hash, err := utils.HashPassword("secret-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useTestUsers(t, "username,password,role,disabled\n"+
+		"olivia,"+hash+",operator,false\n"+
+		"victor,"+hash+",viewer,false\n")
+	// Wrong codes are counted but do not delay the following attempts
+	guardConfig := auth.DefaultLoginGuardConfig()
+	guardConfig.BaseDelay = 0
+	loginGuard = auth.NewLoginGuard(guardConfig)
+	if err := auth.SetMFARequiredRoles([]string{utils.RoleOperator, utils.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	// The 2FA state is cached by the auth package beyond this test's store
+	t.Cleanup(func() {
+		auth.SetMFARequiredRoles(nil)
+		auth.DisableMFA("olivia")
+	})
+
+	call := func(handler gin.HandlerFunc, body string) (int, map[string]interface{}) {
+		t.Helper()
+		w := serveAs(handler, "", "", "", body)
+		resp := map[string]interface{}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("response %q: %v", w.Body.String(), err)
+		}
+		return w.Code, resp
+	}
+
+	// Roles without the requirement log in with the password alone
+	code, resp := call(Login, `{"username": "victor", "password": "secret-pass"}`)
+	if code != http.StatusOK || resp["token"] == nil {
+		t.Fatalf("viewer login: %d %v, want tokens", code, resp)
+	}
+
+	// An operator without 2FA must enroll before getting a session
+	code, resp = call(Login, `{"username": "olivia", "password": "secret-pass"}`)
+	if code != http.StatusOK || resp["mfaEnrollmentRequired"] != true || resp["token"] != nil {
+		t.Fatalf("operator login: %d %v, want an enrollment challenge", code, resp)
+	}
+	challenge, _ := resp["mfaToken"].(string)
+	code, resp = call(LoginMFAEnroll, `{"mfaToken": "`+challenge+`"}`)
+	if code != http.StatusOK {
+		t.Fatalf("enrollment: %d %v", code, resp)
+	}
+	secret, _ := resp["secret"].(string)
+	if code, resp := call(LoginMFA, `{"mfaToken": "`+challenge+`", "code": "000000"}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong code: %d %v, want 401", code, resp)
+	}
+	code, resp = call(LoginMFA, `{"mfaToken": "`+challenge+`", "code": "`+totpNow(t, secret)+`"}`)
+	if code != http.StatusOK || resp["token"] == nil || resp["recoveryCodes"] == nil {
+		t.Fatalf("activation: %d %v, want tokens and recovery codes", code, resp)
+	}
+	recoveryCodes, _ := resp["recoveryCodes"].([]interface{})
+
+	// From now on the password step asks for a code, and a recovery code completes it once
+	code, resp = call(Login, `{"username": "olivia", "password": "secret-pass"}`)
+	if code != http.StatusOK || resp["mfaRequired"] != true || resp["token"] != nil {
+		t.Fatalf("login with 2FA: %d %v, want a challenge", code, resp)
+	}
+	challenge, _ = resp["mfaToken"].(string)
+	body := `{"mfaToken": "` + challenge + `", "code": "` + recoveryCodes[0].(string) + `"}`
+	if code, resp := call(LoginMFA, body); code != http.StatusOK || resp["token"] == nil || resp["recoveryCodes"] != nil {
+		t.Errorf("recovery code: %d %v, want tokens only", code, resp)
+	}
+	if code, resp := call(LoginMFA, body); code != http.StatusUnauthorized {
+		t.Errorf("reused recovery code: %d %v, want 401", code, resp)
+	}
+	if code, resp := call(LoginMFA, `{"mfaToken": "forged", "code": "`+totpNow(t, secret)+`"}`); code != http.StatusUnauthorized {
+		t.Errorf("forged challenge: %d %v, want 401", code, resp)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"backend/internals/auth"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidc.go - Handles OpenID Connect login endpoints.
+// The browser is sent to the identity provider and returns to the callback, which issues the same
+// session JWT as POST /login and hands it to the frontend in the URL fragment.
+//
+// Endpoints:
+//   - GET /auth/providers: List the enabled login methods
+//   - GET /auth/oidc/login: Redirect to the identity provider
+//   - GET /auth/oidc/callback: Complete the authorization-code flow
+
+const oidcStateCookie = "oidc_state"
+
+var oidcProvider *auth.OIDCProvider
+
+// InitializeOIDC sets the OIDC provider used by the login endpoints. A nil provider disables OIDC login.
+func InitializeOIDC(provider *auth.OIDCProvider) {
+	oidcProvider = provider
+}
+
+// GetAuthProviders reports which login methods are available.
+// Response: 200 OK: { "local": true, "oidc": <bool> }
+func GetAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"local": true, "oidc": oidcProvider != nil})
+}
+
+// OIDCLogin starts the authorization-code flow by redirecting to the identity provider.
+// Response: 302 Found, 404 Not Found if OIDC is disabled, or 502 Bad Gateway if the provider is unreachable.
+func OIDCLogin(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not enabled"})
+		return
+	}
+
+	state, err := auth.RandomString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	nonce, err := auth.RandomString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	redirectURL, err := oidcProvider.AuthCodeURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		log.Printf("OIDC login failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	// Bind the state and nonce to this browser for the callback
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state+"."+nonce, 600, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// OIDCCallback completes the authorization-code flow and redirects to the frontend with a session token.
+// Query params: code, state (or error from the identity provider)
+// Response: 302 Found to the frontend with "#token=<jwt>&refreshToken=<token>" or "#error=<message>".
+func OIDCCallback(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not enabled"})
+		return
+	}
+
+	cookie, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		redirectToFrontend(c, url.Values{"error": {idpErr}})
+		return
+	}
+
+	state, nonce, ok := strings.Cut(cookie, ".")
+	if !ok || state == "" || c.Query("state") != state {
+		redirectToFrontend(c, url.Values{"error": {"Invalid login state"}})
+		return
+	}
+
+	identity, err := oidcProvider.Exchange(c.Request.Context(), c.Query("code"), nonce)
+	if err != nil {
+		log.Printf("OIDC callback failed: %v", err)
+		if err == auth.ErrNoRoleMapped {
+			redirectToFrontend(c, url.Values{"error": {"Your account has no access to this application"}})
+			return
+		}
+		redirectToFrontend(c, url.Values{"error": {"Login failed"}})
+		return
+	}
+
+	c.Set("user", identity.Username)
+	tokens, err := auth.IssueTokens(identity)
+	if err != nil {
+		redirectToFrontend(c, url.Values{"error": {"Failed to generate token"}})
+		return
+	}
+	redirectToFrontend(c, url.Values{"token": {tokens.AccessToken}, "refreshToken": {tokens.RefreshToken}})
+}
+
+// redirectToFrontend sends the browser back to the frontend with values in the URL fragment,
+// which is never sent to servers or written to access logs.
+func redirectToFrontend(c *gin.Context, values url.Values) {
+	target := os.Getenv(utils.OIDCPostLoginRedirectEnv)
+	if target == "" {
+		target = utils.DefaultOIDCPostLoginRedirect
+	}
+	c.Redirect(http.StatusFound, target+"#"+values.Encode())
+}
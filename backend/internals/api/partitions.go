@@ -0,0 +1,340 @@
+package api
+
+import (
+	"net/http"
+
+	"backend/internals/middleware"
+	"backend/internals/models"
+	"backend/internals/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// partitions.go - Handles changes to the partitions of topics.
+//
+// Endpoints:
+//   - POST /clusters/:cluster/topics/:name/partitions: Raise the partition count of a topic
+//   - POST /clusters/:cluster/reassignments/plan: Plan moving the replicas of topics onto brokers
+//   - POST /clusters/:cluster/reassignments: Start reassignments (admin only)
+//   - GET /clusters/:cluster/reassignments: List reassignments in progress
+//   - POST /clusters/:cluster/reassignments/cancel: Cancel reassignments (admin only)
+//   - DELETE /clusters/:cluster/reassignments/throttle: Remove the replication throttle (admin only)
+//   - POST /clusters/:cluster/leader-elections/preferred: Move leadership back to the preferred replicas
+//   - POST /clusters/:cluster/leader-elections/unclean: Elect out-of-sync leaders for offline partitions (admin only)
+
+// AddPartitions raises the partition count of a topic to partitionCount. assignment optionally lists
+// the replica broker IDs of each new partition, preferred leader first. With validateOnly the
+// controller checks the change without applying it. The response warns when recent messages have
+// keys, since adding partitions moves most keys to other partitions; callers that have already
+// seen that warning, e.g. from a validateOnly run, set keysAcknowledged to skip the check.
+// Request JSON body:
+//
+//	{
+//	  "partitionCount": <new_total>,
+//	  "assignment": [[<broker_id>, ...], ...],
+//	  "validateOnly": false,
+//	  "keysAcknowledged": false
+//	}
+//
+// Response: 200 OK with the partition counts, sampled key counts and warnings, 400 Bad Request,
+// 404 Not Found, 501 Not Implemented before Kafka 1.0, 500 Internal Server Error or 504 Gateway Timeout.
+func AddPartitions(c *gin.Context) {
+	var req struct {
+		PartitionCount   int       `json:"partitionCount"`
+		Assignment       [][]int32 `json:"assignment"`
+		ValidateOnly     bool      `json:"validateOnly"`
+		KeysAcknowledged bool      `json:"keysAcknowledged"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.PartitionCount < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "partitionCount must be at least 1"})
+		return
+	}
+
+	result, err := middleware.KafkaService(c).AddPartitions(c.Request.Context(), c.Param("name"), req.PartitionCount, req.Assignment, req.ValidateOnly, !req.KeysAcknowledged)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PlanReassignment proposes new replicas for every partition of topics so that they only use the
+// given brokers, e.g. to empty a broker before maintenance. Replicas already on one of the brokers
+// stay in place and the others go to the least loaded broker, spread over racks when every broker
+// has one. Nothing is changed; the plan's partitions can be passed to ExecuteReassignment as they are.
+// Request JSON body:
+//
+//	{
+//	  "topics": ["<topic>", ...],
+//	  "brokers": [<broker_id>, ...]
+//	}
+//
+// Response: 200 OK with the plan, 400 Bad Request, 403 Forbidden for topics the caller may not read,
+// 404 Not Found, 500 Internal Server Error or 504 Gateway Timeout.
+func PlanReassignment(c *gin.Context) {
+	var req struct {
+		Topics  []string `json:"topics"`
+		Brokers []int32  `json:"brokers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	subject := policy.SubjectFromContext(c)
+	for _, topic := range req.Topics {
+		if !policy.Allowed(subject, topic, policy.ActionRead) {
+			middleware.AbortTopicForbidden(c, topic, policy.ActionRead)
+			return
+		}
+	}
+
+	plan, err := middleware.KafkaService(c).PlanReassignment(c.Request.Context(), req.Topics, req.Brokers)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// ExecuteReassignment starts moving partitions to new replicas. throttleBytesPerSec optionally limits
+// the replication traffic of the moved partitions on every broker involved; the throttle stays in
+// place until it is removed with RemoveReplicationThrottle. The caller must be allowed to administer
+// every topic moved.
+// Request JSON body:
+//
+//	{
+//	  "partitions": [{ "topic": "<topic>", "partition": <id>, "replicas": [<broker_id>, ...] }],
+//	  "throttleBytesPerSec": 0
+//	}
+//
+// Response: 200 OK with { "partitions": [{ "topic", "partition", "error" }], "throttled": bool },
+// 400 Bad Request, 403 Forbidden, 404 Not Found, 501 Not Implemented before Kafka 2.4,
+// 500 Internal Server Error or 504 Gateway Timeout.
+func ExecuteReassignment(c *gin.Context) {
+	var req struct {
+		Partitions          []models.PartitionMove `json:"partitions"`
+		ThrottleBytesPerSec int64                  `json:"throttleBytesPerSec"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	subject := policy.SubjectFromContext(c)
+	for _, move := range req.Partitions {
+		if !policy.Allowed(subject, move.Topic, policy.ActionAdmin) {
+			middleware.AbortTopicForbidden(c, move.Topic, policy.ActionAdmin)
+			return
+		}
+	}
+
+	results, err := middleware.KafkaService(c).ExecuteReassignment(c.Request.Context(), req.Partitions, req.ThrottleBytesPerSec)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"partitions": results, "throttled": req.ThrottleBytesPerSec > 0})
+}
+
+// GetReassignments lists the reassignments in progress with their adding and removing replicas.
+// progress is the share of the leader's log the slowest new replica holds, when the cluster reports
+// its log directories. Topics the caller may not read are left out.
+// Response: 200 OK with JSON array of reassignments, 501 Not Implemented before Kafka 2.4,
+// 500 Internal Server Error or 504 Gateway Timeout.
+func GetReassignments(c *gin.Context) {
+	reassignments, err := middleware.KafkaService(c).ListReassignments(c.Request.Context())
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	subject := policy.SubjectFromContext(c)
+	visible := make([]models.PartitionReassignment, 0, len(reassignments))
+	for _, reassignment := range reassignments {
+		if policy.Allowed(subject, reassignment.Topic, policy.ActionRead) {
+			visible = append(visible, reassignment)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
+
+// CancelReassignments cancels the reassignments of the listed partitions, or without a body every
+// reassignment in progress of the topics the caller may administer. Cancelled partitions return to
+// their original replicas.
+// Request JSON body (optional):
+//
+//	{
+//	  "partitions": [{ "topic": "<topic>", "partition": <id> }]
+//	}
+//
+// Response: 200 OK with { "partitions": [{ "topic", "partition", "error" }] }, 400 Bad Request,
+// 403 Forbidden, 501 Not Implemented before Kafka 2.4, 500 Internal Server Error or 504 Gateway Timeout.
+func CancelReassignments(c *gin.Context) {
+	var req struct {
+		Partitions []struct {
+			Topic     string `json:"topic"`
+			Partition int32  `json:"partition"`
+		} `json:"partitions"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	service := middleware.KafkaService(c)
+	subject := policy.SubjectFromContext(c)
+	partitions := map[string][]int32{}
+	for _, partition := range req.Partitions {
+		partitions[partition.Topic] = append(partitions[partition.Topic], partition.Partition)
+	}
+	for topic := range partitions {
+		if !policy.Allowed(subject, topic, policy.ActionAdmin) {
+			middleware.AbortTopicForbidden(c, topic, policy.ActionAdmin)
+			return
+		}
+	}
+	if len(partitions) == 0 && policy.Enabled() {
+		// Limit cancelling everything to the topics the caller may administer
+		reassignments, err := service.ListReassignments(c.Request.Context())
+		if err != nil {
+			respondKafkaError(c, err)
+			return
+		}
+		for _, reassignment := range reassignments {
+			if policy.Allowed(subject, reassignment.Topic, policy.ActionAdmin) {
+				partitions[reassignment.Topic] = append(partitions[reassignment.Topic], reassignment.Partition)
+			}
+		}
+		if len(partitions) == 0 {
+			c.JSON(http.StatusOK, gin.H{"partitions": []models.PartitionResult{}})
+			return
+		}
+	}
+
+	results, err := service.CancelReassignments(c.Request.Context(), partitions)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"partitions": results})
+}
+
+// RemoveReplicationThrottle removes the replication throttle set when reassignments were started, from
+// every broker and topic. Call it once the reassignments are complete.
+// Response: 200 OK with { "topics": [...] } listing the topics that had throttled replicas,
+// 501 Not Implemented before Kafka 2.3, 500 Internal Server Error or 504 Gateway Timeout.
+func RemoveReplicationThrottle(c *gin.Context) {
+	topics, err := middleware.KafkaService(c).RemoveReplicationThrottle(c.Request.Context())
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"topics": topics})
+}
+
+// electionRequest selects the partitions of a leader election: every partition of topics plus the
+// listed partitions, or every partition the caller may administer when both are empty.
+type electionRequest struct {
+	Topics     []string `json:"topics"`
+	Partitions []struct {
+		Topic     string `json:"topic"`
+		Partition int32  `json:"partition"`
+	} `json:"partitions"`
+	Confirm bool `json:"confirm"`
+}
+
+// ElectPreferredLeaders moves the leadership of partitions back to their preferred replica, the first
+// one listed, e.g. when leadership is skewed after a broker restart.
+// Request JSON body (optional, every partition without it):
+//
+//	{
+//	  "topics": ["<topic>", ...],
+//	  "partitions": [{ "topic": "<topic>", "partition": <id> }]
+//	}
+//
+// Response: 200 OK with { "partitions": [{ "topic", "partition", "elected", "error" }] }, 400 Bad Request,
+// 403 Forbidden, 404 Not Found, 501 Not Implemented before Kafka 2.4, 500 Internal Server Error or
+// 504 Gateway Timeout.
+func ElectPreferredLeaders(c *gin.Context) {
+	var req electionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	electLeaders(c, req, false)
+}
+
+// ElectUncleanLeaders elects a leader among the out-of-sync replicas of partitions that have no
+// in-sync replica left. The new leader lacks the messages it had not copied yet, which are lost, so
+// confirm must be true. Partitions that have a leader are left alone.
+// Request JSON body:
+//
+//	{
+//	  "topics": ["<topic>", ...],
+//	  "partitions": [{ "topic": "<topic>", "partition": <id> }],
+//	  "confirm": true
+//	}
+//
+// Response: 200 OK with { "partitions": [{ "topic", "partition", "elected", "error" }] }, 400 Bad Request,
+// 403 Forbidden, 404 Not Found, 501 Not Implemented before Kafka 2.4, 500 Internal Server Error or
+// 504 Gateway Timeout.
+func ElectUncleanLeaders(c *gin.Context) {
+	var req electionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unclean leader elections can lose messages; set confirm to true to run one"})
+		return
+	}
+	electLeaders(c, req, true)
+}
+
+// electLeaders runs the election selected by req after checking the caller may administer its topics.
+func electLeaders(c *gin.Context, req electionRequest, unclean bool) {
+	service := middleware.KafkaService(c)
+	subject := policy.SubjectFromContext(c)
+	partitions := map[string][]int32{}
+	for _, partition := range req.Partitions {
+		partitions[partition.Topic] = append(partitions[partition.Topic], partition.Partition)
+	}
+	for _, topic := range req.Topics {
+		partitions[topic] = nil // All partitions of the topic
+	}
+	for topic := range partitions {
+		if !policy.Allowed(subject, topic, policy.ActionAdmin) {
+			middleware.AbortTopicForbidden(c, topic, policy.ActionAdmin)
+			return
+		}
+	}
+	if len(partitions) == 0 && policy.Enabled() {
+		// Limit a cluster-wide election to the topics the caller may administer
+		topics, err := service.ListTopics(c.Request.Context())
+		if err != nil {
+			respondKafkaError(c, err)
+			return
+		}
+		for _, topic := range topics {
+			if policy.Allowed(subject, topic.Name, policy.ActionAdmin) {
+				partitions[topic.Name] = nil
+			}
+		}
+		if len(partitions) == 0 {
+			c.JSON(http.StatusOK, gin.H{"partitions": []models.LeaderElection{}})
+			return
+		}
+	}
+
+	elections, err := service.ElectLeaders(c.Request.Context(), partitions, unclean)
+	if err != nil {
+		respondKafkaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"partitions": elections})
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internals/auth"
+	"backend/internals/store"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// users.go - Handles admin-only user management endpoints.
+//
+// Endpoints:
+//   - GET /users: List users
+//   - POST /users: Create a user
+//   - PUT /users/:username: Change a user's role or disabled flag
+//   - DELETE /users/:username: Delete a user
+//   - POST /users/:username/reset-password: Set a new password for a user
+//   - DELETE /users/:username/mfa: Reset a user's two-factor authentication (see mfa.go)
+
+// userResponse is the public representation of a user; it never includes the password hash.
+type userResponse struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+func toUserResponse(u store.User) userResponse {
+	return userResponse{Username: u.Username, Role: u.Role, Disabled: u.Disabled}
+}
+
+// ListUsers returns all users.
+// Response: 200 OK with JSON array of users, or 500 Internal Server Error.
+func ListUsers(c *gin.Context) {
+	users, err := store.Users().ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := make([]userResponse, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, toUserResponse(u))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateUser creates a new local user.
+// Request JSON body:
+//
+//	{
+//	  "username": "<username>",
+//	  "password": "<password>",
+//	  "role": "viewer" | "producer" | "operator" | "admin"
+//	}
+//
+// Response: 201 Created, 400 Bad Request, 409 Conflict or 500 Internal Server Error.
+func CreateUser(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and password are required"})
+		return
+	}
+	if err := utils.ValidateUsername(req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = utils.RoleViewer
+	}
+
+	if err := store.Users().CreateUser(req.Username, req.Password, req.Role); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		case errors.Is(err, utils.ErrUserExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, userResponse{Username: req.Username, Role: req.Role})
+}
+
+// UpdateUser changes the role and/or disabled flag of a user.
+// Request JSON body (all fields optional):
+//
+//	{
+//	  "role": "<role>",
+//	  "disabled": true | false
+//	}
+//
+// Response: 200 OK, 400 Bad Request, 404 Not Found or 500 Internal Server Error.
+func UpdateUser(c *gin.Context) {
+	username := c.Param("username")
+	var req struct {
+		Role     *string `json:"role"`
+		Disabled *bool   `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Admins cannot lock themselves out
+	if isCurrentUser(c, username) && ((req.Disabled != nil && *req.Disabled) || (req.Role != nil && *req.Role != utils.RoleAdmin)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot disable or demote your own account"})
+		return
+	}
+
+	if req.Role != nil {
+		if err := store.Users().UpdateUserRole(username, *req.Role); err != nil {
+			respondUserError(c, err)
+			return
+		}
+	}
+	if req.Disabled != nil {
+		if err := store.Users().SetUserDisabled(username, *req.Disabled); err != nil {
+			respondUserError(c, err)
+			return
+		}
+	}
+
+	// Existing sessions carry the old role or status, so end them
+	if req.Role != nil || (req.Disabled != nil && *req.Disabled) {
+		if err := auth.RevokeUser(username); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, err := store.Users().GetUser(username)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toUserResponse(*user))
+}
+
+// DeleteUser deletes a user.
+// Response: 200 OK, 400 Bad Request, 404 Not Found or 500 Internal Server Error.
+func DeleteUser(c *gin.Context) {
+	username := c.Param("username")
+	if isCurrentUser(c, username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+	if err := store.Users().DeleteUser(username); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	if err := auth.RevokeUser(username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auth.DisableMFA(username); err != nil && !errors.Is(err, auth.ErrMFANotEnabled) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ResetUserPassword sets a new password for a user without requiring the current one.
+// Request JSON body:
+//
+//	{
+//	  "newPassword": "<new_password>"
+//	}
+//
+// Response: 200 OK, 400 Bad Request, 404 Not Found or 500 Internal Server Error.
+func ResetUserPassword(c *gin.Context) {
+	username := c.Param("username")
+	var req struct {
+		NewPassword string `json:"newPassword"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := store.Users().UpdateUserPassword(username, req.NewPassword); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	if err := auth.RevokeUser(username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// isCurrentUser reports whether username is the authenticated user making the request.
+func isCurrentUser(c *gin.Context, username string) bool {
+	current, _ := c.Get("user")
+	name, _ := current.(string)
+	return name == username
+}
+
+// respondUserError maps user store errors to HTTP responses.
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, utils.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
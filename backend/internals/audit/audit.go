@@ -0,0 +1,279 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/internals/utils"
+)
+
+// audit.go - Implements the append-only audit trail of mutating operations.
+// Entries are written as JSON lines to data/audit.log. When the file exceeds the size limit it is
+// rotated to audit.log.1 (shifting older files up to the configured count, the oldest is dropped).
+
+// Outcome values recorded for each entry.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// ReasonKey is the gin context key under which handlers may store the reason recorded in Entry.Reason.
+const ReasonKey = "auditReason"
+
+// Entry is a single audit record.
+type Entry struct {
+	Time      time.Time              `json:"time"`              // When the request completed
+	User      string                 `json:"user,omitempty"`    // Authenticated (or attempted) username
+	ClientIP  string                 `json:"clientIp"`          // Client address
+	Action    string                 `json:"action"`            // Audited operation, e.g. "topic.delete"
+	Method    string                 `json:"method"`            // HTTP method
+	Route     string                 `json:"route"`             // Route pattern
+	Cluster   string                 `json:"cluster,omitempty"` // Cluster the operation applies to
+	Target    string                 `json:"target,omitempty"`  // Topic, user or key the operation applies to
+	Params    map[string]interface{} `json:"params,omitempty"`  // Request parameters with payloads redacted
+	Status    int                    `json:"status"`            // HTTP status code
+	Outcome   string                 `json:"outcome"`           // OutcomeSuccess or OutcomeFailure
+	Reason    string                 `json:"reason,omitempty"`  // Why the operation failed, if the handler said
+	LatencyMs float64                `json:"latencyMs"`         // Handling time in milliseconds
+}
+
+// Filter selects entries returned by Query. Zero fields match everything.
+type Filter struct {
+	User   string    // Exact username
+	Action string    // Exact action
+	From   time.Time // Earliest entry time (inclusive)
+	To     time.Time // Latest entry time (inclusive)
+	Limit  int       // Maximum number of entries; newest entries are returned first
+}
+
+// Sink stores audit entries. Implementations must be safe for concurrent use.
+type Sink interface {
+	// Record stores an entry.
+	Record(entry Entry) error
+	// Query returns the entries matching filter, newest first.
+	Query(filter Filter) ([]Entry, error)
+	// Close releases resources held by the sink.
+	Close() error
+}
+
+// Logger appends entries to a rotating JSON lines file. It is safe for concurrent use.
+type Logger struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewLogger creates a logger writing to path, rotating after maxSize bytes and keeping maxFiles rotated files.
+func NewLogger(path string, maxSize int64, maxFiles int) *Logger {
+	return &Logger{path: path, maxSize: maxSize, maxFiles: maxFiles}
+}
+
+// NewLoggerFromEnv creates a logger configured by AUDIT_LOG_FILE, AUDIT_LOG_MAX_SIZE_MB and AUDIT_LOG_MAX_FILES.
+func NewLoggerFromEnv() (*Logger, error) {
+	path := os.Getenv(utils.AuditLogFileEnv)
+	if path == "" {
+		path = utils.DataFilePath(utils.AuditLogFileName)
+	}
+	maxSizeMB, err := envInt(utils.AuditLogMaxSizeEnv, utils.DefaultAuditLogMaxSizeMB)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles, err := envInt(utils.AuditLogMaxFilesEnv, utils.DefaultAuditLogMaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(path, int64(maxSizeMB)<<20, maxFiles), nil
+}
+
+func envInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return n, nil
+}
+
+// Record appends an entry to the log, rotating the file first if it is full.
+func (l *Logger) Record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.open(); err != nil {
+		return err
+	}
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// open opens the current file for appending if needed. Callers must hold l.mu.
+func (l *Logger) open() error {
+	if l.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files up by one and starts a new current file. Callers must hold l.mu.
+func (l *Logger) rotate() error {
+	l.file.Close()
+	l.file = nil
+	os.Remove(l.rotatedPath(l.maxFiles))
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(l.rotatedPath(i), l.rotatedPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, l.rotatedPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+func (l *Logger) rotatedPath(n int) string {
+	return l.path + "." + strconv.Itoa(n)
+}
+
+// Query returns the entries matching filter from the current and rotated files, newest first.
+func (l *Logger) Query(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []Entry{}
+	// Read from the newest file to the oldest
+	paths := []string{l.path}
+	for i := 1; i <= l.maxFiles; i++ {
+		paths = append(paths, l.rotatedPath(i))
+	}
+	for _, path := range paths {
+		matches, err := readEntries(path, filter)
+		if err != nil {
+			return nil, err
+		}
+		// Entries within a file are in chronological order
+		for i := len(matches) - 1; i >= 0; i-- {
+			entries = append(entries, matches[i])
+		}
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+// readEntries returns the entries of one file matching filter. Missing files have no entries.
+func readEntries(path string, filter Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a partially written line rather than failing the whole query
+			continue
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Matches reports whether entry is selected by the filter (ignoring Limit).
+func (f Filter) Matches(entry Entry) bool {
+	if f.User != "" && entry.User != f.User {
+		return false
+	}
+	if f.Action != "" && entry.Action != f.Action {
+		return false
+	}
+	if !f.From.IsZero() && entry.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.Time.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Close closes the current file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// defaultSink is the sink used by the audit middleware and query endpoint.
+var defaultSink Sink
+
+// Initialize sets the default sink.
+func Initialize(s Sink) {
+	defaultSink = s
+}
+
+// Default returns the default sink, or nil if auditing is not configured.
+func Default() Sink {
+	return defaultSink
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// apikeys.go - Manages personal API keys used by scripts and CI pipelines instead of a login.
+// A key is "kui_<id>.<secret>"; only a hash of the secret is stored under data/. Keys act with the
+// owner's current role, further limited to the permission scopes chosen when the key was created.
+// Only local accounts own keys: the role of an LDAP or SSO user cannot be re-checked without their
+// password or IdP session, so a key could outlive a demotion.
+
+// APIKeyPrefix starts every raw API key so keys can be told apart from JWTs.
+const APIKeyPrefix = "kui_"
+
+// apiKeyLastUsedInterval limits how often the last-used timestamp of a key is persisted.
+const apiKeyLastUsedInterval = time.Minute
+
+// APIKey is a personal API key. The hash of its secret is never returned by the API.
+type APIKey struct {
+	ID         string     `json:"id"`                   // Public key ID
+	Name       string     `json:"name"`                 // Description chosen by the owner
+	Username   string     `json:"username"`             // Owner
+	Role       string     `json:"role"`                 // Owner role when the key was created
+	Scopes     []string   `json:"scopes"`               // Permissions the key may use
+	Hash       string     `json:"hash,omitempty"`       // SHA-256 of the secret part
+	CreatedAt  time.Time  `json:"createdAt"`            // Creation time
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`  // Expiry; nil never expires
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"` // Last successful authentication
+}
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyExpired  = errors.New("API key expired")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+var (
+	apiKeys      map[string]*APIKey
+	apiKeysMutex sync.Mutex
+)
+
+// loadAPIKeys reads the key store on first use. Callers must hold apiKeysMutex.
+func loadAPIKeys() error {
+	if apiKeys != nil {
+		return nil
+	}
+	keys := map[string]*APIKey{}
+	if err := store.Documents().ReadDocument(utils.APIKeysFileName, &keys); err != nil {
+		return err
+	}
+	apiKeys = keys
+	return nil
+}
+
+// saveAPIKeys persists the key store. Callers must hold apiKeysMutex.
+func saveAPIKeys() error {
+	return store.Documents().WriteDocument(utils.APIKeysFileName, apiKeys)
+}
+
+// publicAPIKey returns a copy of key without its hash.
+func publicAPIKey(key *APIKey) APIKey {
+	result := *key
+	result.Hash = ""
+	return result
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than a JWT.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, APIKeyPrefix)
+}
+
+// CreateAPIKey creates a key for identity and returns it together with the raw key,
+// which is not stored and cannot be retrieved again. A zero ttl creates a key that never expires.
+func CreateAPIKey(identity *Identity, name string, scopes []string, ttl time.Duration) (*APIKey, string, error) {
+	id, err := RandomString(12)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := RandomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	key := &APIKey{
+		ID:        id,
+		Name:      name,
+		Username:  identity.Username,
+		Role:      identity.Role,
+		Scopes:    scopes,
+		Hash:      hashSecret(secret),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return nil, "", err
+	}
+	apiKeys[id] = key
+	if err := saveAPIKeys(); err != nil {
+		delete(apiKeys, id)
+		return nil, "", err
+	}
+	result := publicAPIKey(key)
+	return &result, APIKeyPrefix + id + "." + secret, nil
+}
+
+// ListAPIKeys returns the keys of username, or of every user when username is empty, oldest first.
+func ListAPIKeys(username string) ([]APIKey, error) {
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return nil, err
+	}
+	keys := []APIKey{}
+	for _, key := range apiKeys {
+		if username == "" || key.Username == username {
+			keys = append(keys, publicAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// GetAPIKey returns the key with the given ID.
+func GetAPIKey(id string) (*APIKey, error) {
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return nil, err
+	}
+	key, ok := apiKeys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	result := publicAPIKey(key)
+	return &result, nil
+}
+
+// DeleteAPIKey deletes the key with the given ID.
+func DeleteAPIKey(id string) error {
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return err
+	}
+	if _, ok := apiKeys[id]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	delete(apiKeys, id)
+	return saveAPIKeys()
+}
+
+// DeleteUserAPIKeys deletes every key of username.
+func DeleteUserAPIKeys(username string) error {
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return err
+	}
+	for id, key := range apiKeys {
+		if key.Username == username {
+			delete(apiKeys, id)
+		}
+	}
+	return saveAPIKeys()
+}
+
+// AuthenticateAPIKey verifies a raw API key and returns the identity it acts as and its scopes.
+// The owner must be an enabled account in the local user store and acts with their current role;
+// keys of any other owner are rejected.
+func AuthenticateAPIKey(raw string) (*Identity, []string, error) {
+	key, err := useAPIKey(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := store.Users().GetUser(key.Username)
+	switch {
+	case errors.Is(err, utils.ErrUserNotFound):
+		return nil, nil, ErrInvalidAPIKey
+	case err != nil:
+		return nil, nil, err
+	case user.Disabled:
+		return nil, nil, ErrUserDisabled
+	}
+	return &Identity{Username: user.Username, Role: user.Role, Source: CSVBackendName}, key.Scopes, nil
+}
+
+// useAPIKey looks up and verifies a raw key and records its use.
+func useAPIKey(raw string) (*APIKey, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, APIKeyPrefix), ".")
+	if !IsAPIKey(raw) || !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKeysMutex.Lock()
+	defer apiKeysMutex.Unlock()
+	if err := loadAPIKeys(); err != nil {
+		return nil, err
+	}
+	key, ok := apiKeys[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now().UTC()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		key.LastUsedAt = &now
+		if err := saveAPIKeys(); err != nil {
+			return nil, err
+		}
+	}
+	result := publicAPIKey(key)
+	return &result, nil
+}
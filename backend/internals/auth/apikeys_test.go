@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internals/utils"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	users := useTestUsers(t, "username,password,role,disabled\n"+
+		"alice,alice-pass,operator,false\n"+
+		"bob,bob-pass,admin,false\n"+
+		"carol,carol-pass,admin,true\n")
+	create := func(owner *Identity, scopes []string, ttl time.Duration) string {
+		t.Helper()
+		_, raw, err := CreateAPIKey(owner, "test", scopes, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	local := func(username, role string) *Identity {
+		return &Identity{Username: username, Role: role, Source: CSVBackendName}
+	}
+
+	valid := create(local("alice", utils.RoleOperator), []string{"read", "produce"}, 0)
+	unexpired := create(local("alice", utils.RoleOperator), []string{"read"}, time.Hour)
+	expired := create(local("alice", utils.RoleOperator), []string{"read"}, time.Nanosecond)
+	disabled := create(local("carol", utils.RoleAdmin), []string{"read"}, 0)
+	removed := create(local("bob", utils.RoleAdmin), []string{"read"}, 0)
+	external := create(&Identity{Username: "oidc:https://idp.example|42", Role: utils.RoleAdmin, Source: OIDCSourceName}, []string{"read"}, 0)
+	ldapAdmin := create(&Identity{Username: "dave", Role: utils.RoleAdmin, Source: LDAPBackendName}, []string{"read"}, 0)
+	id, _, _ := strings.Cut(strings.TrimPrefix(valid, APIKeyPrefix), ".")
+	time.Sleep(time.Millisecond)
+
+	// Demotions and removals made after the keys were created apply to them
+	if err := users.UpdateUserRole("alice", utils.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.DeleteUser("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		raw        string
+		wantErr    error
+		wantScopes []string
+	}{
+		{"valid key", valid, nil, []string{"read", "produce"}},
+		{"unexpired key", unexpired, nil, []string{"read"}},
+		{"expired key", expired, ErrAPIKeyExpired, nil},
+		{"disabled owner", disabled, ErrUserDisabled, nil},
+		{"removed owner", removed, ErrInvalidAPIKey, nil},
+		{"SSO owner", external, ErrInvalidAPIKey, nil},
+		{"LDAP owner", ldapAdmin, ErrInvalidAPIKey, nil},
+		{"wrong secret", APIKeyPrefix + id + ".wrong", ErrInvalidAPIKey, nil},
+		{"unknown id", APIKeyPrefix + "unknown." + strings.Repeat("a", 32), ErrInvalidAPIKey, nil},
+		{"missing secret", APIKeyPrefix + id, ErrInvalidAPIKey, nil},
+		{"missing prefix", strings.TrimPrefix(valid, APIKeyPrefix), ErrInvalidAPIKey, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, scopes, err := AuthenticateAPIKey(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if identity.Username != "alice" || identity.Role != utils.RoleViewer || !identity.IsLocal() {
+				t.Errorf("identity %+v, want local alice with the current role", identity)
+			}
+			if strings.Join(scopes, ",") != strings.Join(tt.wantScopes, ",") {
+				t.Errorf("scopes %v, want %v", scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestAPIKeyLastUsed(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\nalice,alice-pass,viewer,false\n")
+	key, raw, err := CreateAPIKey(&Identity{Username: "alice", Role: utils.RoleViewer, Source: CSVBackendName}, "ci", []string{"read"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.LastUsedAt != nil || key.Hash != "" {
+		t.Fatalf("new key %+v, want no last use and no hash", key)
+	}
+
+	if _, _, err := AuthenticateAPIKey(raw); err != nil {
+		t.Fatal(err)
+	}
+	used, err := GetAPIKey(key.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used.LastUsedAt == nil || used.Hash != "" {
+		t.Fatalf("used key %+v, want a last use and no hash", used)
+	}
+
+	// Uses within apiKeyLastUsedInterval are not persisted again
+	first := *used.LastUsedAt
+	if _, _, err := AuthenticateAPIKey(raw); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := GetAPIKey(key.ID); !again.LastUsedAt.Equal(first) {
+		t.Errorf("last use moved from %s to %s within the interval", first, again.LastUsedAt)
+	}
+}
+
+func TestRevokeUserDeletesAPIKeys(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n"+
+		"alice,alice-pass,admin,false\n"+
+		"bob,bob-pass,viewer,false\n")
+	_, alice, err := CreateAPIKey(&Identity{Username: "alice", Role: utils.RoleAdmin, Source: CSVBackendName}, "ci", []string{"read"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bob, err := CreateAPIKey(&Identity{Username: "bob", Role: utils.RoleViewer, Source: CSVBackendName}, "ci", []string{"read"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := AuthenticateAPIKey(alice); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked user's key: error %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if keys, _ := ListAPIKeys("alice"); len(keys) != 0 {
+		t.Errorf("revoked user still has %d keys", len(keys))
+	}
+	if _, _, err := AuthenticateAPIKey(bob); err != nil {
+		t.Errorf("other user's key: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"backend/internals/utils"
+)
+
+// authenticator.go - Defines the pluggable username/password authentication backends behind POST /login.
+// The backend is selected with the AUTH_BACKEND environment variable: "csv" (default), "static" or "ldap".
+
+// Identity is a user authenticated by an Authenticator.
+type Identity struct {
+	Username string   // Username used in the session token
+	Role     string   // Backend role granted to the user
+	Groups   []string // Groups reported by the backend, used by topic policies
+	Source   string   // Backend that authenticated the user: a backend name or OIDCSourceName
+}
+
+// IsLocal reports whether the identity is an account of the local user store. Only local accounts
+// can change their password, use two-factor authentication and create API keys.
+func (i *Identity) IsLocal() bool {
+	return i.Source == CSVBackendName
+}
+
+// Authenticator verifies a username and password.
+// Implementations return ErrInvalidCredentials for unknown users or wrong passwords,
+// ErrUserDisabled for disabled accounts and ErrNoRoleMapped for users without a role;
+// any other error means the backend is unavailable.
+type Authenticator interface {
+	// Name returns the configuration name of the backend.
+	Name() string
+	// Authenticate verifies the credentials and returns the user's identity.
+	Authenticate(username, password string) (*Identity, error)
+}
+
+var (
+	ErrInvalidCredentials = utils.ErrInvalidCredentials
+	ErrUserDisabled       = utils.ErrUserDisabled
+	ErrNoRoleMapped       = errors.New("no role is mapped to the user's groups")
+)
+
+// NewAuthenticatorFromEnv builds the authenticator selected by AUTH_BACKEND.
+func NewAuthenticatorFromEnv() (Authenticator, error) {
+	switch backend := strings.ToLower(os.Getenv(utils.AuthBackendEnv)); backend {
+	case "", CSVBackendName:
+		return NewCSVAuthenticator(), nil
+	case StaticBackendName:
+		return NewStaticFileAuthenticatorFromEnv()
+	case LDAPBackendName:
+		config, err := LDAPConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewLDAPAuthenticator(config), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q", utils.AuthBackendEnv, backend)
+	}
+}
+
+// parseRoleMap parses a comma-separated list of group=role pairs.
+func parseRoleMap(envName, value string) (map[string]string, error) {
+	roleMap := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok || !utils.IsValidRole(role) {
+			return nil, fmt.Errorf("invalid %s entry: %q", envName, pair)
+		}
+		roleMap[group] = role
+	}
+	return roleMap, nil
+}
+
+// IsAuthError reports whether err is a credential error rather than a backend failure.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrUserDisabled) || errors.Is(err, ErrNoRoleMapped)
+}
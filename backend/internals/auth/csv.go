@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"log"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// csv.go - Authenticates against the local user store (users.csv or the embedded database, see STORE_BACKEND).
+
+// CSVBackendName is the AUTH_BACKEND value selecting the local user store. The name predates other stores.
+const CSVBackendName = "csv"
+
+// CSVAuthenticator authenticates users of the local user store.
+// Legacy plain-text passwords are rehashed after a successful login.
+type CSVAuthenticator struct{}
+
+// NewCSVAuthenticator creates an authenticator backed by the local user store.
+func NewCSVAuthenticator() *CSVAuthenticator {
+	return &CSVAuthenticator{}
+}
+
+// Name returns the configuration name of the backend.
+func (a *CSVAuthenticator) Name() string {
+	return CSVBackendName
+}
+
+// Authenticate verifies the credentials against the local user store.
+func (a *CSVAuthenticator) Authenticate(username, password string) (*Identity, error) {
+	user, err := store.Users().GetUser(username)
+	if err != nil {
+		if errors.Is(err, utils.ErrUserNotFound) {
+			utils.RejectMissingUser(password)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Compare provided password with stored hash (or legacy plain-text value)
+	ok, needsRehash := utils.VerifyPassword(user.Password, password)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// Transparently migrate legacy plain-text rows to a hash
+	if needsRehash {
+		if err := store.Users().UpdateUserPassword(user.Username, password); err != nil {
+			log.Printf("Failed to rehash password for user %s: %v", user.Username, err)
+		}
+	}
+	return &Identity{Username: user.Username, Role: user.Role, Source: CSVBackendName}, nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// useTestUsers points the local user store at a users.csv with the given content and starts with empty
+// revocation, API key and 2FA state.
+func useTestUsers(t *testing.T, csv string) store.UserStore {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, utils.UsersFileName)
+	if err := os.WriteFile(path, []byte(csv), 0600); err != nil {
+		t.Fatal(err)
+	}
+	users := store.NewCSVUserStore(path)
+	store.Initialize(&store.Backend{Users: users, Documents: store.NewFileDocumentStore(dir)})
+	revocations, apiKeys, mfa = nil, nil, nil
+	return users
+}
+
+func TestCSVAuthenticate(t *testing.T) {
+	hash, err := utils.HashPassword("alice-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := useTestUsers(t, "username,password,role,disabled\n"+
+		"alice,"+hash+",producer,false\n"+
+		"bob,bob-pass,viewer,false\n"+
+		"carol,carol-pass,admin,true\n")
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+		wantRole string
+	}{
+		{"hashed password", "alice", "alice-pass", nil, utils.RoleProducer},
+		{"wrong password", "alice", "bob-pass", ErrInvalidCredentials, ""},
+		{"legacy plain-text password", "bob", "bob-pass", nil, utils.RoleViewer},
+		{"unknown user", "mallory", "alice-pass", ErrInvalidCredentials, ""},
+		{"disabled user", "carol", "carol-pass", ErrUserDisabled, ""},
+		{"disabled user with a wrong password", "carol", "nope", ErrInvalidCredentials, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := NewCSVAuthenticator().Authenticate(tt.username, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (identity.Username != tt.username || identity.Role != tt.wantRole) {
+				t.Errorf("identity %+v, want %s with role %s", identity, tt.username, tt.wantRole)
+			}
+		})
+	}
+
+	// bob's legacy plain-text password was rehashed by the successful login and still works
+	bob, err := users.GetUser("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsPasswordHash(bob.Password) {
+		t.Errorf("legacy password was not rehashed: %q", bob.Password)
+	}
+	if _, err := NewCSVAuthenticator().Authenticate("bob", "bob-pass"); err != nil {
+		t.Errorf("login after the rehash failed: %v", err)
+	}
+	// A disabled user's legacy password is not rehashed
+	if carol, _ := users.GetUser("carol"); utils.IsPasswordHash(carol.Password) {
+		t.Error("disabled user's password was rehashed")
+	}
+}
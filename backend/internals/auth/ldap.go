@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internals/utils"
+)
+
+// ldap.go - Authenticates users with an LDAP bind and maps their group membership to roles.
+// The user entry is located with a search (optionally bound as a service account), the password is
+// verified by binding as that entry, and groups are read from a member attribute or a group search.
+
+// LDAPBackendName is the AUTH_BACKEND value selecting LDAP.
+const LDAPBackendName = "ldap"
+
+// LDAPConfig holds the settings of the LDAP authenticator.
+type LDAPConfig struct {
+	URL                string            // ldap:// or ldaps:// server URL
+	StartTLS           bool              // Upgrade ldap:// connections with StartTLS
+	InsecureSkipVerify bool              // Skip TLS certificate verification (development only)
+	BindDN             string            // Service account used to search for users; empty searches anonymously
+	BindPassword       string            // Service account password
+	BaseDN             string            // Base DN for user searches
+	UserFilter         string            // User search filter; %s is replaced by the escaped username
+	GroupAttribute     string            // User attribute listing group DNs (e.g. memberOf)
+	GroupBaseDN        string            // Base DN for group searches; used with GroupFilter
+	GroupFilter        string            // Group search filter; %s is replaced by the escaped user DN
+	RoleMap            map[string]string // Group CN or DN to backend role
+	DefaultRole        string            // Role for users without a mapped group; empty denies them
+	Timeout            time.Duration     // Network timeout per operation
+}
+
+// LDAPAuthenticator authenticates users against an LDAP directory.
+type LDAPAuthenticator struct {
+	config LDAPConfig
+}
+
+// NewLDAPAuthenticator creates an LDAP authenticator with the given configuration.
+func NewLDAPAuthenticator(config LDAPConfig) *LDAPAuthenticator {
+	if config.UserFilter == "" {
+		config.UserFilter = "(uid=%s)"
+	}
+	if config.GroupAttribute == "" && config.GroupFilter == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &LDAPAuthenticator{config: config}
+}
+
+// LDAPConfigFromEnv reads the LDAP authenticator configuration from the environment.
+func LDAPConfigFromEnv() (LDAPConfig, error) {
+	config := LDAPConfig{
+		URL:            os.Getenv(utils.LDAPURLEnv),
+		BindDN:         os.Getenv(utils.LDAPBindDNEnv),
+		BindPassword:   os.Getenv(utils.LDAPBindPasswordEnv),
+		BaseDN:         os.Getenv(utils.LDAPBaseDNEnv),
+		UserFilter:     os.Getenv(utils.LDAPUserFilterEnv),
+		GroupAttribute: os.Getenv(utils.LDAPGroupAttributeEnv),
+		GroupBaseDN:    os.Getenv(utils.LDAPGroupBaseDNEnv),
+		GroupFilter:    os.Getenv(utils.LDAPGroupFilterEnv),
+		DefaultRole:    os.Getenv(utils.LDAPDefaultRoleEnv),
+	}
+	if config.URL == "" || config.BaseDN == "" {
+		return LDAPConfig{}, fmt.Errorf("%s and %s are required for the ldap backend", utils.LDAPURLEnv, utils.LDAPBaseDNEnv)
+	}
+	if config.DefaultRole != "" && !utils.IsValidRole(config.DefaultRole) {
+		return LDAPConfig{}, fmt.Errorf("invalid %s: %q", utils.LDAPDefaultRoleEnv, config.DefaultRole)
+	}
+	config.StartTLS, _ = strconv.ParseBool(os.Getenv(utils.LDAPStartTLSEnv))
+	config.InsecureSkipVerify, _ = strconv.ParseBool(os.Getenv(utils.LDAPInsecureSkipVerifyEnv))
+
+	roleMap, err := parseRoleMap(utils.LDAPRoleMapEnv, os.Getenv(utils.LDAPRoleMapEnv))
+	if err != nil {
+		return LDAPConfig{}, err
+	}
+	config.RoleMap = roleMap
+	return config, nil
+}
+
+// Name returns the configuration name of the backend.
+func (a *LDAPAuthenticator) Name() string {
+	return LDAPBackendName
+}
+
+// Authenticate locates the user entry, binds as it with the password and maps its groups to a role.
+func (a *LDAPAuthenticator) Authenticate(username, password string) (*Identity, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := dialLDAP(a.config.URL, a.config.StartTLS, &tls.Config{InsecureSkipVerify: a.config.InsecureSkipVerify}, a.config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.config.BindDN != "" {
+		if err := conn.Bind(a.config.BindDN, a.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap service bind failed: %w", err)
+		}
+	}
+
+	// "1.1" requests no attributes when only the DN is needed
+	attributes := []string{"1.1"}
+	if a.config.GroupAttribute != "" {
+		attributes = []string{a.config.GroupAttribute}
+	}
+	filter := strings.ReplaceAll(a.config.UserFilter, "%s", EscapeLDAPFilter(username))
+	entries, err := conn.Search(a.config.BaseDN, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		// Unknown or ambiguous usernames are treated as bad credentials
+		return nil, ErrInvalidCredentials
+	}
+	user := entries[0]
+
+	if err := conn.Bind(user.DN, password); err != nil {
+		if errors.Is(err, errLDAPInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	var groupDNs []string
+	if a.config.GroupAttribute != "" {
+		groupDNs = user.Attribute(a.config.GroupAttribute)
+	}
+	if a.config.GroupFilter != "" {
+		// Re-bind as the service account if the user cannot read groups
+		if a.config.BindDN != "" {
+			if err := conn.Bind(a.config.BindDN, a.config.BindPassword); err != nil {
+				return nil, fmt.Errorf("ldap service bind failed: %w", err)
+			}
+		}
+		base := a.config.GroupBaseDN
+		if base == "" {
+			base = a.config.BaseDN
+		}
+		groupFilter := strings.ReplaceAll(a.config.GroupFilter, "%s", EscapeLDAPFilter(user.DN))
+		groups, err := conn.Search(base, groupFilter, []string{"cn"})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			groupDNs = append(groupDNs, g.DN)
+		}
+	}
+
+	// Groups may be mapped by full DN or by their CN
+	var groups []string
+	mapKeys := make([]string, 0, 2*len(groupDNs))
+	for _, dn := range groupDNs {
+		cn := firstRDNValue(dn)
+		groups = append(groups, cn)
+		mapKeys = append(mapKeys, cn, strings.ToLower(dn))
+	}
+	roleMap := make(map[string]string, len(a.config.RoleMap))
+	for k, v := range a.config.RoleMap {
+		if strings.Contains(k, "=") {
+			k = strings.ToLower(k)
+		}
+		roleMap[k] = v
+	}
+
+	role := MapGroupsToRole(mapKeys, roleMap, a.config.DefaultRole)
+	if role == "" {
+		return nil, ErrNoRoleMapped
+	}
+	return &Identity{Username: username, Role: role, Groups: groups, Source: LDAPBackendName}, nil
+}
+
+// firstRDNValue returns the value of the first RDN of a DN, e.g. "admins" for "cn=admins,ou=groups,dc=example".
+func firstRDNValue(dn string) string {
+	rdn, _, _ := strings.Cut(dn, ",")
+	if _, value, ok := strings.Cut(rdn, "="); ok {
+		return strings.TrimSpace(value)
+	}
+	return dn
+}
@@ -0,0 +1,452 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internals/utils"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapTestEntry is an entry of the in-process directory.
+type ldapTestEntry struct {
+	dn         string
+	password   string // Simple bind password; empty entries cannot bind
+	attributes map[string][]string
+}
+
+// ldapTestServer is a minimal in-process LDAP server answering simple binds and searches over a
+// fixed set of entries. It records the binds and search filters it receives.
+type ldapTestServer struct {
+	listener net.Listener
+	entries  []ldapTestEntry
+
+	mu       sync.Mutex
+	binds    []string      // DNs of successful binds, in order
+	searches []ldapRequest // Search requests, in order
+}
+
+// ldapRequest is a search request as received by the server.
+type ldapRequest struct {
+	boundDN    string
+	baseDN     string
+	filter     *ber.Packet
+	attributes []string
+}
+
+func newLDAPTestServer(t *testing.T, entries []ldapTestEntry) *ldapTestServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ldapTestServer{listener: listener, entries: entries}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *ldapTestServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *ldapTestServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *ldapTestServer) handle(conn net.Conn) {
+	defer conn.Close()
+	boundDN := ""
+	for {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		msg, err := ber.ReadPacket(conn)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+		id, _ := msg.Children[0].Value.(int64)
+		op := msg.Children[1]
+		reply := func(ops ...*ber.Packet) {
+			for _, op := range ops {
+				envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+				envelope.AppendChild(op)
+				conn.Write(envelope.Bytes())
+			}
+		}
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := ldap.LDAPResultInvalidCredentials
+			if entry := s.lookup(dn); entry != nil && entry.password != "" && entry.password == password {
+				code = ldap.LDAPResultSuccess
+				boundDN = entry.dn
+				s.mu.Lock()
+				s.binds = append(s.binds, entry.dn)
+				s.mu.Unlock()
+			}
+			reply(ldapTestResult(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			baseDN, _ := op.Children[0].Value.(string)
+			request := ldapRequest{boundDN: boundDN, baseDN: baseDN, filter: op.Children[6]}
+			for _, attr := range op.Children[7].Children {
+				name, _ := attr.Value.(string)
+				request.attributes = append(request.attributes, name)
+			}
+			s.mu.Lock()
+			s.searches = append(s.searches, request)
+			s.mu.Unlock()
+			for _, entry := range s.entries {
+				if strings.HasSuffix(strings.ToLower(entry.dn), strings.ToLower(request.baseDN)) && matchLDAPFilter(request.filter, entry) {
+					reply(ldapTestSearchEntry(entry, request.attributes))
+				}
+			}
+			reply(ldapTestResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		default:
+			return
+		}
+	}
+}
+
+// lookup returns the entry with the given DN.
+func (s *ldapTestServer) lookup(dn string) *ldapTestEntry {
+	for i := range s.entries {
+		if strings.EqualFold(s.entries[i].dn, dn) {
+			return &s.entries[i]
+		}
+	}
+	return nil
+}
+
+// matchLDAPFilter evaluates an encoded filter against an entry. Names and values compare case-insensitively.
+func matchLDAPFilter(filter *ber.Packet, entry ldapTestEntry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchLDAPFilter(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchLDAPFilter(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchLDAPFilter(filter.Children[0], entry)
+	case ldap.FilterPresent:
+		return len(entry.attribute(filter.Data.String())) > 0
+	case ldap.FilterEqualityMatch:
+		name, _ := filter.Children[0].Value.(string)
+		want, _ := filter.Children[1].Value.(string)
+		for _, value := range entry.attribute(name) {
+			if strings.EqualFold(value, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e ldapTestEntry) attribute(name string) []string {
+	for attr, values := range e.attributes {
+		if strings.EqualFold(attr, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func ldapTestResult(tag ber.Tag, code int) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	return result
+}
+
+func ldapTestSearchEntry(entry ldapTestEntry, attributes []string) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for _, name := range attributes {
+		values := entry.attribute(name)
+		if len(values) == 0 {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	result.AppendChild(attrs)
+	return result
+}
+
+// ldapTestDirectory is a directory with a service account, three users and two groups.
+func ldapTestDirectory() []ldapTestEntry {
+	return []ldapTestEntry{
+		{dn: "cn=svc,ou=system,dc=example,dc=com", password: "svc-pass"},
+		{dn: "uid=alice,ou=people,dc=example,dc=com", password: "alice-pass", attributes: map[string][]string{
+			"uid": {"alice"}, "objectClass": {"person"}, "memberOf": {"cn=kafka-admins,ou=groups,dc=example,dc=com"},
+		}},
+		{dn: "uid=bob,ou=people,dc=example,dc=com", password: "bob-pass", attributes: map[string][]string{
+			"uid": {"bob"}, "objectClass": {"person"}, "memberOf": {"cn=kafka-devs,ou=groups,dc=example,dc=com", "cn=wiki,ou=groups,dc=example,dc=com"},
+		}},
+		{dn: "uid=carol,ou=people,dc=example,dc=com", password: "carol-pass", attributes: map[string][]string{
+			"uid": {"carol"}, "objectClass": {"person"},
+		}},
+		{dn: "cn=kafka-admins,ou=groups,dc=example,dc=com", attributes: map[string][]string{
+			"cn": {"kafka-admins"}, "objectClass": {"groupOfNames"}, "member": {"uid=alice,ou=people,dc=example,dc=com"},
+		}},
+		{dn: "cn=kafka-devs,ou=groups,dc=example,dc=com", attributes: map[string][]string{
+			"cn": {"kafka-devs"}, "objectClass": {"groupOfNames"}, "member": {"uid=bob,ou=people,dc=example,dc=com", "uid=carol,ou=people,dc=example,dc=com"},
+		}},
+	}
+}
+
+func testLDAPConfig(s *ldapTestServer) LDAPConfig {
+	return LDAPConfig{
+		URL:          s.url(),
+		BindDN:       "cn=svc,ou=system,dc=example,dc=com",
+		BindPassword: "svc-pass",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+		RoleMap: map[string]string{
+			"kafka-admins": utils.RoleAdmin,
+			"cn=kafka-devs,ou=groups,dc=example,dc=com": utils.RoleProducer,
+		},
+		Timeout: 2 * time.Second,
+	}
+}
+
+func TestLDAPAuthenticateMemberOf(t *testing.T) {
+	s := newLDAPTestServer(t, ldapTestDirectory())
+	tests := []struct {
+		name        string
+		username    string
+		password    string
+		defaultRole string
+		wantRole    string
+		wantErr     error
+	}{
+		{name: "group mapped by CN", username: "alice", password: "alice-pass", wantRole: utils.RoleAdmin},
+		{name: "group mapped by DN", username: "bob", password: "bob-pass", wantRole: utils.RoleProducer},
+		{name: "bad password", username: "alice", password: "wrong", wantErr: ErrInvalidCredentials},
+		{name: "empty password", username: "alice", password: "", wantErr: ErrInvalidCredentials},
+		{name: "unknown user", username: "mallory", password: "x", wantErr: ErrInvalidCredentials},
+		{name: "no group and no default", username: "carol", password: "carol-pass", wantErr: ErrNoRoleMapped},
+		{name: "no group uses the default", username: "carol", password: "carol-pass", defaultRole: utils.RoleViewer, wantRole: utils.RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testLDAPConfig(s)
+			config.DefaultRole = tt.defaultRole
+			identity, err := NewLDAPAuthenticator(config).Authenticate(tt.username, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Username != tt.username || identity.Role != tt.wantRole {
+				t.Errorf("identity %+v, want %s with role %s", identity, tt.username, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestLDAPServiceBindAndSearch(t *testing.T) {
+	s := newLDAPTestServer(t, ldapTestDirectory())
+	identity, err := NewLDAPAuthenticator(testLDAPConfig(s)).Authenticate("bob", "bob-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(identity.Groups, ","); got != "kafka-devs,wiki" {
+		t.Errorf("groups %s, want kafka-devs,wiki", got)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wantBinds := []string{"cn=svc,ou=system,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"}
+	if strings.Join(s.binds, ";") != strings.Join(wantBinds, ";") {
+		t.Errorf("binds %v, want %v", s.binds, wantBinds)
+	}
+	if len(s.searches) != 1 {
+		t.Fatalf("%d searches, want 1", len(s.searches))
+	}
+	search := s.searches[0]
+	if search.boundDN != wantBinds[0] || search.baseDN != "ou=people,dc=example,dc=com" {
+		t.Errorf("search as %q under %q", search.boundDN, search.baseDN)
+	}
+	if strings.Join(search.attributes, ",") != "memberOf" {
+		t.Errorf("search requested attributes %v, want memberOf", search.attributes)
+	}
+}
+
+func TestLDAPServiceBindFailure(t *testing.T) {
+	s := newLDAPTestServer(t, ldapTestDirectory())
+	config := testLDAPConfig(s)
+	config.BindPassword = "wrong"
+	_, err := NewLDAPAuthenticator(config).Authenticate("alice", "alice-pass")
+	if err == nil || errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), "service bind failed") {
+		t.Fatalf("error %v, want a service bind failure that is not ErrInvalidCredentials", err)
+	}
+}
+
+func TestLDAPGroupFilter(t *testing.T) {
+	s := newLDAPTestServer(t, ldapTestDirectory())
+	config := testLDAPConfig(s)
+	config.GroupAttribute = ""
+	config.GroupBaseDN = "ou=groups,dc=example,dc=com"
+	config.GroupFilter = "(&(objectClass=groupOfNames)(member=%s))"
+	config.RoleMap = map[string]string{"kafka-admins": utils.RoleAdmin, "kafka-devs": utils.RoleOperator}
+
+	tests := []struct {
+		username string
+		wantRole string
+	}{
+		{"alice", utils.RoleAdmin},
+		{"carol", utils.RoleOperator},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			identity, err := NewLDAPAuthenticator(config).Authenticate(tt.username, tt.username+"-pass")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Role != tt.wantRole {
+				t.Errorf("role %s, want %s", identity.Role, tt.wantRole)
+			}
+		})
+	}
+
+	// The group search runs as the service account again, under the group base DN
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := s.searches[len(s.searches)-1]
+	if last.boundDN != config.BindDN || last.baseDN != config.GroupBaseDN {
+		t.Errorf("group search as %q under %q", last.boundDN, last.baseDN)
+	}
+	if strings.Join(last.attributes, ",") != "cn" {
+		t.Errorf("group search requested attributes %v, want cn", last.attributes)
+	}
+}
+
+func TestLDAPFilterInjection(t *testing.T) {
+	s := newLDAPTestServer(t, ldapTestDirectory())
+	for _, username := range []string{"*", "alice)(uid=*", "*)(|(uid=*", `alice\`} {
+		t.Run(username, func(t *testing.T) {
+			_, err := NewLDAPAuthenticator(testLDAPConfig(s)).Authenticate(username, "alice-pass")
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("error %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+
+	// The username reaches the server as a literal equality value
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter := s.searches[1].filter
+	if filter.Tag != ldap.FilterAnd || len(filter.Children) != 2 {
+		t.Fatalf("filter tag %d with %d children, want an AND of 2", filter.Tag, len(filter.Children))
+	}
+	uid := filter.Children[1]
+	if uid.Tag != ldap.FilterEqualityMatch || uid.Children[1].Value != "alice)(uid=*" {
+		t.Errorf("uid filter %d %v, want equality with the literal username", uid.Tag, uid.Children[1].Value)
+	}
+}
+
+func TestEscapeLDAPFilter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"alice", "alice"},
+		{"*", `\2a`},
+		{"a(b)c", `a\28b\29c`},
+		{`back\slash`, `back\5cslash`},
+		{"nul\x00byte", `nul\00byte`},
+		{"*)(uid=*", `\2a\29\28uid=\2a`},
+		{"jörg", `j\c3\b6rg`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := EscapeLDAPFilter(tt.value)
+			if got != tt.want {
+				t.Fatalf("EscapeLDAPFilter(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+			// The escaped value compiles to an equality match on the original value
+			filter, err := ldap.CompileFilter("(uid=" + got + ")")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filter.Tag != ldap.FilterEqualityMatch || filter.Children[1].Data.String() != tt.value {
+				t.Errorf("filter for %q matches %q", got, filter.Children[1].Data.String())
+			}
+		})
+	}
+}
+
+func TestLDAPElementSizeLimit(t *testing.T) {
+	// A response whose message ID is followed by an octet string claiming nearly 2 GiB
+	oversized := []byte{0x30, 0x84, 0x7f, 0xff, 0xff, 0xff, 0x02, 0x01, 0x01, 0x04, 0x84, 0x7f, 0xff, 0xff, 0xf0}
+	if _, err := ber.ReadPacket(bytes.NewReader(oversized)); err == nil || !strings.Contains(err.Error(), "greater than maximum") {
+		t.Errorf("oversized element: error %v, want the size limit", err)
+	}
+	atLimit := ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, strings.Repeat("a", maxLDAPElementSize), "")
+	if _, err := ber.ReadPacket(bytes.NewReader(atLimit.Bytes())); err != nil {
+		t.Errorf("element of %d bytes: %v", maxLDAPElementSize, err)
+	}
+
+	// A server sending such a response fails the login at once instead of allocating for it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := ber.ReadPacket(conn); err == nil {
+			conn.Write(oversized)
+		}
+		time.Sleep(5 * time.Second)
+	}()
+	config := LDAPConfig{URL: "ldap://" + listener.Addr().String(), BindDN: "cn=svc", BindPassword: "svc-pass", BaseDN: "dc=example", Timeout: 3 * time.Second}
+	started := time.Now()
+	_, err = NewLDAPAuthenticator(config).Authenticate("alice", "alice-pass")
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("error %v, want a backend failure", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("login failed after %s, want an immediate failure", elapsed)
+	}
+}
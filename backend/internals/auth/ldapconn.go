@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapconn.go - Wraps the go-ldap client with the operations needed for authentication:
+// simple bind, subtree search and StartTLS, each bounded by the configured timeout.
+
+// maxLDAPElementSize bounds the length of a BER element read from the LDAP server, so a hostile or
+// broken server (or anyone in the path of a plain ldap:// connection) cannot make a login allocate
+// more than this for a single value.
+const maxLDAPElementSize = 1 << 20
+
+func init() {
+	ber.MaxPacketLengthBytes = maxLDAPElementSize
+}
+
+// errLDAPInvalidCredentials is returned by Bind when the server rejects the credentials.
+var errLDAPInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// ldapEntry is a single search result.
+type ldapEntry struct {
+	DN    string
+	entry *ldap.Entry
+}
+
+// Attribute returns the values of the named attribute, matching the name case-insensitively.
+func (e ldapEntry) Attribute(name string) []string {
+	return e.entry.GetEqualFoldAttributeValues(name)
+}
+
+// ldapConn is an open connection to an LDAP server.
+type ldapConn struct {
+	conn    *ldap.Conn
+	timeout time.Duration
+}
+
+// dialLDAP connects to an ldap:// or ldaps:// URL, optionally upgrading with StartTLS.
+func dialLDAP(rawURL string, startTLS bool, tlsConfig *tls.Config, timeout time.Duration) (*ldapConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url: %w", err)
+	}
+	host := u.Host
+	dialer := &net.Dialer{Timeout: timeout}
+
+	// The connection is dialed here rather than by go-ldap so the StartTLS handshake can be given a deadline
+	var raw net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), ldap.DefaultLdapPort)
+		}
+		raw, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), ldap.DefaultLdapsPort)
+		}
+		raw, err = tls.DialWithDialer(dialer, "tcp", host, withServerName(tlsConfig, u.Hostname()))
+	default:
+		return nil, fmt.Errorf("unsupported ldap url scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap server: %w", err)
+	}
+
+	conn := ldap.NewConn(raw, u.Scheme == "ldaps")
+	conn.Start()
+	conn.SetTimeout(timeout)
+	if startTLS && u.Scheme == "ldap" {
+		raw.SetDeadline(time.Now().Add(timeout))
+		if err := conn.StartTLS(withServerName(tlsConfig, u.Hostname())); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls failed: %w", err)
+		}
+		raw.SetDeadline(time.Time{})
+	}
+	return &ldapConn{conn: conn, timeout: timeout}, nil
+}
+
+// withServerName returns a copy of config with ServerName set when it is empty.
+func withServerName(config *tls.Config, serverName string) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = serverName
+	}
+	return config
+}
+
+// Close sends an unbind request and closes the connection.
+func (c *ldapConn) Close() error {
+	if err := c.conn.Unbind(); err != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Bind performs a simple bind. Empty passwords are rejected to prevent unauthenticated binds.
+func (c *ldapConn) Bind(dn, password string) error {
+	if password == "" {
+		return errLDAPInvalidCredentials
+	}
+	err := c.conn.Bind(dn, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return errLDAPInvalidCredentials
+	}
+	return err
+}
+
+// Search performs a subtree search and returns all matching entries. Referrals are ignored.
+func (c *ldapConn) Search(baseDN, filter string, attributes []string) ([]ldapEntry, error) {
+	request := ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(c.timeout.Seconds()), false, filter, attributes, nil)
+	result, err := c.conn.Search(request)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ldapEntry, len(result.Entries))
+	for i, entry := range result.Entries {
+		entries[i] = ldapEntry{DN: entry.DN, entry: entry}
+	}
+	return entries, nil
+}
+
+// EscapeLDAPFilter escapes a value for safe use inside an LDAP filter (RFC 4515).
+func EscapeLDAPFilter(value string) string {
+	return ldap.EscapeFilter(value)
+}
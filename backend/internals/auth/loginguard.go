@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/utils"
+)
+
+// loginguard.go - Protects password login against brute-force attacks.
+// Failed attempts are counted per client IP and per username in a sliding window. Each failure for a
+// username doubles the delay before the next attempt is accepted, and reaching the failure limit locks
+// the username for a while. Unknown usernames are tracked like real ones so lockouts do not reveal
+// which accounts exist. State is kept in memory and resets when the server restarts.
+
+// LoginGuardConfig holds the brute-force protection limits.
+type LoginGuardConfig struct {
+	MaxFailures     int           // Failures per username within Window before it is locked
+	IPMaxFailures   int           // Failures per client IP within Window before it is throttled
+	Window          time.Duration // Sliding window for counting failures
+	LockoutDuration time.Duration // How long a username stays locked
+	BaseDelay       time.Duration // Delay after the first failure; doubles with each further failure
+	MaxDelay        time.Duration // Upper bound of the backoff delay
+}
+
+// DefaultLoginGuardConfig returns the default limits.
+func DefaultLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		MaxFailures:     5,
+		IPMaxFailures:   20,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+	}
+}
+
+// LoginGuardConfigFromEnv reads the limits from the environment, using defaults for unset values.
+func LoginGuardConfigFromEnv() (LoginGuardConfig, error) {
+	config := DefaultLoginGuardConfig()
+	for env, target := range map[string]*int{
+		utils.LoginMaxFailuresEnv:   &config.MaxFailures,
+		utils.LoginIPMaxFailuresEnv: &config.IPMaxFailures,
+	} {
+		if value := os.Getenv(env); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return LoginGuardConfig{}, fmt.Errorf("invalid %s: %q", env, value)
+			}
+			*target = n
+		}
+	}
+	for env, target := range map[string]*time.Duration{
+		utils.LoginFailureWindowEnv:   &config.Window,
+		utils.LoginLockoutDurationEnv: &config.LockoutDuration,
+	} {
+		if value := os.Getenv(env); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return LoginGuardConfig{}, fmt.Errorf("invalid %s: %q", env, value)
+			}
+			*target = d
+		}
+	}
+	return config, nil
+}
+
+// LoginBlockedError is returned by LoginGuard.Check when an attempt must be rejected.
+type LoginBlockedError struct {
+	Reason     string        // "locked", "backoff" or "ip"
+	RetryAfter time.Duration // Time until the next attempt is accepted
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("login blocked (%s), retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+// Reasons reported in LoginBlockedError.
+const (
+	BlockedLocked  = "locked"
+	BlockedBackoff = "backoff"
+	BlockedIP      = "ip"
+)
+
+// attemptRecord tracks recent failures of one username or client IP.
+type attemptRecord struct {
+	failures    []time.Time // Failure times within the window, oldest first
+	lockedAt    time.Time   // When the username was locked
+	lockedUntil time.Time   // Zero unless the username is locked
+}
+
+// Lockout describes a username or client IP that is currently blocked, for the admin endpoints.
+type Lockout struct {
+	Key          string    `json:"key"`          // Username or client IP
+	Failures     int       `json:"failures"`     // Failures within the window
+	LastFailure  time.Time `json:"lastFailure"`  // Time of the latest failure
+	BlockedUntil time.Time `json:"blockedUntil"` // When attempts are accepted again
+}
+
+// LoginGuard counts failed logins and decides whether new attempts are allowed. It is safe for concurrent use.
+type LoginGuard struct {
+	config LoginGuardConfig
+	now    func() time.Time // Clock, replaced in tests
+
+	mu        sync.Mutex
+	users     map[string]*attemptRecord
+	ips       map[string]*attemptRecord
+	lastSweep time.Time
+}
+
+// NewLoginGuard creates a guard with the given limits.
+func NewLoginGuard(config LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{
+		config: config,
+		now:    time.Now,
+		users:  map[string]*attemptRecord{},
+		ips:    map[string]*attemptRecord{},
+	}
+}
+
+// userKey normalizes usernames so case variations share a counter.
+func userKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// prune drops failures that left the window, including one exactly Window old, so a block ends when
+// its RetryAfter has passed. Callers must hold g.mu.
+func (g *LoginGuard) prune(r *attemptRecord, now time.Time) {
+	cutoff := now.Add(-g.config.Window)
+	i := 0
+	for i < len(r.failures) && !r.failures[i].After(cutoff) {
+		i++
+	}
+	r.failures = r.failures[i:]
+}
+
+// backoff returns the delay required after n consecutive failures.
+func (g *LoginGuard) backoff(n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	delay := float64(g.config.BaseDelay) * math.Pow(2, float64(n-1))
+	if delay > float64(g.config.MaxDelay) {
+		return g.config.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Check reports whether a login attempt for username from ip may proceed.
+// Returns a *LoginBlockedError when it must be rejected.
+func (g *LoginGuard) Check(ip, username string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+
+	if r, ok := g.ips[ip]; ok {
+		g.prune(r, now)
+		if len(r.failures) >= g.config.IPMaxFailures {
+			return &LoginBlockedError{Reason: BlockedIP, RetryAfter: r.failures[0].Add(g.config.Window).Sub(now)}
+		}
+	}
+	if r, ok := g.users[userKey(username)]; ok {
+		if now.Before(r.lockedUntil) {
+			return &LoginBlockedError{Reason: BlockedLocked, RetryAfter: r.lockedUntil.Sub(now)}
+		}
+		g.prune(r, now)
+		if n := len(r.failures); n > 0 {
+			if next := r.failures[n-1].Add(g.backoff(n)); now.Before(next) {
+				return &LoginBlockedError{Reason: BlockedBackoff, RetryAfter: next.Sub(now)}
+			}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt. Returns true if it locked the username.
+func (g *LoginGuard) RecordFailure(ip, username string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	g.sweep(now)
+
+	ipRecord := g.record(g.ips, ip)
+	g.prune(ipRecord, now)
+	ipRecord.failures = append(ipRecord.failures, now)
+
+	userRecord := g.record(g.users, userKey(username))
+	g.prune(userRecord, now)
+	userRecord.failures = append(userRecord.failures, now)
+	if len(userRecord.failures) >= g.config.MaxFailures {
+		userRecord.lockedAt = now
+		userRecord.lockedUntil = now.Add(g.config.LockoutDuration)
+		userRecord.failures = nil
+		return true
+	}
+	return false
+}
+
+// RecordSuccess clears the failures of username after a successful login.
+func (g *LoginGuard) RecordSuccess(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.users, userKey(username))
+}
+
+func (g *LoginGuard) record(records map[string]*attemptRecord, key string) *attemptRecord {
+	r, ok := records[key]
+	if !ok {
+		r = &attemptRecord{}
+		records[key] = r
+	}
+	return r
+}
+
+// sweep removes records without recent failures or an active lockout, at most once a minute.
+// Callers must hold g.mu.
+func (g *LoginGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < time.Minute {
+		return
+	}
+	g.lastSweep = now
+	for _, records := range []map[string]*attemptRecord{g.users, g.ips} {
+		for key, r := range records {
+			g.prune(r, now)
+			if len(r.failures) == 0 && !now.Before(r.lockedUntil) {
+				delete(records, key)
+			}
+		}
+	}
+}
+
+// Lockouts returns the usernames and client IPs whose attempts are currently blocked.
+func (g *LoginGuard) Lockouts() (users []Lockout, ips []Lockout) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+
+	users, ips = []Lockout{}, []Lockout{}
+	for key, r := range g.users {
+		g.prune(r, now)
+		if now.Before(r.lockedUntil) {
+			users = append(users, Lockout{Key: key, Failures: g.config.MaxFailures, LastFailure: r.lockedAt, BlockedUntil: r.lockedUntil})
+		}
+	}
+	for key, r := range g.ips {
+		g.prune(r, now)
+		if len(r.failures) >= g.config.IPMaxFailures {
+			ips = append(ips, Lockout{Key: key, Failures: len(r.failures), LastFailure: r.failures[len(r.failures)-1], BlockedUntil: r.failures[0].Add(g.config.Window)})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Key < users[j].Key })
+	sort.Slice(ips, func(i, j int) bool { return ips[i].Key < ips[j].Key })
+	return users, ips
+}
+
+// ClearUser removes the lockout and failures of username. Returns false if there was nothing to clear.
+func (g *LoginGuard) ClearUser(username string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := userKey(username)
+	_, ok := g.users[key]
+	delete(g.users, key)
+	return ok
+}
+
+// ClearIP removes the failures recorded for a client IP. Returns false if there was nothing to clear.
+func (g *LoginGuard) ClearIP(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.ips[ip]
+	delete(g.ips, ip)
+	return ok
+}
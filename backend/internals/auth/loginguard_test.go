@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// testClock is a manually advanced clock for LoginGuard.
+type testClock struct{ now time.Time }
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time                  { return c.now }
+func (c *testClock) Advance(d time.Duration)         { c.now = c.now.Add(d) }
+func (c *testClock) After(d time.Duration) time.Time { return c.now.Add(d) }
+
+func newTestLoginGuard(clock *testClock) *LoginGuard {
+	g := NewLoginGuard(LoginGuardConfig{
+		MaxFailures:     3,
+		IPMaxFailures:   5,
+		Window:          10 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+	})
+	g.now = clock.Now
+	return g
+}
+
+// blockedReason returns the reason and retry delay of a blocked check, or "" when the attempt may proceed.
+func blockedReason(t *testing.T, err error) (string, time.Duration) {
+	t.Helper()
+	if err == nil {
+		return "", 0
+	}
+	var blocked *LoginBlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("error %v, want a *LoginBlockedError", err)
+	}
+	return blocked.Reason, blocked.RetryAfter
+}
+
+func TestLoginGuardBackoff(t *testing.T) {
+	g := NewLoginGuard(LoginGuardConfig{BaseDelay: time.Second, MaxDelay: 10 * time.Second})
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{60, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := g.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardUserBackoffAndLockout(t *testing.T) {
+	clock := newTestClock()
+	g := newTestLoginGuard(clock)
+
+	if locked := g.RecordFailure("10.0.0.1", "alice"); locked {
+		t.Fatal("first failure locked the user")
+	}
+	if reason, retry := blockedReason(t, g.Check("10.0.0.2", "alice")); reason != BlockedBackoff || retry != time.Second {
+		t.Fatalf("after one failure: %q retry %s, want backoff of 1s", reason, retry)
+	}
+	// Usernames are matched case-insensitively and other users are unaffected
+	if reason, _ := blockedReason(t, g.Check("10.0.0.2", " ALICE ")); reason != BlockedBackoff {
+		t.Errorf("case variant: %q, want backoff", reason)
+	}
+	if err := g.Check("10.0.0.1", "bob"); err != nil {
+		t.Errorf("other user: %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if err := g.Check("10.0.0.1", "alice"); err != nil {
+		t.Fatalf("after the backoff: %v", err)
+	}
+	g.RecordFailure("10.0.0.1", "alice")
+	if reason, retry := blockedReason(t, g.Check("10.0.0.1", "alice")); reason != BlockedBackoff || retry != 2*time.Second {
+		t.Fatalf("after two failures: %q retry %s, want backoff of 2s", reason, retry)
+	}
+
+	clock.Advance(2 * time.Second)
+	if locked := g.RecordFailure("10.0.0.1", "alice"); !locked {
+		t.Fatal("third failure did not lock the user")
+	}
+	if reason, retry := blockedReason(t, g.Check("10.0.0.3", "alice")); reason != BlockedLocked || retry != 15*time.Minute {
+		t.Fatalf("after the lockout: %q retry %s, want locked for 15m", reason, retry)
+	}
+	if users, _ := g.Lockouts(); len(users) != 1 || users[0].Key != "alice" || !users[0].BlockedUntil.Equal(clock.After(15*time.Minute)) {
+		t.Errorf("lockouts %+v, want alice until the end of the lockout", users)
+	}
+
+	// The lockout expires and starts the count afresh
+	clock.Advance(15*time.Minute - time.Second)
+	if reason, _ := blockedReason(t, g.Check("10.0.0.1", "alice")); reason != BlockedLocked {
+		t.Errorf("just before expiry: %q, want locked", reason)
+	}
+	clock.Advance(time.Second)
+	if err := g.Check("10.0.0.1", "alice"); err != nil {
+		t.Fatalf("after expiry: %v", err)
+	}
+	if users, _ := g.Lockouts(); len(users) != 0 {
+		t.Errorf("lockouts after expiry: %+v", users)
+	}
+	if locked := g.RecordFailure("10.0.0.1", "alice"); locked {
+		t.Error("first failure after expiry locked the user again")
+	}
+}
+
+func TestLoginGuardFailuresLeaveWindow(t *testing.T) {
+	clock := newTestClock()
+	g := newTestLoginGuard(clock)
+
+	g.RecordFailure("10.0.0.1", "alice")
+	clock.Advance(6 * time.Minute)
+	g.RecordFailure("10.0.0.1", "alice")
+
+	// The first failure leaves the window, so the third one does not lock
+	clock.Advance(5 * time.Minute)
+	if locked := g.RecordFailure("10.0.0.1", "alice"); locked {
+		t.Fatal("failures outside the window counted towards the lockout")
+	}
+	if reason, retry := blockedReason(t, g.Check("10.0.0.1", "alice")); reason != BlockedBackoff || retry != 2*time.Second {
+		t.Errorf("%q retry %s, want backoff of 2s for two failures in the window", reason, retry)
+	}
+}
+
+func TestLoginGuardIPThreshold(t *testing.T) {
+	clock := newTestClock()
+	g := newTestLoginGuard(clock)
+
+	// Spread over different usernames so no single user is locked
+	usernames := []string{"a", "b", "c", "d", "e"}
+	for i, username := range usernames {
+		if err := g.Check("10.0.0.1", "z"); err != nil {
+			t.Fatalf("attempt %d blocked: %v", i+1, err)
+		}
+		g.RecordFailure("10.0.0.1", username)
+		clock.Advance(time.Minute)
+	}
+	reason, retry := blockedReason(t, g.Check("10.0.0.1", "z"))
+	if reason != BlockedIP || retry != 5*time.Minute {
+		t.Fatalf("%q retry %s, want the IP blocked until the first failure leaves the window", reason, retry)
+	}
+	if err := g.Check("10.0.0.2", "z"); err != nil {
+		t.Errorf("other IP: %v", err)
+	}
+	if _, ips := g.Lockouts(); len(ips) != 1 || ips[0].Key != "10.0.0.1" || ips[0].Failures != len(usernames) {
+		t.Errorf("IP lockouts %+v, want 10.0.0.1 with %d failures", ips, len(usernames))
+	}
+
+	// A successful login clears the username but not the IP
+	g.RecordSuccess("z")
+	if reason, _ := blockedReason(t, g.Check("10.0.0.1", "z")); reason != BlockedIP {
+		t.Errorf("after a success: %q, want the IP still blocked", reason)
+	}
+	clock.Advance(retry)
+	if err := g.Check("10.0.0.1", "z"); err != nil {
+		t.Errorf("after the oldest failure left the window: %v", err)
+	}
+}
+
+func TestLoginGuardClear(t *testing.T) {
+	clock := newTestClock()
+	g := newTestLoginGuard(clock)
+	for i := 0; i < 3; i++ {
+		g.RecordFailure("10.0.0.1", "alice")
+		g.RecordFailure("10.0.0.1", "bob")
+	}
+
+	if !g.ClearUser("Alice") {
+		t.Fatal("ClearUser found nothing to clear")
+	}
+	if g.ClearUser("alice") {
+		t.Error("ClearUser cleared alice twice")
+	}
+	if reason, _ := blockedReason(t, g.Check("10.0.0.2", "alice")); reason != "" {
+		t.Errorf("cleared user: %q, want no block", reason)
+	}
+	if reason, _ := blockedReason(t, g.Check("10.0.0.2", "bob")); reason != BlockedLocked {
+		t.Errorf("other user: %q, want still locked", reason)
+	}
+
+	if reason, _ := blockedReason(t, g.Check("10.0.0.1", "carol")); reason != BlockedIP {
+		t.Fatalf("IP before clearing: %q, want blocked", reason)
+	}
+	if !g.ClearIP("10.0.0.1") {
+		t.Fatal("ClearIP found nothing to clear")
+	}
+	if g.ClearIP("10.0.0.1") {
+		t.Error("ClearIP cleared the IP twice")
+	}
+	if err := g.Check("10.0.0.1", "carol"); err != nil {
+		t.Errorf("cleared IP: %v", err)
+	}
+}
+
+func TestLoginGuardSweep(t *testing.T) {
+	clock := newTestClock()
+	g := newTestLoginGuard(clock)
+	g.RecordFailure("10.0.0.1", "alice")
+	for i := 0; i < 3; i++ {
+		g.RecordFailure("10.0.0.2", "bob")
+	}
+
+	// After the window only bob's lockout is still worth keeping
+	clock.Advance(11 * time.Minute)
+	g.RecordFailure("10.0.0.3", "carol")
+	if _, ok := g.users["alice"]; ok {
+		t.Error("sweep kept a user without recent failures")
+	}
+	if _, ok := g.ips["10.0.0.1"]; ok {
+		t.Error("sweep kept an IP without recent failures")
+	}
+	if _, ok := g.users["bob"]; !ok {
+		t.Error("sweep removed a locked user")
+	}
+
+	// Sweeps run at most once a minute: bob's lockout ends at 15m but the record stays until the next sweep
+	clock.Advance(3*time.Minute + 50*time.Second)
+	g.RecordFailure("10.0.0.4", "dave")
+	clock.Advance(20 * time.Second)
+	g.RecordFailure("10.0.0.4", "erin")
+	if _, ok := g.users["bob"]; !ok {
+		t.Error("sweep ran within a minute of the previous one")
+	}
+	clock.Advance(50 * time.Second)
+	g.RecordFailure("10.0.0.4", "frank")
+	if _, ok := g.users["bob"]; ok {
+		t.Error("sweep kept an expired lockout")
+	}
+	if _, ok := g.users["carol"]; !ok {
+		t.Error("sweep removed a failure within the window")
+	}
+}
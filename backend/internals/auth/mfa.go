@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mfa.go - Manages TOTP two-factor authentication for local accounts.
+// Enrollment stores a pending secret until the user proves their authenticator app works by entering
+// a code; activation then enables 2FA and hands out single-use recovery codes (stored hashed).
+// Admins can require 2FA for roles. Login with 2FA is a two-step flow: the password step returns a
+// short-lived challenge token which is exchanged, together with a code, for the session tokens.
+
+const (
+	// MFAChallengeTTL is how long the second login step may take.
+	MFAChallengeTTL = 5 * time.Minute
+
+	// recoveryCodeCount is the number of recovery codes issued at once.
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotPending       = errors.New("no two-factor enrollment in progress")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+)
+
+// mfaRecord is the persisted 2FA state of a user.
+type mfaRecord struct {
+	Secret        string    `json:"secret,omitempty"`        // Base32 TOTP secret once enabled
+	PendingSecret string    `json:"pendingSecret,omitempty"` // Secret awaiting activation
+	RecoveryCodes []string  `json:"recoveryCodes,omitempty"` // SHA-256 hashes of unused recovery codes
+	LastStep      int64     `json:"lastStep"`                // Last accepted time step, to prevent replays
+	EnabledAt     time.Time `json:"enabledAt"`               // When 2FA was activated
+}
+
+// mfaState is the persisted form of the 2FA store.
+type mfaState struct {
+	RequiredRoles []string              `json:"requiredRoles"` // Roles that must use 2FA
+	Users         map[string]*mfaRecord `json:"users"`         // Username to 2FA state
+}
+
+var (
+	mfa      *mfaState
+	mfaMutex sync.Mutex
+)
+
+// loadMFA reads the 2FA store on first use. Callers must hold mfaMutex.
+func loadMFA() error {
+	if mfa != nil {
+		return nil
+	}
+	state := &mfaState{}
+	if err := store.Documents().ReadDocument(utils.MFAFileName, state); err != nil {
+		return err
+	}
+	if state.Users == nil {
+		state.Users = map[string]*mfaRecord{}
+	}
+	if state.RequiredRoles == nil {
+		state.RequiredRoles = []string{}
+	}
+	mfa = state
+	return nil
+}
+
+// saveMFA persists the 2FA store. Callers must hold mfaMutex.
+func saveMFA() error {
+	return store.Documents().WriteDocument(utils.MFAFileName, mfa)
+}
+
+// MFAStatus describes the 2FA state of a user.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`                // 2FA is active
+	Required               bool `json:"required"`               // The user's role must use 2FA
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"` // Unused recovery codes
+}
+
+// GetMFAStatus returns the 2FA state of username with the given role.
+func GetMFAStatus(username, role string) (*MFAStatus, error) {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Required: mfaRequired(role)}
+	if r, ok := mfa.Users[username]; ok && r.Secret != "" {
+		status.Enabled = true
+		status.RecoveryCodesRemaining = len(r.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// mfaRequired reports whether role must use 2FA. Callers must hold mfaMutex.
+func mfaRequired(role string) bool {
+	for _, r := range mfa.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// MFARequiredRoles returns the roles that must use 2FA.
+func MFARequiredRoles() ([]string, error) {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return nil, err
+	}
+	return append([]string{}, mfa.RequiredRoles...), nil
+}
+
+// SetMFARequiredRoles sets the roles that must use 2FA.
+func SetMFARequiredRoles(roles []string) error {
+	required := []string{}
+	for _, role := range roles {
+		if !utils.IsValidRole(role) {
+			return utils.ErrInvalidRole
+		}
+		required = append(required, role)
+	}
+	sort.Strings(required)
+
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return err
+	}
+	mfa.RequiredRoles = required
+	return saveMFA()
+}
+
+// BeginMFAEnrollment generates a pending secret for username and returns it with its otpauth URI.
+// Starting again replaces a previous pending secret.
+func BeginMFAEnrollment(username string) (secret, uri string, err error) {
+	secret, err = GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return "", "", err
+	}
+	r := mfa.Users[username]
+	if r == nil {
+		r = &mfaRecord{}
+		mfa.Users[username] = r
+	}
+	if r.Secret != "" {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	r.PendingSecret = secret
+	if err := saveMFA(); err != nil {
+		return "", "", err
+	}
+	return secret, TOTPURI(utils.MFAIssuer, username, secret), nil
+}
+
+// ActivateMFA enables 2FA for username if code matches the pending secret, returning new recovery codes.
+func ActivateMFA(username, code string) ([]string, error) {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return nil, err
+	}
+	r := mfa.Users[username]
+	if r == nil || r.PendingSecret == "" {
+		return nil, ErrMFANotPending
+	}
+	step, ok := verifyTOTP(r.PendingSecret, code, time.Now(), r.LastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	r.Secret, r.PendingSecret = r.PendingSecret, ""
+	r.LastStep = step
+	r.RecoveryCodes = hashes
+	r.EnabledAt = time.Now().UTC()
+	if err := saveMFA(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// MFAEnabled reports whether username has 2FA enabled.
+func MFAEnabled(username string) (bool, error) {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return false, err
+	}
+	r, ok := mfa.Users[username]
+	return ok && r.Secret != "", nil
+}
+
+// VerifyMFA checks a TOTP code or an unused recovery code for username. Recovery codes are consumed.
+func VerifyMFA(username, code string) error {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return err
+	}
+	r := mfa.Users[username]
+	if r == nil || r.Secret == "" {
+		return ErrMFANotEnabled
+	}
+
+	if step, ok := verifyTOTP(r.Secret, code, time.Now(), r.LastStep); ok {
+		r.LastStep = step
+		return saveMFA()
+	}
+	hash := hashSecret(normalizeRecoveryCode(code))
+	for i, h := range r.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			r.RecoveryCodes = append(r.RecoveryCodes[:i], r.RecoveryCodes[i+1:]...)
+			return saveMFA()
+		}
+	}
+	return ErrInvalidMFACode
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of username.
+func RegenerateRecoveryCodes(username string) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return nil, err
+	}
+	r := mfa.Users[username]
+	if r == nil || r.Secret == "" {
+		return nil, ErrMFANotEnabled
+	}
+	r.RecoveryCodes = hashes
+	if err := saveMFA(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the 2FA state of username, including any pending enrollment.
+func DisableMFA(username string) error {
+	mfaMutex.Lock()
+	defer mfaMutex.Unlock()
+	if err := loadMFA(); err != nil {
+		return err
+	}
+	if _, ok := mfa.Users[username]; !ok {
+		return ErrMFANotEnabled
+	}
+	delete(mfa.Users, username)
+	return saveMFA()
+}
+
+// generateRecoveryCodes returns new recovery codes and their hashes.
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		secret, err := GenerateTOTPSecret()
+		if err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(secret[:5] + "-" + secret[5:10])
+		codes = append(codes, code)
+		hashes = append(hashes, hashSecret(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces when comparing recovery codes.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// mfaSigningKey derives the key for challenge tokens from the session secret, so a challenge
+// token can never be accepted as an access token.
+func mfaSigningKey() []byte {
+	sum := sha256.Sum256(append([]byte("mfa-challenge:"), JWTSecret()...))
+	return sum[:]
+}
+
+// IssueMFAChallenge creates the token returned by the password step of a 2FA login.
+func IssueMFAChallenge(identity *Identity) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  identity.Username,
+		"role": identity.Role,
+		"src":  identity.Source,
+		"iat":  now.Unix(),
+		"exp":  now.Add(MFAChallengeTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(mfaSigningKey())
+}
+
+// ParseMFAChallenge validates a challenge token and returns the identity that passed the password step.
+func ParseMFAChallenge(tokenString string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return mfaSigningKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+	username, _ := claims["sub"].(string)
+	role, _ := claims["role"].(string)
+	source, _ := claims["src"].(string)
+	if username == "" || source == "" {
+		return nil, ErrInvalidMFAChallenge
+	}
+	return &Identity{Username: username, Role: role, Source: source}, nil
+}
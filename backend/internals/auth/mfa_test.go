@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internals/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// currentTOTPCode returns the code of secret for the current time step plus offset.
+func currentTOTPCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, time.Now().Unix()/int64(totpPeriod.Seconds())+offset)
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	if _, err := ActivateMFA("alice", "123456"); !errors.Is(err, ErrMFANotPending) {
+		t.Fatalf("activation without enrollment: error %v, want %v", err, ErrMFANotPending)
+	}
+	secret, uri, err := BeginMFAEnrollment("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("URI %s does not contain the secret", uri)
+	}
+	if enabled, _ := MFAEnabled("alice"); enabled {
+		t.Fatal("2FA enabled before activation")
+	}
+	if _, err := ActivateMFA("alice", "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("wrong activation code: error %v, want %v", err, ErrInvalidMFACode)
+	}
+
+	codes, err := ActivateMFA("alice", currentTOTPCode(t, secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("%d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	status, err := GetMFAStatus("alice", utils.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Errorf("status %+v, want enabled with all recovery codes", status)
+	}
+	if _, _, err := BeginMFAEnrollment("alice"); !errors.Is(err, ErrMFAAlreadyEnabled) {
+		t.Errorf("second enrollment: error %v, want %v", err, ErrMFAAlreadyEnabled)
+	}
+	if err := VerifyMFA("bob", currentTOTPCode(t, secret, 0)); !errors.Is(err, ErrMFANotEnabled) {
+		t.Errorf("user without 2FA: error %v, want %v", err, ErrMFANotEnabled)
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	secret, _, err := BeginMFAEnrollment("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	activation := currentTOTPCode(t, secret, 0)
+	codes, err := ActivateMFA("alice", activation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The code used for activation cannot be replayed, the next step's code works once
+	if err := VerifyMFA("alice", activation); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replayed activation code: error %v, want %v", err, ErrInvalidMFACode)
+	}
+	next := currentTOTPCode(t, secret, 1)
+	if err := VerifyMFA("alice", next); err != nil {
+		t.Fatalf("next step's code: %v", err)
+	}
+	if err := VerifyMFA("alice", next); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replayed code: error %v, want %v", err, ErrInvalidMFACode)
+	}
+
+	// Recovery codes ignore case and dashes and work exactly once
+	variant := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if err := VerifyMFA("alice", variant); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := VerifyMFA("alice", codes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("reused recovery code: error %v, want %v", err, ErrInvalidMFACode)
+	}
+	if err := VerifyMFA("alice", codes[1]); err != nil {
+		t.Errorf("second recovery code: %v", err)
+	}
+	if status, _ := GetMFAStatus("alice", utils.RoleViewer); status.RecoveryCodesRemaining != recoveryCodeCount-2 {
+		t.Errorf("%d recovery codes remaining, want %d", status.RecoveryCodesRemaining, recoveryCodeCount-2)
+	}
+
+	// Regenerated codes replace the old ones
+	fresh, err := RegenerateRecoveryCodes("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMFA("alice", codes[2]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replaced recovery code: error %v, want %v", err, ErrInvalidMFACode)
+	}
+	if err := VerifyMFA("alice", fresh[0]); err != nil {
+		t.Errorf("regenerated recovery code: %v", err)
+	}
+
+	// The state survives a reload
+	mfa = nil
+	if err := VerifyMFA("alice", fresh[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("used recovery code after reload: error %v, want %v", err, ErrInvalidMFACode)
+	}
+	if err := DisableMFA("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMFA("alice", fresh[1]); !errors.Is(err, ErrMFANotEnabled) {
+		t.Errorf("after disabling: error %v, want %v", err, ErrMFANotEnabled)
+	}
+}
+
+func TestMFARequiredRoles(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	if err := SetMFARequiredRoles([]string{utils.RoleAdmin, "root"}); !errors.Is(err, utils.ErrInvalidRole) {
+		t.Fatalf("unknown role: error %v, want %v", err, utils.ErrInvalidRole)
+	}
+	if err := SetMFARequiredRoles([]string{utils.RoleOperator, utils.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if roles, _ := MFARequiredRoles(); strings.Join(roles, ",") != "admin,operator" {
+		t.Errorf("required roles %v, want admin and operator", roles)
+	}
+
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{utils.RoleViewer, false},
+		{utils.RoleProducer, false},
+		{utils.RoleOperator, true},
+		{utils.RoleAdmin, true},
+	}
+	for _, tt := range tests {
+		status, err := GetMFAStatus("alice", tt.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Required != tt.want || status.Enabled {
+			t.Errorf("status for %s: %+v, want required %v and not enabled", tt.role, status, tt.want)
+		}
+	}
+}
+
+func TestParseMFAChallenge(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	identity := &Identity{Username: "alice", Role: utils.RoleAdmin, Source: CSVBackendName}
+	challenge, err := IssueMFAChallenge(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseMFAChallenge(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != identity.Username || got.Role != identity.Role || got.Source != identity.Source {
+		t.Errorf("identity %+v, want %+v", got, identity)
+	}
+	// A challenge is not a session
+	if _, err := ParseAccessToken(challenge); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("challenge as access token: error %v, want %v", err, ErrInvalidToken)
+	}
+
+	sign := func(key []byte, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims := func(exp time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "alice", "role": utils.RoleAdmin, "src": CSVBackendName, "exp": exp.Unix()}
+	}
+	session, err := IssueTokens(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", sign(mfaSigningKey(), claims(time.Now().Add(-time.Second)))},
+		{"without expiry", sign(mfaSigningKey(), jwt.MapClaims{"sub": "alice", "role": utils.RoleAdmin, "src": CSVBackendName})},
+		{"signed with another key", sign([]byte("forged"), claims(time.Now().Add(time.Minute)))},
+		{"signed with the session key", sign(JWTSecret(), claims(time.Now().Add(time.Minute)))},
+		{"access token", session.AccessToken},
+		{"without subject", sign(mfaSigningKey(), jwt.MapClaims{"role": utils.RoleAdmin, "src": CSVBackendName, "exp": time.Now().Add(time.Minute).Unix()})},
+		{"without source", sign(mfaSigningKey(), jwt.MapClaims{"sub": "alice", "role": utils.RoleAdmin, "exp": time.Now().Add(time.Minute).Unix()})},
+		{"unsigned", strings.Join(strings.Split(challenge, ".")[:2], ".") + "."},
+		{"tampered", challenge[:len(challenge)-2] + "xx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMFAChallenge(tt.token); !errors.Is(err, ErrInvalidMFAChallenge) {
+				t.Errorf("error %v, want %v", err, ErrInvalidMFAChallenge)
+			}
+		})
+	}
+}
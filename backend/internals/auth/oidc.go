@@ -0,0 +1,402 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidc.go - Implements the OpenID Connect authorization-code flow against an external identity provider.
+// Discovers the provider endpoints, exchanges authorization codes, verifies ID tokens against the
+// provider's JWKS and maps group claims to backend roles.
+
+// OIDCSourceName is the Identity source of users that logged in through the identity provider.
+const OIDCSourceName = "oidc"
+
+// OIDCConfig holds the settings of an OpenID Connect provider.
+type OIDCConfig struct {
+	IssuerURL    string            // Issuer URL; discovery is read from <issuer>/.well-known/openid-configuration
+	ClientID     string            // OAuth client ID
+	ClientSecret string            // OAuth client secret
+	RedirectURL  string            // Callback URL registered with the provider
+	Scopes       []string          // Requested scopes (openid is always included)
+	GroupsClaim  string            // ID token claim holding the user's groups
+	RoleMap      map[string]string // Group name to backend role
+	DefaultRole  string            // Role for users without a mapped group; empty denies them
+}
+
+// oidcDiscovery is the subset of the provider metadata used by the backend.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jsonWebKey is a single entry of a JWKS document.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// OIDCProvider performs the authorization-code flow for one identity provider.
+type OIDCProvider struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+}
+
+var ErrOIDCNotConfigured = errors.New("oidc is not configured")
+
+// NewOIDCProvider creates a provider for the given configuration.
+// Discovery is performed lazily on first use so the backend can start while the IdP is unavailable.
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	return &OIDCProvider{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OIDCConfigFromEnv reads the provider configuration from the environment.
+// Returns ErrOIDCNotConfigured when OIDC_ISSUER is not set.
+//
+// OIDC_ROLE_MAP is a comma-separated list of group=role pairs, e.g. "kafka-admins=admin,devs=producer".
+func OIDCConfigFromEnv() (OIDCConfig, error) {
+	issuer := os.Getenv(utils.OIDCIssuerEnv)
+	if issuer == "" {
+		return OIDCConfig{}, ErrOIDCNotConfigured
+	}
+	config := OIDCConfig{
+		IssuerURL:    strings.TrimRight(issuer, "/"),
+		ClientID:     os.Getenv(utils.OIDCClientIDEnv),
+		ClientSecret: os.Getenv(utils.OIDCClientSecretEnv),
+		RedirectURL:  os.Getenv(utils.OIDCRedirectURLEnv),
+		GroupsClaim:  os.Getenv(utils.OIDCGroupsClaimEnv),
+		DefaultRole:  os.Getenv(utils.OIDCDefaultRoleEnv),
+	}
+	if scopes := os.Getenv(utils.OIDCScopesEnv); scopes != "" {
+		config.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	} else {
+		config.Scopes = []string{"profile", "email"}
+	}
+	if config.ClientID == "" || config.RedirectURL == "" {
+		return OIDCConfig{}, fmt.Errorf("%s and %s are required when %s is set", utils.OIDCClientIDEnv, utils.OIDCRedirectURLEnv, utils.OIDCIssuerEnv)
+	}
+	if config.DefaultRole != "" && !utils.IsValidRole(config.DefaultRole) {
+		return OIDCConfig{}, fmt.Errorf("invalid %s: %q", utils.OIDCDefaultRoleEnv, config.DefaultRole)
+	}
+	roleMap, err := parseRoleMap(utils.OIDCRoleMapEnv, os.Getenv(utils.OIDCRoleMapEnv))
+	if err != nil {
+		return OIDCConfig{}, err
+	}
+	config.RoleMap = roleMap
+	return config, nil
+}
+
+// AuthCodeURL returns the provider URL the browser is redirected to for login.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := append([]string{"openid"}, p.config.Scopes...)
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.config.ClientID},
+		"redirect_uri":  {p.config.RedirectURL},
+		"scope":         {strings.Join(scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code, verifies the returned ID token and maps it to an identity.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.config.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, tokenResp.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return p.identityFromClaims(claims)
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token.
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (jwt.MapClaims, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(disc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	return claims, nil
+}
+
+// identityFromClaims extracts the username and groups from ID token claims and maps them to a role.
+// The username is namespaced with the issuer (see OIDCUsername) rather than taken from
+// preferred_username or email, which the user may be able to choose and which could name a local account.
+func (p *OIDCProvider) identityFromClaims(claims jwt.MapClaims) (*Identity, error) {
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+	if issuer == "" || subject == "" {
+		return nil, errors.New("id_token has no usable subject")
+	}
+	username := OIDCUsername(issuer, subject)
+
+	var groups []string
+	switch v := claims[p.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		groups = []string{v}
+	}
+
+	role := MapGroupsToRole(groups, p.config.RoleMap, p.config.DefaultRole)
+	if role == "" {
+		return nil, ErrNoRoleMapped
+	}
+	return &Identity{Username: username, Role: role, Groups: groups, Source: OIDCSourceName}, nil
+}
+
+// OIDCUsername returns the username of an identity provider user, "oidc:<issuer>|<sub>". Local
+// usernames cannot contain ':', so these names never collide with local accounts.
+func OIDCUsername(issuer, subject string) string {
+	return OIDCSourceName + ":" + issuer + "|" + subject
+}
+
+// MapGroupsToRole returns the most privileged role mapped to any of the groups,
+// or defaultRole when none of them is mapped.
+func MapGroupsToRole(groups []string, roleMap map[string]string, defaultRole string) string {
+	best := -1
+	for _, g := range groups {
+		role, ok := roleMap[g]
+		if !ok {
+			continue
+		}
+		for i, r := range utils.Roles {
+			if r == role && i > best {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return defaultRole
+	}
+	return utils.Roles[best]
+}
+
+// getDiscovery fetches and caches the provider metadata.
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var disc oidcDiscovery
+	if err := p.getJSON(ctx, p.config.IssuerURL+"/.well-known/openid-configuration", &disc); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if strings.TrimRight(disc.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery returned issuer %q, expected %q", disc.Issuer, p.config.IssuerURL)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is incomplete")
+	}
+	p.discovery = &disc
+	return p.discovery, nil
+}
+
+// getKey returns the verification key with the given key ID, refreshing the JWKS once if it is unknown.
+func (p *OIDCProvider) getKey(ctx context.Context, kid string) (interface{}, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, disc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	p.keys = keys
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key. Callers must hold p.mu.
+// An empty kid matches only when the provider publishes a single key.
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON performs a GET request and decodes the JSON response into v.
+func (p *OIDCProvider) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// publicKey converts a JWK into an RSA or ECDSA public key.
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// RandomString returns a URL-safe random string built from n random bytes.
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internals/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "kafka-ui"
+	testClientSecret = "s3cret"
+	testCode         = "auth-code"
+	testNonce        = "nonce-1"
+)
+
+// mockOIDCProvider is an identity provider served from an httptest.Server. It publishes its
+// discovery document and JWKS and answers the token endpoint with the ID token set by the test.
+type mockOIDCProvider struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	keys       map[string]*rsa.PrivateKey // Published signing keys by kid
+	idToken    string                     // Returned by the token endpoint
+	jwksFetch  int                        // Number of JWKS requests served
+	tokenCalls int                        // Number of valid token requests served
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	m := &mockOIDCProvider{keys: map[string]*rsa.PrivateKey{}}
+	m.addKey(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.jwksFetch++
+		keys := []map[string]string{}
+		for kid, key := range m.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || !ok || user != testClientID || pass != testClientSecret ||
+			r.FormValue("grant_type") != "authorization_code" || r.FormValue("code") != testCode {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.tokenCalls++
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": m.idToken})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// addKey publishes a new RSA signing key.
+func (m *mockOIDCProvider) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	m.keys[kid] = key
+	m.mu.Unlock()
+}
+
+// issue sets the ID token returned by the token endpoint, signed with the key kid.
+func (m *mockOIDCProvider) issue(t *testing.T, kid string, claims jwt.MapClaims) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(m.keys[kid])
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.idToken = signed
+}
+
+// claims returns valid ID token claims for user, modified by the given overrides.
+func (m *mockOIDCProvider) claims(user string, overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":                m.server.URL,
+		"sub":                "sub-" + user,
+		"aud":                testClientID,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              testNonce,
+		"preferred_username": user,
+		"groups":             []string{"kafka-devs"},
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func (m *mockOIDCProvider) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		IssuerURL:    m.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		Scopes:       []string{"profile"},
+		RoleMap:      map[string]string{"kafka-devs": utils.RoleProducer, "kafka-admins": utils.RoleAdmin},
+	})
+}
+
+func TestOIDCExchange(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+		nonce     string
+		wantErr   string
+		wantRole  string
+	}{
+		{name: "good flow", nonce: testNonce, wantRole: utils.RoleProducer},
+		{name: "most privileged group wins", overrides: jwt.MapClaims{"groups": []string{"kafka-devs", "kafka-admins"}}, nonce: testNonce, wantRole: utils.RoleAdmin},
+		{name: "wrong issuer", overrides: jwt.MapClaims{"iss": "https://evil.example.com"}, nonce: testNonce, wantErr: "invalid id_token"},
+		{name: "wrong audience", overrides: jwt.MapClaims{"aud": "another-client"}, nonce: testNonce, wantErr: "invalid id_token"},
+		{name: "expired", overrides: jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}, nonce: testNonce, wantErr: "invalid id_token"},
+		{name: "no expiry", overrides: jwt.MapClaims{"exp": nil}, nonce: testNonce, wantErr: "invalid id_token"},
+		{name: "nonce mismatch", nonce: "other-nonce", wantErr: "nonce mismatch"},
+		{name: "no subject", overrides: jwt.MapClaims{"sub": nil}, nonce: testNonce, wantErr: "no usable subject"},
+		{name: "no mapped group", overrides: jwt.MapClaims{"groups": []string{"marketing"}}, nonce: testNonce, wantErr: ErrNoRoleMapped.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.issue(t, "key-1", m.claims("alice", tt.overrides))
+			identity, err := m.provider().Exchange(context.Background(), testCode, tt.nonce)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := OIDCUsername(m.server.URL, "sub-alice")
+			if identity.Username != want || identity.Role != tt.wantRole || identity.Source != OIDCSourceName {
+				t.Errorf("identity %+v, want %s with role %s", identity, want, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeBadCode(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	m.issue(t, "key-1", m.claims("alice", nil))
+	if _, err := m.provider().Exchange(context.Background(), "stolen-code", testNonce); err == nil {
+		t.Fatal("exchange with an invalid code succeeded")
+	}
+}
+
+func TestOIDCUnknownKidRefreshesJWKS(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	p := m.provider()
+	m.issue(t, "key-1", m.claims("alice", nil))
+	if _, err := p.Exchange(context.Background(), testCode, testNonce); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Exchange(context.Background(), testCode, testNonce); err != nil {
+		t.Fatal(err)
+	}
+	if m.jwksFetch != 1 {
+		t.Fatalf("JWKS fetched %d times for a known key, want 1", m.jwksFetch)
+	}
+
+	// The provider rotates to a new key: the unknown kid triggers one refresh
+	m.addKey(t, "key-2")
+	m.issue(t, "key-2", m.claims("bob", nil))
+	identity, err := p.Exchange(context.Background(), testCode, testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != OIDCUsername(m.server.URL, "sub-bob") || m.jwksFetch != 2 {
+		t.Errorf("identity %+v after %d JWKS fetches, want bob after 2", identity, m.jwksFetch)
+	}
+
+	// A kid the provider does not publish is rejected after refreshing
+	m.mu.Lock()
+	m.keys["key-3"], _ = rsa.GenerateKey(rand.Reader, 2048)
+	m.mu.Unlock()
+	m.issue(t, "key-3", m.claims("mallory", nil))
+	m.mu.Lock()
+	delete(m.keys, "key-3")
+	m.mu.Unlock()
+	if _, err := p.Exchange(context.Background(), testCode, testNonce); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Errorf("error %v, want unknown signing key", err)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	p := m.provider()
+	p.config.IssuerURL = m.server.URL + "/realms/other"
+	_, err := p.AuthCodeURL(context.Background(), "state", testNonce)
+	if err == nil {
+		t.Fatal("discovery for another issuer succeeded")
+	}
+	if errors.Is(err, ErrOIDCNotConfigured) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	got, err := m.provider().AuthCodeURL(context.Background(), "state-1", testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{m.server.URL + "/authorize?", "client_id=kafka-ui", "state=state-1", "nonce=nonce-1", "scope=openid+profile", "response_type=code"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s does not contain %s", got, want)
+		}
+	}
+}
+
+func TestMapGroupsToRole(t *testing.T) {
+	roleMap := map[string]string{"viewers": utils.RoleViewer, "devs": utils.RoleProducer, "ops": utils.RoleOperator, "admins": utils.RoleAdmin}
+	tests := []struct {
+		name        string
+		groups      []string
+		defaultRole string
+		want        string
+	}{
+		{"single group", []string{"devs"}, "", utils.RoleProducer},
+		{"most privileged of several", []string{"viewers", "admins", "devs"}, "", utils.RoleAdmin},
+		{"unmapped groups use the default", []string{"marketing"}, utils.RoleViewer, utils.RoleViewer},
+		{"no groups and no default denies", nil, "", ""},
+		{"mapped group beats the default", []string{"ops"}, utils.RoleViewer, utils.RoleOperator},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MapGroupsToRole(tt.groups, roleMap, tt.defaultRole); got != tt.want {
+				t.Errorf("MapGroupsToRole(%v) = %q, want %q", tt.groups, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCNameCollidesWithLocalUser(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\nadmin,admin-pass,admin,false\n")
+	m := newMockOIDCProvider(t)
+	m.issue(t, "key-1", m.claims("admin", jwt.MapClaims{"sub": "admin", "email": "admin"}))
+	identity, err := m.provider().Exchange(context.Background(), testCode, testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username == "admin" || identity.IsLocal() {
+		t.Fatalf("identity provider user %+v was mapped to the local admin", identity)
+	}
+
+	// The session carries the namespaced name and its source, also after a refresh
+	tokens, err := IssueTokens(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sub"] != OIDCUsername(m.server.URL, "admin") || claims["src"] != OIDCSourceName {
+		t.Errorf("access token claims %v", claims)
+	}
+	refreshed, err := RefreshTokens(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, _ = ParseAccessToken(refreshed.AccessToken); claims["src"] != OIDCSourceName {
+		t.Errorf("refreshed access token claims %v", claims)
+	}
+
+	// Revoking the local admin leaves the identity provider session alone, and the other way round
+	if err := RevokeUser("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccessToken(refreshed.AccessToken); err != nil {
+		t.Errorf("revoking the local admin revoked the SSO session: %v", err)
+	}
+	local, err := NewCSVAuthenticator().Authenticate("admin", "admin-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !local.IsLocal() {
+		t.Errorf("local identity %+v is not local", local)
+	}
+}
+
+func TestTokensWithoutSourceRejected(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	now := time.Now()
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "admin", "role": utils.RoleAdmin, "jti": "legacy", "gen": 0, "iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+	}).SignedString(JWTSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccessToken(legacy); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token without a source: error %v, want %v", err, ErrInvalidToken)
+	}
+
+	tokens, err := IssueTokens(&Identity{Username: "admin", Role: utils.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RefreshTokens(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh token without a source: error %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
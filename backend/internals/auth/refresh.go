@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// refresh.go - Manages rotating refresh tokens persisted under data/.
+// A refresh token is "<id>.<secret>"; only a hash of the secret is stored. Each refresh consumes the
+// token and issues a new one in the same family. Presenting an already-used token is treated as theft
+// and revokes the whole family.
+
+// refreshTokenRecord is the persisted form of a refresh token.
+type refreshTokenRecord struct {
+	ID         string   `json:"id"`         // Public token ID
+	Family     string   `json:"family"`     // ID of the first token issued at login
+	Hash       string   `json:"hash"`       // SHA-256 of the secret part
+	Username   string   `json:"username"`   // Owner
+	Role       string   `json:"role"`       // Role at login
+	Groups     []string `json:"groups"`     // Groups at login
+	Source     string   `json:"source"`     // Backend the user logged in with
+	Generation int64    `json:"generation"` // User token generation at issue time
+	ExpiresAt  int64    `json:"expiresAt"`  // Expiry (unix seconds)
+	Used       bool     `json:"used"`       // Whether the token has been rotated
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+var refreshMutex sync.Mutex
+
+func loadRefreshTokens() (map[string]*refreshTokenRecord, error) {
+	records := map[string]*refreshTokenRecord{}
+	if err := store.Documents().ReadDocument(utils.RefreshTokensFileName, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveRefreshTokens(records map[string]*refreshTokenRecord) error {
+	now := time.Now().Unix()
+	for id, r := range records {
+		if r.ExpiresAt < now {
+			delete(records, id)
+		}
+	}
+	return store.Documents().WriteDocument(utils.RefreshTokensFileName, records)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueRefreshToken creates a refresh token for identity in family (a new family when empty).
+// Returns the raw token and the user's current token generation.
+func issueRefreshToken(identity *Identity, family string) (string, int64, error) {
+	generation, err := TokenGeneration(identity.Username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	records, err := loadRefreshTokens()
+	if err != nil {
+		return "", 0, err
+	}
+	raw, err := addRefreshToken(records, identity, family, generation)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := saveRefreshTokens(records); err != nil {
+		return "", 0, err
+	}
+	return raw, generation, nil
+}
+
+// addRefreshToken creates a new record in records and returns the raw token.
+func addRefreshToken(records map[string]*refreshTokenRecord, identity *Identity, family string, generation int64) (string, error) {
+	id, err := RandomString(12)
+	if err != nil {
+		return "", err
+	}
+	secret, err := RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	if family == "" {
+		family = id
+	}
+	records[id] = &refreshTokenRecord{
+		ID:         id,
+		Family:     family,
+		Hash:       hashSecret(secret),
+		Username:   identity.Username,
+		Role:       identity.Role,
+		Groups:     identity.Groups,
+		Source:     identity.Source,
+		Generation: generation,
+		ExpiresAt:  time.Now().Add(RefreshTokenTTL).Unix(),
+	}
+	return id + "." + secret, nil
+}
+
+// findRefreshToken looks up and verifies a raw token against records.
+func findRefreshToken(records map[string]*refreshTokenRecord, raw string) (*refreshTokenRecord, error) {
+	id, secret, ok := strings.Cut(raw, ".")
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	record, ok := records[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(record.Hash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrInvalidRefreshToken
+	}
+	return record, nil
+}
+
+// rotateRefreshToken consumes a refresh token and issues its successor.
+func rotateRefreshToken(raw string) (*Identity, string, int64, error) {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	records, err := loadRefreshTokens()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	record, err := findRefreshToken(records, raw)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if record.Used {
+		// A consumed token was presented again: assume it was stolen and end the session
+		deleteFamily(records, record.Family)
+		if err := saveRefreshTokens(records); err != nil {
+			return nil, "", 0, err
+		}
+		return nil, "", 0, ErrRefreshTokenReused
+	}
+	// Sessions from before identity sources were recorded must log in again
+	if record.ExpiresAt < time.Now().Unix() || record.Source == "" {
+		return nil, "", 0, ErrInvalidRefreshToken
+	}
+	generation, err := TokenGeneration(record.Username)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if record.Generation < generation {
+		return nil, "", 0, ErrInvalidRefreshToken
+	}
+
+	// Keep the used record until it expires so reuse can be detected
+	record.Used = true
+	identity := &Identity{Username: record.Username, Role: record.Role, Groups: record.Groups, Source: record.Source}
+	next, err := addRefreshToken(records, identity, record.Family, generation)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if err := saveRefreshTokens(records); err != nil {
+		return nil, "", 0, err
+	}
+	return identity, next, generation, nil
+}
+
+// RevokeRefreshToken ends the session a refresh token belongs to.
+func RevokeRefreshToken(raw string) error {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	records, err := loadRefreshTokens()
+	if err != nil {
+		return err
+	}
+	record, err := findRefreshToken(records, raw)
+	if err != nil {
+		return err
+	}
+	deleteFamily(records, record.Family)
+	return saveRefreshTokens(records)
+}
+
+// revokeUserRefreshTokens deletes every refresh token of username.
+func revokeUserRefreshTokens(username string) error {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	records, err := loadRefreshTokens()
+	if err != nil {
+		return err
+	}
+	for id, r := range records {
+		if r.Username == username {
+			delete(records, id)
+		}
+	}
+	return saveRefreshTokens(records)
+}
+
+func deleteFamily(records map[string]*refreshTokenRecord, family string) {
+	for id, r := range records {
+		if r.Family == family {
+			delete(records, id)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// revocation.go - Maintains the server-side revocation list consulted by JWTMiddleware.
+// Individual access tokens are revoked by ID (jti) on logout. Every token also carries the user's
+// token generation; bumping the generation on password change or account disable revokes all
+// tokens issued before. The list is persisted under data/.
+
+// revocationList is the persisted form of the revocation list.
+type revocationList struct {
+	Tokens map[string]int64 `json:"tokens"` // Revoked token ID to its expiry (unix seconds)
+	Users  map[string]int64 `json:"users"`  // Username to current token generation; older tokens are revoked
+}
+
+var (
+	revocations      *revocationList
+	revocationsMutex sync.RWMutex
+)
+
+// loadRevocations reads the revocation list on first use. Callers must hold revocationsMutex for writing.
+func loadRevocations() error {
+	if revocations != nil {
+		return nil
+	}
+	list := &revocationList{}
+	if err := store.Documents().ReadDocument(utils.RevocationsFileName, list); err != nil {
+		return err
+	}
+	if list.Tokens == nil {
+		list.Tokens = map[string]int64{}
+	}
+	if list.Users == nil {
+		list.Users = map[string]int64{}
+	}
+	revocations = list
+	return nil
+}
+
+// readRevocations calls fn with the revocation list loaded and revocationsMutex held for reading.
+func readRevocations(fn func()) error {
+	revocationsMutex.RLock()
+	loaded := revocations != nil
+	revocationsMutex.RUnlock()
+	if !loaded {
+		revocationsMutex.Lock()
+		err := loadRevocations()
+		revocationsMutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	revocationsMutex.RLock()
+	defer revocationsMutex.RUnlock()
+	fn()
+	return nil
+}
+
+// saveRevocations prunes expired entries and persists the list. Callers must hold revocationsMutex for writing.
+func saveRevocations() error {
+	now := time.Now().Unix()
+	for jti, exp := range revocations.Tokens {
+		if exp < now {
+			delete(revocations.Tokens, jti)
+		}
+	}
+	return store.Documents().WriteDocument(utils.RevocationsFileName, revocations)
+}
+
+// RevokeToken revokes a single access token until its expiry.
+func RevokeToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	revocationsMutex.Lock()
+	defer revocationsMutex.Unlock()
+	if err := loadRevocations(); err != nil {
+		return err
+	}
+	revocations.Tokens[jti] = expiresAt.Unix()
+	return saveRevocations()
+}
+
+// RevokeUser revokes every access and refresh token issued to username so far and deletes their API keys.
+func RevokeUser(username string) error {
+	if err := revokeUserRefreshTokens(username); err != nil {
+		return err
+	}
+	if err := DeleteUserAPIKeys(username); err != nil {
+		return err
+	}
+	revocationsMutex.Lock()
+	defer revocationsMutex.Unlock()
+	if err := loadRevocations(); err != nil {
+		return err
+	}
+	revocations.Users[username]++
+	return saveRevocations()
+}
+
+// TokenGeneration returns the current token generation of username.
+func TokenGeneration(username string) (int64, error) {
+	var generation int64
+	err := readRevocations(func() {
+		generation = revocations.Users[username]
+	})
+	return generation, err
+}
+
+// IsRevoked reports whether the access token with the given ID, subject and generation has been revoked.
+// It is called on every authenticated request, so it only takes the read lock; expired entries are
+// pruned when the list is next written.
+func IsRevoked(jti, username string, generation int64) (bool, error) {
+	revoked := false
+	err := readRevocations(func() {
+		_, revoked = revocations.Tokens[jti]
+		revoked = revoked || generation < revocations.Users[username]
+	})
+	return revoked, err
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"backend/internals/utils"
+)
+
+// static.go - Authenticates against a read-only JSON file of users.
+// Useful for deployments where accounts are provisioned by configuration management.
+//
+// Example file:
+//
+//	{
+//	  "users": [
+//	    { "username": "ci", "passwordHash": "$2a$10$...", "role": "producer", "groups": ["ci"] }
+//	  ]
+//	}
+
+// StaticBackendName is the AUTH_BACKEND value selecting the static file store.
+const StaticBackendName = "static"
+
+// StaticUser is a user entry in the static users file.
+type StaticUser struct {
+	Username     string   `json:"username"`     // Username
+	PasswordHash string   `json:"passwordHash"` // bcrypt hash of the password
+	Role         string   `json:"role"`         // Backend role
+	Groups       []string `json:"groups"`       // Optional groups for topic policies
+	Disabled     bool     `json:"disabled"`     // Whether the account is disabled
+}
+
+// StaticFileAuthenticator authenticates users listed in a static JSON file.
+type StaticFileAuthenticator struct {
+	users map[string]StaticUser
+}
+
+// NewStaticFileAuthenticator loads users from the given file.
+// Plain-text passwords are rejected so the file never holds reusable secrets.
+func NewStaticFileAuthenticator(filePath string) (*StaticFileAuthenticator, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static users file: %w", err)
+	}
+	var file struct {
+		Users []StaticUser `json:"users"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse static users file: %w", err)
+	}
+
+	users := make(map[string]StaticUser, len(file.Users))
+	for _, u := range file.Users {
+		if !utils.IsValidRole(u.Role) {
+			return nil, fmt.Errorf("static user %q has invalid role %q", u.Username, u.Role)
+		}
+		if !utils.IsPasswordHash(u.PasswordHash) {
+			return nil, fmt.Errorf("static user %q must use a bcrypt passwordHash", u.Username)
+		}
+		users[u.Username] = u
+	}
+	return &StaticFileAuthenticator{users: users}, nil
+}
+
+// NewStaticFileAuthenticatorFromEnv loads the file named by AUTH_STATIC_FILE,
+// falling back to data/static_users.json.
+func NewStaticFileAuthenticatorFromEnv() (*StaticFileAuthenticator, error) {
+	filePath := os.Getenv(utils.AuthStaticFileEnv)
+	if filePath == "" {
+		filePath = filepath.Join(utils.UsersDataDir, utils.StaticUsersFileName)
+	}
+	return NewStaticFileAuthenticator(filePath)
+}
+
+// Name returns the configuration name of the backend.
+func (a *StaticFileAuthenticator) Name() string {
+	return StaticBackendName
+}
+
+// Authenticate verifies the credentials against the loaded users.
+func (a *StaticFileAuthenticator) Authenticate(username, password string) (*Identity, error) {
+	user, ok := a.users[username]
+	if !ok {
+		utils.RejectMissingUser(password)
+		return nil, ErrInvalidCredentials
+	}
+	if ok, _ := utils.VerifyPassword(user.PasswordHash, password); !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return &Identity{Username: user.Username, Role: user.Role, Groups: user.Groups, Source: StaticBackendName}, nil
+}
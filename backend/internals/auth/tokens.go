@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"backend/internals/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokens.go - Issues and validates the session tokens accepted by JWTMiddleware.
+// Every login method (local password, OIDC) ends with a call to IssueTokens so the resulting
+// short-lived access token always carries the same claims, paired with a rotating refresh token.
+
+const (
+	// AccessTokenTTL is the lifetime of an access token.
+	AccessTokenTTL = 15 * time.Minute
+
+	// RefreshTokenTTL is the lifetime of a refresh token.
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+var (
+	ErrTokenExpired = utils.ErrTokenExpired
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrInvalidToken = utils.ErrInvalidToken
+)
+
+// TokenPair is the response of a successful login or refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`        // Short-lived access JWT
+	RefreshToken string `json:"refreshToken"` // Opaque single-use refresh token
+	ExpiresIn    int    `json:"expiresIn"`    // Access token lifetime in seconds
+}
+
+// JWTSecret returns the HMAC secret used to sign session tokens.
+// Uses the JWT_SECRET env var, falling back to the built-in default.
+func JWTSecret() []byte {
+	secret := os.Getenv(utils.JWTSecretKeyEnv)
+	if secret == "" {
+		secret = utils.DefaultJWTSecret
+	}
+	return []byte(secret)
+}
+
+// IssueTokens creates an access token and a new refresh token family for identity.
+func IssueTokens(identity *Identity) (*TokenPair, error) {
+	refreshToken, generation, err := issueRefreshToken(identity, "")
+	if err != nil {
+		return nil, err
+	}
+	return newTokenPair(identity, refreshToken, generation)
+}
+
+// RefreshTokens rotates a refresh token, returning a new access token and refresh token.
+func RefreshTokens(rawRefreshToken string) (*TokenPair, error) {
+	identity, refreshToken, generation, err := rotateRefreshToken(rawRefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenPair(identity, refreshToken, generation)
+}
+
+func newTokenPair(identity *Identity, refreshToken string, generation int64) (*TokenPair, error) {
+	accessToken, err := issueAccessToken(identity, generation)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// issueAccessToken creates a signed access JWT for identity.
+func issueAccessToken(identity *Identity, generation int64) (string, error) {
+	jti, err := RandomString(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  identity.Username,
+		"role": identity.Role,
+		"src":  identity.Source,
+		"jti":  jti,
+		"gen":  generation,
+		"iat":  now.Unix(),
+		"exp":  now.Add(AccessTokenTTL).Unix(),
+	}
+	if len(identity.Groups) > 0 {
+		claims["groups"] = identity.Groups
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(JWTSecret())
+}
+
+// ParseAccessToken validates the signature, expiry and revocation status of an access token.
+// Returns ErrTokenExpired, ErrTokenRevoked or ErrInvalidToken on failure.
+func ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return JWTSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens without a source predate namespaced usernames and cannot be told apart from local accounts
+	if source, _ := claims["src"].(string); source == "" {
+		return nil, ErrInvalidToken
+	}
+	username, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	generation, _ := claims["gen"].(float64)
+	revoked, err := IsRevoked(jti, username, int64(generation))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+func testIdentity(username string) *Identity {
+	return &Identity{Username: username, Role: utils.RoleOperator, Groups: []string{"team"}, Source: CSVBackendName}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	first, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := RefreshTokens(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	claims, err := ParseAccessToken(second.AccessToken)
+	if err != nil {
+		t.Fatalf("rotated access token: %v", err)
+	}
+	if claims["sub"] != "alice" || claims["role"] != utils.RoleOperator || claims["src"] != CSVBackendName {
+		t.Errorf("rotated claims %v, want the identity of the login", claims)
+	}
+
+	// Presenting the consumed token again ends the whole session, including its successor
+	if _, err := RefreshTokens(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: error %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := RefreshTokens(second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("successor after reuse: error %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	pair, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _, _ := strings.Cut(pair.RefreshToken, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"unknown id", "unknown." + strings.Repeat("a", 32)},
+		{"wrong secret", id + ".wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RefreshTokens(tt.token); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("error %v, want %v", err, ErrInvalidRefreshToken)
+			}
+		})
+	}
+	if _, err := RefreshTokens(pair.RefreshToken); err != nil {
+		t.Errorf("valid token after failed attempts: %v", err)
+	}
+}
+
+func TestExpiredRefreshToken(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	pair, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := loadRefreshTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		r.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	}
+	if err := store.Documents().WriteDocument(utils.RefreshTokensFileName, records); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RefreshTokens(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("error %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	alice, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := IssueTokens(testIdentity("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseAccessToken(alice.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token: error %v, want %v", err, ErrTokenRevoked)
+	}
+	if _, err := RefreshTokens(alice.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh token: error %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if _, err := ParseAccessToken(bob.AccessToken); err != nil {
+		t.Errorf("other user's access token: %v", err)
+	}
+
+	// Tokens issued after the revocation carry the new generation
+	again, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccessToken(again.AccessToken); err != nil {
+		t.Errorf("access token after a new login: %v", err)
+	}
+}
+
+func TestLogoutRevocation(t *testing.T) {
+	useTestUsers(t, "username,password,role,disabled\n")
+	first, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := IssueTokens(testIdentity("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseAccessToken(first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jti, _ := claims["jti"].(string)
+	if err := RevokeToken(jti, time.Now().Add(AccessTokenTTL)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeRefreshToken(first.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseAccessToken(first.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("logged out access token: error %v, want %v", err, ErrTokenRevoked)
+	}
+	if _, err := RefreshTokens(first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("logged out refresh token: error %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	// The user's other sessions are unaffected
+	if _, err := ParseAccessToken(other.AccessToken); err != nil {
+		t.Errorf("other session's access token: %v", err)
+	}
+	if _, err := RefreshTokens(other.RefreshToken); err != nil {
+		t.Errorf("other session's refresh token: %v", err)
+	}
+
+	// Revocations are persisted: a fresh load of the list still rejects the token
+	revocations = nil
+	if _, err := ParseAccessToken(first.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("after reload: error %v, want %v", err, ErrTokenRevoked)
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 Appendix B test vectors.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 Appendix B lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		want := tt.want[len(tt.want)-totpDigits:]
+		if got := totpCode([]byte("12345678901234567890"), tt.unix/30); got != want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, want)
+		}
+		now := time.Unix(tt.unix, 0)
+		if _, ok := verifyTOTP(rfc6238Secret, want, now, 0); !ok {
+			t.Errorf("verifyTOTP rejected the code at %d", tt.unix)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / 30
+	key := []byte("12345678901234567890")
+	codeAt := func(offset int64) string { return totpCode(key, current+offset) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, codeAt(0), 0, current, true},
+		{"previous step", rfc6238Secret, codeAt(-1), 0, current - 1, true},
+		{"next step", rfc6238Secret, codeAt(1), 0, current + 1, true},
+		{"two steps behind", rfc6238Secret, codeAt(-2), 0, 0, false},
+		{"two steps ahead", rfc6238Secret, codeAt(2), 0, 0, false},
+		{"code with spaces", rfc6238Secret, codeAt(0)[:3] + " " + codeAt(0)[3:], 0, current, true},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), codeAt(0), 0, current, true},
+		{"replayed step", rfc6238Secret, codeAt(0), current, 0, false},
+		{"step before the last accepted one", rfc6238Secret, codeAt(-1), current, 0, false},
+		{"step after the last accepted one", rfc6238Secret, codeAt(1), current, current + 1, true},
+		{"wrong code", rfc6238Secret, "000000", 0, 0, false},
+		{"too short", rfc6238Secret, codeAt(0)[1:], 0, 0, false},
+		{"too long", rfc6238Secret, codeAt(0) + "0", 0, 0, false},
+		{"invalid secret", "not base32!", codeAt(0), 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("verifyTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != totpSecretSize {
+		t.Fatalf("secret %q decodes to %d bytes (%v), want %d", secret, len(key), err, totpSecretSize)
+	}
+	if other, _ := GenerateTOTPSecret(); other == secret {
+		t.Error("two generated secrets are equal")
+	}
+
+	u, err := url.Parse(TOTPURI("Kafka UI", "alice", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || q.Get("secret") != secret || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("URI %s does not describe the secret", u)
+	}
+}
@@ -0,0 +1,225 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/internals/models"
+	"backend/internals/utils"
+)
+
+// monitor.go - Polls broker liveness in the background and keeps a status history per cluster.
+// Every poll leases the cluster's client like a request does, so monitored clusters keep their
+// pooled client open. Results of GET /brokers requests are recorded in the same history.
+
+// DefaultBrokerPollInterval is how often brokers are probed when BROKER_POLL_INTERVAL is not set.
+const DefaultBrokerPollInterval = 30 * time.Second
+
+// BrokerHistorySize is the number of status samples kept per broker.
+const BrokerHistorySize = 120
+
+// brokerPollTimeout bounds the metadata request and liveness probes of one cluster.
+const brokerPollTimeout = 20 * time.Second
+
+// BrokerPollIntervalFromEnv returns the interval configured by BROKER_POLL_INTERVAL (e.g. "1m").
+// "0" disables background polling.
+func BrokerPollIntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv(utils.BrokerPollIntervalEnv)
+	if value == "" {
+		return DefaultBrokerPollInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", utils.BrokerPollIntervalEnv, value)
+	}
+	return interval, nil
+}
+
+// brokerRecord is the status history of one broker.
+type brokerRecord struct {
+	address  string
+	lastSeen *time.Time
+	samples  []models.BrokerStatusSample
+}
+
+// BrokerMonitor keeps the broker status history of every cluster. It is safe for concurrent use.
+type BrokerMonitor struct {
+	registry *Registry
+	interval time.Duration
+
+	mu       sync.Mutex
+	clusters map[string]map[int32]*brokerRecord
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBrokerMonitor creates a monitor for the clusters of registry and starts polling them every
+// interval. With a zero interval only the results passed to Record are kept.
+func NewBrokerMonitor(registry *Registry, interval time.Duration) *BrokerMonitor {
+	m := &BrokerMonitor{
+		registry: registry,
+		interval: interval,
+		clusters: map[string]map[int32]*brokerRecord{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if interval > 0 {
+		go m.pollLoop()
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// Record adds the status of brokers to the history of a cluster and sets the LastSeen of brokers
+// that did not answer to the last time they did.
+func (m *BrokerMonitor) Record(cluster string, brokers []models.Broker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := m.clusters[cluster]
+	if records == nil {
+		records = map[int32]*brokerRecord{}
+		m.clusters[cluster] = records
+	}
+	for i := range brokers {
+		broker := &brokers[i]
+		record := records[broker.ID]
+		if record == nil {
+			record = &brokerRecord{}
+			records[broker.ID] = record
+		}
+		if broker.Address != "" {
+			record.address = broker.Address
+		}
+		if broker.LastSeen != nil {
+			record.lastSeen = broker.LastSeen
+		} else {
+			broker.LastSeen = record.lastSeen
+		}
+		record.add(models.BrokerStatusSample{
+			Time:      broker.CheckedAt,
+			Status:    broker.Status,
+			Detail:    broker.StatusDetail,
+			LatencyMs: broker.LatencyMs,
+		})
+	}
+}
+
+// recordFailure marks every known broker of a cluster as unreachable after the cluster could not be queried.
+func (m *BrokerMonitor) recordFailure(cluster string, err error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, record := range m.clusters[cluster] {
+		record.add(models.BrokerStatusSample{
+			Time:   now,
+			Status: models.BrokerUnreachable,
+			Detail: err.Error(),
+		})
+	}
+}
+
+func (r *brokerRecord) add(sample models.BrokerStatusSample) {
+	r.samples = append(r.samples, sample)
+	if len(r.samples) > BrokerHistorySize {
+		r.samples = append([]models.BrokerStatusSample{}, r.samples[len(r.samples)-BrokerHistorySize:]...)
+	}
+}
+
+// History returns the status history of the brokers of a cluster, sorted by broker ID.
+func (m *BrokerMonitor) History(cluster string) []models.BrokerHistory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := make([]models.BrokerHistory, 0, len(m.clusters[cluster]))
+	for id, record := range m.clusters[cluster] {
+		history = append(history, models.BrokerHistory{
+			ID:       id,
+			Address:  record.address,
+			LastSeen: record.lastSeen,
+			Samples:  append([]models.BrokerStatusSample{}, record.samples...),
+		})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].ID < history[j].ID })
+	return history
+}
+
+// Interval returns the background polling interval (0 if polling is disabled).
+func (m *BrokerMonitor) Interval() time.Duration {
+	return m.interval
+}
+
+func (m *BrokerMonitor) pollLoop() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	m.poll()
+	for {
+		select {
+		case <-ticker.C:
+			m.poll()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// poll probes all clusters concurrently and forgets the history of removed ones.
+func (m *BrokerMonitor) poll() {
+	defs := m.registry.List()
+	names := make(map[string]bool, len(defs))
+	var wg sync.WaitGroup
+	for _, def := range defs {
+		names[def.Name] = true
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			m.pollCluster(name)
+		}(def.Name)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name := range m.clusters {
+		if !names[name] {
+			delete(m.clusters, name)
+		}
+	}
+}
+
+func (m *BrokerMonitor) pollCluster(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), brokerPollTimeout)
+	defer cancel()
+	lease, err := m.registry.Acquire(ctx, name)
+	if errors.Is(err, ErrClusterNotFound) {
+		return
+	}
+	if err != nil {
+		m.recordFailure(name, err)
+		return
+	}
+	defer lease.Release()
+
+	brokers, err := lease.Client().GetBrokers(ctx, nil)
+	if err != nil {
+		m.recordFailure(name, err)
+		return
+	}
+	m.Record(name, brokers)
+}
+
+// Close stops background polling and waits for a running poll to finish.
+func (m *BrokerMonitor) Close() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
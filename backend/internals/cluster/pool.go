@@ -0,0 +1,370 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/internals/kafka"
+	"backend/internals/utils"
+)
+
+// pool.go - Caches Kafka clients by their connection parameters.
+// Every request holds a lease on the client it uses; clients that nobody holds are closed once
+// they have been idle for the TTL, and clients whose cluster was changed or removed are closed
+// as soon as their last lease is released, so connections are never leaked or cut mid-request.
+
+// DefaultIdleTTL is how long an unused client stays open when KAFKA_CLIENT_IDLE_TTL is not set.
+const DefaultIdleTTL = 10 * time.Minute
+
+// IdleTTLFromEnv returns the idle TTL configured by KAFKA_CLIENT_IDLE_TTL (e.g. "5m").
+func IdleTTLFromEnv() (time.Duration, error) {
+	value := os.Getenv(utils.KafkaClientIdleTTLEnv)
+	if value == "" {
+		return DefaultIdleTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", utils.KafkaClientIdleTTLEnv, value)
+	}
+	return ttl, nil
+}
+
+// poolEntry is one cached client. Its fields are guarded by Pool.mu, except client and err,
+// which are written once before ready is closed.
+type poolEntry struct {
+	id        int64
+	key       string
+	servers   []string
+	mechanism string
+	tls       bool
+	clusters  map[string]bool
+
+	ready  chan struct{} // Closed once connecting has finished
+	client kafka.KafkaService
+	err    error
+
+	refs      int
+	requests  int64
+	retired   bool
+	closed    bool
+	createdAt time.Time
+	lastUsed  time.Time
+}
+
+// Pool caches one client per distinct set of connection parameters. It is safe for concurrent use.
+type Pool struct {
+	connect Connector
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+	retired map[*poolEntry]bool // Retired entries waiting for their last lease
+	lastID  int64
+	opened  int64
+	closed  int64
+	failed  int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPool creates a pool connecting with connect and starts closing clients idle for longer than ttl.
+func NewPool(connect Connector, ttl time.Duration) *Pool {
+	p := &Pool{
+		connect: connect,
+		ttl:     ttl,
+		entries: map[string]*poolEntry{},
+		retired: map[*poolEntry]bool{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.reapLoop()
+	return p
+}
+
+// Lease is a hold on a pooled client. Release must be called once the client is no longer used.
+type Lease struct {
+	pool  *Pool
+	entry *poolEntry
+	once  sync.Once
+}
+
+// Client returns the leased client.
+func (l *Lease) Client() kafka.KafkaService {
+	return l.entry.client
+}
+
+// Release returns the lease to the pool. Calling it more than once has no effect.
+func (l *Lease) Release() {
+	l.once.Do(func() { l.pool.release(l.entry) })
+}
+
+// fingerprint identifies the connection parameters of a definition. The cluster name and
+// timestamps are not part of it, so clusters with identical settings share a client.
+// It is derived from the credentials and must never leave the process; connections are
+// identified by their pool ID instead.
+func fingerprint(def Definition) string {
+	params, _ := json.Marshal(struct {
+		BootstrapServers []string      `json:"bootstrapServers"`
+		SASL             *SASLSettings `json:"sasl"`
+		TLS              *TLSSettings  `json:"tls"`
+	}{def.BootstrapServers, def.SASL, def.TLS})
+	sum := sha256.Sum256(params)
+	return hex.EncodeToString(sum[:])
+}
+
+// Acquire leases the client for def, connecting if the pool has none for its parameters.
+// Concurrent requests for the same parameters wait for a single connection attempt. When ctx ends
+// first, Acquire returns its error and the attempt carries on in the background for the others.
+func (p *Pool) Acquire(ctx context.Context, def Definition) (*Lease, error) {
+	key := fingerprint(def)
+	now := time.Now()
+
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	if !ok {
+		p.lastID++
+		entry = &poolEntry{
+			id:        p.lastID,
+			key:       key,
+			servers:   append([]string{}, def.BootstrapServers...),
+			tls:       def.TLS != nil,
+			clusters:  map[string]bool{},
+			ready:     make(chan struct{}),
+			createdAt: now,
+		}
+		if def.SASL != nil {
+			entry.mechanism = def.SASL.Mechanism
+		}
+		p.entries[key] = entry
+	}
+	entry.clusters[def.Name] = true
+	entry.refs++
+	entry.requests++
+	entry.lastUsed = now
+	p.mu.Unlock()
+
+	if !ok {
+		go p.open(entry, def)
+	}
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		p.release(entry)
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		p.release(entry)
+		return nil, entry.err
+	}
+	return &Lease{pool: p, entry: entry}, nil
+}
+
+// open connects the client of a new entry and wakes up the requests waiting for it.
+func (p *Pool) open(entry *poolEntry, def Definition) {
+	client, err := p.connect(def)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		entry.err = fmt.Errorf("failed to connect to cluster %s: %w", def.Name, err)
+		p.failed++
+		if p.entries[entry.key] == entry {
+			delete(p.entries, entry.key)
+		}
+		delete(p.retired, entry)
+	} else {
+		entry.client = client
+		p.opened++
+		// Every request waiting for this retired client gave up before it connected
+		if entry.retired && entry.refs == 0 {
+			delete(p.retired, entry)
+			p.closeEntry(entry)
+		}
+	}
+	close(entry.ready)
+}
+
+// release drops one reference, closing the client if it was retired and this was the last lease.
+func (p *Pool) release(entry *poolEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry.refs--
+	entry.lastUsed = time.Now()
+	if entry.retired && entry.refs == 0 && entry.client != nil {
+		delete(p.retired, entry)
+		p.closeEntry(entry)
+	}
+}
+
+// Retire stops handing out the client for def's parameters. It is closed immediately when idle,
+// otherwise when its last lease is released.
+func (p *Pool) Retire(def Definition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[fingerprint(def)]
+	if !ok {
+		return
+	}
+	delete(p.entries, entry.key)
+	entry.retired = true
+	select {
+	case <-entry.ready:
+		if entry.refs == 0 && entry.client != nil {
+			p.closeEntry(entry)
+			return
+		}
+	default:
+	}
+	p.retired[entry] = true
+}
+
+// closeEntry closes the client of an entry once. Callers must hold p.mu.
+func (p *Pool) closeEntry(entry *poolEntry) {
+	if entry.closed {
+		return
+	}
+	entry.closed = true
+	entry.client.Close()
+	p.closed++
+}
+
+// reapLoop periodically closes idle clients until Close is called.
+func (p *Pool) reapLoop() {
+	defer close(p.done)
+	interval := p.ttl / 4
+	if interval < time.Second {
+		interval = time.Second
+	} else if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.reap(now)
+		}
+	}
+}
+
+// reap closes the connected clients that have no leases and were last used before now minus the TTL.
+func (p *Pool) reap(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, entry := range p.entries {
+		if entry.refs == 0 && entry.client != nil && now.Sub(entry.lastUsed) >= p.ttl {
+			delete(p.entries, key)
+			p.closeEntry(entry)
+		}
+	}
+}
+
+// Close stops the reaper and closes every connected client, including leased ones.
+func (p *Pool) Close() {
+	close(p.stop)
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, entry := range p.entries {
+		if entry.client != nil {
+			p.closeEntry(entry)
+		}
+		delete(p.entries, key)
+	}
+	for entry := range p.retired {
+		if entry.client != nil {
+			p.closeEntry(entry)
+		}
+		delete(p.retired, entry)
+	}
+}
+
+// PoolStats describes the state of the pool.
+type PoolStats struct {
+	IdleTTL     time.Duration    `json:"-"`
+	Opened      int64            `json:"opened"` // Clients connected since startup
+	Closed      int64            `json:"closed"` // Clients closed since startup (idle or retired)
+	Failed      int64            `json:"failed"` // Failed connection attempts since startup
+	Connections []ConnectionInfo `json:"connections"`
+}
+
+// ConnectionInfo describes one pooled client. It never includes credentials.
+type ConnectionInfo struct {
+	ID               int64     `json:"id"`       // Sequential pool ID, unique for the process lifetime
+	Clusters         []string  `json:"clusters"` // Clusters that have used this client
+	BootstrapServers []string  `json:"bootstrapServers"`
+	SASLMechanism    string    `json:"saslMechanism,omitempty"`
+	TLS              bool      `json:"tls"`
+	State            string    `json:"state"` // connecting, active, idle or retired
+	Leases           int       `json:"leases"`
+	Requests         int64     `json:"requests"`
+	CreatedAt        time.Time `json:"createdAt"`
+	LastUsed         time.Time `json:"lastUsed"`
+}
+
+// Stats returns a snapshot of the pool, sorted by bootstrap servers.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := PoolStats{
+		IdleTTL:     p.ttl,
+		Opened:      p.opened,
+		Closed:      p.closed,
+		Failed:      p.failed,
+		Connections: []ConnectionInfo{},
+	}
+	for _, entry := range p.entries {
+		stats.Connections = append(stats.Connections, entry.info())
+	}
+	for entry := range p.retired {
+		stats.Connections = append(stats.Connections, entry.info())
+	}
+	sort.Slice(stats.Connections, func(i, j int) bool {
+		a, b := stats.Connections[i], stats.Connections[j]
+		if a.BootstrapServers[0] != b.BootstrapServers[0] {
+			return a.BootstrapServers[0] < b.BootstrapServers[0]
+		}
+		return a.ID < b.ID
+	})
+	return stats
+}
+
+// info describes an entry. Callers must hold Pool.mu.
+func (e *poolEntry) info() ConnectionInfo {
+	clusters := make([]string, 0, len(e.clusters))
+	for name := range e.clusters {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+	state := "idle"
+	switch {
+	case e.retired:
+		state = "retired"
+	case e.client == nil:
+		state = "connecting"
+	case e.refs > 0:
+		state = "active"
+	}
+	return ConnectionInfo{
+		ID:               e.id,
+		Clusters:         clusters,
+		BootstrapServers: append([]string{}, e.servers...),
+		SASLMechanism:    e.mechanism,
+		TLS:              e.tls,
+		State:            state,
+		Leases:           e.refs,
+		Requests:         e.requests,
+		CreatedAt:        e.createdAt,
+		LastUsed:         e.lastUsed,
+	}
+}
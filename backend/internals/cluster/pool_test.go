@@ -0,0 +1,370 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internals/kafka"
+	"backend/internals/utils"
+)
+
+// fakeClient is a KafkaService that only records Close.
+type fakeClient struct {
+	kafka.KafkaService
+	servers []string
+	closed  atomic.Int32
+}
+
+func (c *fakeClient) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+// fakeConnector counts connection attempts and hands out fakeClients. Connecting to a server
+// named "down:9092" fails.
+type fakeConnector struct {
+	mu      sync.Mutex
+	calls   int
+	clients []*fakeClient
+}
+
+func (f *fakeConnector) connect(def Definition) (kafka.KafkaService, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if def.BootstrapServers[0] == "down:9092" {
+		return nil, errors.New("connection refused")
+	}
+	client := &fakeClient{servers: def.BootstrapServers}
+	f.clients = append(f.clients, client)
+	return client, nil
+}
+
+func newTestPool(t *testing.T) (*Pool, *fakeConnector) {
+	t.Helper()
+	f := &fakeConnector{}
+	p := NewPool(f.connect, time.Hour)
+	t.Cleanup(p.Close)
+	return p, f
+}
+
+func testDefinition(name, server, password string) Definition {
+	def := Definition{Name: name, BootstrapServers: []string{server}}
+	if password != "" {
+		def.SASL = &SASLSettings{Mechanism: "PLAIN", Username: "svc", Password: password}
+	}
+	return def
+}
+
+func acquire(t *testing.T, p *Pool, def Definition) *Lease {
+	t.Helper()
+	lease, err := p.Acquire(context.Background(), def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lease
+}
+
+func TestFingerprint(t *testing.T) {
+	base := testDefinition("prod", "b1:9092", "pw")
+	tests := []struct {
+		name string
+		def  Definition
+		same bool
+	}{
+		{"other name", testDefinition("prod-alias", "b1:9092", "pw"), true},
+		{"other timestamps", Definition{Name: "prod", BootstrapServers: base.BootstrapServers, SASL: base.SASL, UpdatedAt: time.Now()}, true},
+		{"other server", testDefinition("prod", "b2:9092", "pw"), false},
+		{"other password", testDefinition("prod", "b1:9092", "pw2"), false},
+		{"no SASL", testDefinition("prod", "b1:9092", ""), false},
+		{"TLS", Definition{Name: "prod", BootstrapServers: base.BootstrapServers, SASL: base.SASL, TLS: &TLSSettings{}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(tt.def) == fingerprint(base); got != tt.same {
+				t.Errorf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestPoolSharesClients(t *testing.T) {
+	p, f := newTestPool(t)
+	a := acquire(t, p, testDefinition("a", "b1:9092", "hunter2-secret"))
+	b := acquire(t, p, testDefinition("b", "b1:9092", "hunter2-secret"))
+	c := acquire(t, p, testDefinition("c", "b1:9092", "other"))
+	if a.Client() != b.Client() {
+		t.Error("clusters with the same parameters got different clients")
+	}
+	if a.Client() == c.Client() {
+		t.Error("clusters with different credentials share a client")
+	}
+	if f.calls != 2 {
+		t.Errorf("connected %d times, want 2", f.calls)
+	}
+
+	stats := p.Stats()
+	if len(stats.Connections) != 2 || stats.Opened != 2 {
+		t.Fatalf("stats %+v, want 2 opened connections", stats)
+	}
+	shared := stats.Connections[0]
+	if shared.Leases != 2 || strings.Join(shared.Clusters, ",") != "a,b" || shared.State != "active" {
+		t.Errorf("shared connection %+v, want 2 leases by a and b", shared)
+	}
+	if stats.Connections[0].ID == stats.Connections[1].ID {
+		t.Error("connections have the same ID")
+	}
+	// Neither credentials nor the fingerprint derived from them are exposed
+	data, _ := json.Marshal(stats)
+	for _, secret := range []string{"hunter2-secret", fingerprint(testDefinition("a", "b1:9092", "hunter2-secret"))[:16]} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("pool stats expose %q: %s", secret, data)
+		}
+	}
+}
+
+func TestPoolConcurrentAcquireConnectsOnce(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	p := NewPool(func(def Definition) (kafka.KafkaService, error) {
+		calls.Add(1)
+		<-release
+		return &fakeClient{}, nil
+	}, time.Hour)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	leases := make([]*Lease, 10)
+	for i := range leases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leases[i], _ = p.Acquire(context.Background(), testDefinition("a", "b1:9092", ""))
+		}(i)
+	}
+	for stats := p.Stats(); len(stats.Connections) == 0 || stats.Connections[0].Leases < len(leases); stats = p.Stats() {
+		time.Sleep(time.Millisecond)
+	}
+	if state := p.Stats().Connections[0].State; state != "connecting" {
+		t.Errorf("state %s while connecting", state)
+	}
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("connected %d times, want 1", calls.Load())
+	}
+	for _, lease := range leases {
+		if lease == nil || lease.Client() != leases[0].Client() {
+			t.Fatal("concurrent requests got different clients")
+		}
+	}
+}
+
+func TestPoolConnectFailure(t *testing.T) {
+	p, f := newTestPool(t)
+	def := testDefinition("a", "down:9092", "")
+	for i := 0; i < 2; i++ {
+		if _, err := p.Acquire(context.Background(), def); err == nil || !strings.Contains(err.Error(), "failed to connect to cluster a") {
+			t.Fatalf("error %v, want a connection failure", err)
+		}
+	}
+	// Failures are not cached: every request retries
+	stats := p.Stats()
+	if f.calls != 2 || stats.Failed != 2 || len(stats.Connections) != 0 {
+		t.Errorf("%d calls, stats %+v; want 2 failed attempts and no connections", f.calls, stats)
+	}
+}
+
+func TestPoolRetire(t *testing.T) {
+	tests := []struct {
+		name       string
+		leases     int  // Leases held when the client is retired
+		wantClosed bool // Client closed right after Retire
+	}{
+		{name: "idle client closes immediately", leases: 0, wantClosed: true},
+		{name: "leased client waits for its lease", leases: 1, wantClosed: false},
+		{name: "leased client waits for every lease", leases: 3, wantClosed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, f := newTestPool(t)
+			def := testDefinition("a", "b1:9092", "")
+			acquire(t, p, def).Release()
+			var leases []*Lease
+			for i := 0; i < tt.leases; i++ {
+				leases = append(leases, acquire(t, p, def))
+			}
+			client := f.clients[0]
+
+			p.Retire(def)
+			if closed := client.closed.Load() == 1; closed != tt.wantClosed {
+				t.Fatalf("closed = %v after Retire, want %v", closed, tt.wantClosed)
+			}
+			if len(leases) > 0 && p.Stats().Connections[0].State != "retired" {
+				t.Errorf("state %s, want retired", p.Stats().Connections[0].State)
+			}
+
+			// New requests get a new client while the retired one drains
+			next := acquire(t, p, def)
+			if next.Client() == client {
+				t.Error("retired client was handed out again")
+			}
+			next.Release()
+
+			for i, lease := range leases {
+				lease.Release()
+				lease.Release() // Releasing twice has no effect
+				last := i == len(leases)-1
+				if got := client.closed.Load(); got != 0 && !last {
+					t.Fatalf("retired client closed with %d leases left", len(leases)-i-1)
+				}
+			}
+			if client.closed.Load() != 1 {
+				t.Errorf("retired client closed %d times, want 1", client.closed.Load())
+			}
+		})
+	}
+}
+
+func TestPoolReap(t *testing.T) {
+	p, f := newTestPool(t)
+	idle := acquire(t, p, testDefinition("idle", "b1:9092", ""))
+	recent := acquire(t, p, testDefinition("recent", "b2:9092", ""))
+	leased := acquire(t, p, testDefinition("leased", "b3:9092", ""))
+	idle.Release()
+	recent.Release()
+
+	p.mu.Lock()
+	for _, entry := range p.entries {
+		if entry.servers[0] != "b2:9092" {
+			entry.lastUsed = entry.lastUsed.Add(-2 * time.Hour)
+		}
+	}
+	p.mu.Unlock()
+
+	p.reap(time.Now())
+	closed := map[string]bool{}
+	for _, client := range f.clients {
+		closed[client.servers[0]] = client.closed.Load() > 0
+	}
+	want := map[string]bool{"b1:9092": true, "b2:9092": false, "b3:9092": false}
+	for server, wantClosed := range want {
+		if closed[server] != wantClosed {
+			t.Errorf("client of %s closed = %v, want %v", server, closed[server], wantClosed)
+		}
+	}
+	if stats := p.Stats(); len(stats.Connections) != 2 || stats.Closed != 1 {
+		t.Errorf("stats %+v, want 2 connections and 1 closed", stats)
+	}
+
+	// The reaped cluster reconnects on its next request
+	acquire(t, p, testDefinition("idle", "b1:9092", "")).Release()
+	if f.calls != 4 {
+		t.Errorf("connected %d times, want 4", f.calls)
+	}
+	leased.Release()
+}
+
+func TestPoolClose(t *testing.T) {
+	f := &fakeConnector{}
+	p := NewPool(f.connect, time.Hour)
+	acquire(t, p, testDefinition("a", "b1:9092", "")).Release()
+	leased := acquire(t, p, testDefinition("b", "b2:9092", ""))
+	retired := acquire(t, p, testDefinition("c", "b3:9092", ""))
+	p.Retire(testDefinition("c", "b3:9092", ""))
+
+	p.Close()
+	for _, client := range f.clients {
+		if client.closed.Load() != 1 {
+			t.Errorf("client of %s closed %d times, want 1", client.servers[0], client.closed.Load())
+		}
+	}
+	// Leases released after Close do not close clients again
+	leased.Release()
+	retired.Release()
+	for _, client := range f.clients {
+		if client.closed.Load() != 1 {
+			t.Errorf("client of %s closed %d times after release, want 1", client.servers[0], client.closed.Load())
+		}
+	}
+}
+
+func TestIdleTTLFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", DefaultIdleTTL, false},
+		{"5m", 5 * time.Minute, false},
+		{"0s", 0, true},
+		{"-1m", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(utils.KafkaClientIdleTTLEnv, tt.value)
+			got, err := IdleTTLFromEnv()
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("IdleTTLFromEnv() = %v, %v; want %v (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolAcquireCanceled(t *testing.T) {
+	tests := []struct {
+		name       string
+		retire     bool // The cluster is retired while connecting
+		wantClosed bool // Client closed once connected
+	}{
+		{name: "client kept for later requests"},
+		{name: "retired client closed", retire: true, wantClosed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connected := make(chan struct{})
+			client := &fakeClient{}
+			p := NewPool(func(def Definition) (kafka.KafkaService, error) {
+				<-connected
+				return client, nil
+			}, time.Hour)
+			defer p.Close()
+			def := testDefinition("a", "b1:9092", "")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if _, err := p.Acquire(ctx, def); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("error %v, want the deadline to pass", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Acquire returned after %v, want it to stop at the deadline", elapsed)
+			}
+			if tt.retire {
+				p.Retire(def)
+			}
+
+			close(connected)
+			for p.Stats().Opened == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			if closed := client.closed.Load() == 1; closed != tt.wantClosed {
+				t.Errorf("closed = %v, want %v", closed, tt.wantClosed)
+			}
+			if !tt.retire {
+				lease := acquire(t, p, def)
+				if lease.Client() != client {
+					t.Error("the connection made for the canceled request was not reused")
+				}
+				lease.Release()
+			}
+		})
+	}
+}
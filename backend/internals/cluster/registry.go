@@ -0,0 +1,295 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internals/kafka"
+	"backend/internals/secrets"
+	"backend/internals/store"
+	"backend/internals/utils"
+)
+
+// registry.go - Keeps the named Kafka cluster definitions and leases their clients from a Pool.
+// Definitions are persisted in the document store; clients are connected on first use and retired
+// when their cluster is changed or removed, so requests for different clusters never interfere.
+
+var (
+	ErrClusterNotFound = errors.New("cluster not found")
+	ErrClusterExists   = errors.New("cluster already exists")
+	ErrInvalidCluster  = errors.New("invalid cluster definition")
+)
+
+// validName restricts cluster names to characters that are safe in URLs and file names.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Definition describes how to reach a cluster.
+type Definition struct {
+	Name             string        `json:"name"`             // Unique name used in /api/clusters/:cluster routes
+	BootstrapServers []string      `json:"bootstrapServers"` // host:port addresses of one or more brokers
+	SASL             *SASLSettings `json:"sasl,omitempty"`   // SASL authentication, if required
+	TLS              *TLSSettings  `json:"tls,omitempty"`    // TLS encryption, if enabled
+	CreatedAt        time.Time     `json:"createdAt"`
+	UpdatedAt        time.Time     `json:"updatedAt"`
+}
+
+// Validate checks the name, bootstrap server addresses and security settings,
+// returning an error wrapping ErrInvalidCluster.
+func (d *Definition) Validate() error {
+	if !validName.MatchString(d.Name) {
+		return fmt.Errorf("%w: name must be up to 64 letters, digits, '.', '_' or '-'", ErrInvalidCluster)
+	}
+	if len(d.BootstrapServers) == 0 {
+		return fmt.Errorf("%w: at least one bootstrap server is required", ErrInvalidCluster)
+	}
+	for _, server := range d.BootstrapServers {
+		if host, port, err := net.SplitHostPort(server); err != nil || host == "" || port == "" {
+			return fmt.Errorf("%w: bootstrap server %q is not host:port", ErrInvalidCluster, server)
+		}
+	}
+	if d.SASL != nil {
+		if err := d.SASL.validate(); err != nil {
+			return err
+		}
+	}
+	if d.TLS != nil {
+		if err := d.TLS.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Connector creates a client for a cluster definition.
+type Connector func(def Definition) (kafka.KafkaService, error)
+
+// Connect is the default Connector, creating a Sarama client with the cluster's security settings.
+func Connect(def Definition) (kafka.KafkaService, error) {
+	config, err := kafka.NewConfig(def.securityConfig())
+	if err != nil {
+		return nil, err
+	}
+	return kafka.NewClient(def.BootstrapServers, config)
+}
+
+// Registry holds the cluster definitions. It is safe for concurrent use.
+type Registry struct {
+	docs store.DocumentStore
+	box  *secrets.Box
+	pool *Pool
+
+	mu       sync.RWMutex
+	clusters map[string]*Definition
+}
+
+// NewRegistry loads the persisted cluster definitions, decrypting their secrets with box.
+// Clients are leased from pool, which the registry closes in Close.
+func NewRegistry(docs store.DocumentStore, box *secrets.Box, pool *Pool) (*Registry, error) {
+	var defs []*Definition
+	if err := docs.ReadDocument(utils.ClustersFileName, &defs); err != nil {
+		return nil, err
+	}
+	for _, def := range defs {
+		if err := def.openSecrets(box); err != nil {
+			return nil, err
+		}
+	}
+	r := &Registry{
+		docs:     docs,
+		box:      box,
+		pool:     pool,
+		clusters: map[string]*Definition{},
+	}
+	for _, def := range defs {
+		r.clusters[def.Name] = def
+	}
+	return r, nil
+}
+
+// save persists the definitions with their secrets encrypted. Callers must hold r.mu for writing.
+func (r *Registry) save() error {
+	defs := make([]*Definition, 0, len(r.clusters))
+	for _, def := range r.clusters {
+		sealed, err := def.sealSecrets(r.box)
+		if err != nil {
+			return err
+		}
+		defs = append(defs, sealed)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return r.docs.WriteDocument(utils.ClustersFileName, defs)
+}
+
+// List returns all cluster definitions sorted by name.
+func (r *Registry) List() []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]Definition, 0, len(r.clusters))
+	for _, def := range r.clusters {
+		defs = append(defs, def.clone())
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Get returns the named cluster definition.
+func (r *Registry) Get(name string) (Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.clusters[name]
+	if !ok {
+		return Definition{}, ErrClusterNotFound
+	}
+	return def.clone(), nil
+}
+
+// Create adds a cluster definition.
+func (r *Registry) Create(def Definition) (Definition, error) {
+	def.BootstrapServers = normalizeServers(def.BootstrapServers)
+	if err := def.Validate(); err != nil {
+		return Definition{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clusters[def.Name]; ok {
+		return Definition{}, ErrClusterExists
+	}
+	def.CreatedAt = time.Now().UTC()
+	def.UpdatedAt = def.CreatedAt
+	r.clusters[def.Name] = &def
+	if err := r.save(); err != nil {
+		delete(r.clusters, def.Name)
+		return Definition{}, err
+	}
+	return def.clone(), nil
+}
+
+// Update replaces the connection settings of the cluster named by update.Name and retires its
+// current client. Secrets left empty in update keep their stored values.
+func (r *Registry) Update(update Definition) (Definition, error) {
+	name := update.Name
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.clusters[name]
+	if !ok {
+		return Definition{}, ErrClusterNotFound
+	}
+	def := update.clone()
+	def.BootstrapServers = normalizeServers(def.BootstrapServers)
+	if def.SASL != nil {
+		def.SASL.keepSecrets(current.SASL)
+	}
+	if err := def.Validate(); err != nil {
+		return Definition{}, err
+	}
+	def.CreatedAt = current.CreatedAt
+	def.UpdatedAt = time.Now().UTC()
+	r.clusters[name] = &def
+	if err := r.save(); err != nil {
+		r.clusters[name] = current
+		return Definition{}, err
+	}
+	r.retire(current)
+	return def.clone(), nil
+}
+
+// Delete removes a cluster definition and retires its client.
+func (r *Registry) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.clusters[name]
+	if !ok {
+		return ErrClusterNotFound
+	}
+	delete(r.clusters, name)
+	if err := r.save(); err != nil {
+		r.clusters[name] = current
+		return err
+	}
+	r.retire(current)
+	return nil
+}
+
+// retire retires the pooled client of a replaced or removed definition, unless another cluster
+// still connects with the same parameters. Callers must hold r.mu for writing.
+func (r *Registry) retire(old *Definition) {
+	key := fingerprint(*old)
+	for _, def := range r.clusters {
+		if fingerprint(*def) == key {
+			return
+		}
+	}
+	r.pool.Retire(*old)
+}
+
+// Acquire leases the client of the named cluster, connecting on first use. Connecting happens
+// without holding the registry lock so a slow cluster does not block the others, and waiting for
+// it stops when ctx ends. The caller must release the lease when done.
+func (r *Registry) Acquire(ctx context.Context, name string) (*Lease, error) {
+	for {
+		r.mu.RLock()
+		def, ok := r.clusters[name]
+		r.mu.RUnlock()
+		if !ok {
+			return nil, ErrClusterNotFound
+		}
+
+		lease, err := r.pool.Acquire(ctx, def.clone())
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.RLock()
+		unchanged := r.clusters[name] == def
+		r.mu.RUnlock()
+		if unchanged {
+			return lease, nil
+		}
+		// The cluster was changed or removed while connecting; the stale client is left to the reaper
+		lease.Release()
+	}
+}
+
+// PoolStats returns the state of the client pool.
+func (r *Registry) PoolStats() PoolStats {
+	return r.pool.Stats()
+}
+
+// Close closes all clients.
+func (r *Registry) Close() {
+	r.pool.Close()
+}
+
+func (d *Definition) clone() Definition {
+	c := *d
+	c.BootstrapServers = append([]string{}, d.BootstrapServers...)
+	if d.SASL != nil {
+		sasl := *d.SASL
+		sasl.Scopes = append([]string(nil), d.SASL.Scopes...)
+		c.SASL = &sasl
+	}
+	if d.TLS != nil {
+		tls := *d.TLS
+		c.TLS = &tls
+	}
+	return c
+}
+
+// normalizeServers trims the addresses and drops empty entries.
+func normalizeServers(servers []string) []string {
+	result := []string{}
+	for _, server := range servers {
+		if server = strings.TrimSpace(server); server != "" {
+			result = append(result, server)
+		}
+	}
+	return result
+}
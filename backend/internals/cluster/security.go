@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+
+	"backend/internals/kafka"
+	"backend/internals/secrets"
+)
+
+// security.go - Per-cluster SASL and TLS settings.
+// Passwords and client secrets are encrypted before definitions are persisted and are never
+// returned by the API; an update that leaves a secret empty keeps the stored one.
+
+// SASLSettings configures SASL authentication.
+type SASLSettings struct {
+	Mechanism    string   `json:"mechanism"`              // PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER
+	Username     string   `json:"username,omitempty"`     // PLAIN and SCRAM user
+	Password     string   `json:"password,omitempty"`     // PLAIN and SCRAM password (secret)
+	TokenURL     string   `json:"tokenUrl,omitempty"`     // OAUTHBEARER token endpoint
+	ClientID     string   `json:"clientId,omitempty"`     // OAUTHBEARER client ID
+	ClientSecret string   `json:"clientSecret,omitempty"` // OAUTHBEARER client secret (secret)
+	Scopes       []string `json:"scopes,omitempty"`       // OAUTHBEARER scopes
+}
+
+// TLSSettings configures TLS. Files are read from the server's file system when connecting.
+type TLSSettings struct {
+	CAFile             string `json:"caFile,omitempty"`             // PEM CA bundle (system roots if empty)
+	CertFile           string `json:"certFile,omitempty"`           // PEM client certificate for mTLS
+	KeyFile            string `json:"keyFile,omitempty"`            // PEM client key for mTLS
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"` // Skip certificate verification (development only)
+}
+
+// validate checks the SASL settings.
+func (s *SASLSettings) validate() error {
+	switch s.Mechanism {
+	case kafka.SASLMechanismPlain, kafka.SASLMechanismSCRAMSHA256, kafka.SASLMechanismSCRAMSHA512:
+		if s.Username == "" || s.Password == "" {
+			return fmt.Errorf("%w: SASL %s requires a username and password", ErrInvalidCluster, s.Mechanism)
+		}
+	case kafka.SASLMechanismOAuthBearer:
+		if u, err := url.Parse(s.TokenURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: SASL OAUTHBEARER requires an http(s) tokenUrl", ErrInvalidCluster)
+		}
+		if s.ClientID == "" || s.ClientSecret == "" {
+			return fmt.Errorf("%w: SASL OAUTHBEARER requires a clientId and clientSecret", ErrInvalidCluster)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported SASL mechanism %q", ErrInvalidCluster, s.Mechanism)
+	}
+	return nil
+}
+
+// validate checks the TLS settings.
+func (t *TLSSettings) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("%w: TLS certFile and keyFile must be set together", ErrInvalidCluster)
+	}
+	return nil
+}
+
+// keepSecrets copies the stored secrets into s where the update left them empty,
+// as long as the mechanism is unchanged.
+func (s *SASLSettings) keepSecrets(current *SASLSettings) {
+	if current == nil || current.Mechanism != s.Mechanism {
+		return
+	}
+	if s.Password == "" {
+		s.Password = current.Password
+	}
+	if s.ClientSecret == "" {
+		s.ClientSecret = current.ClientSecret
+	}
+}
+
+// sealSecrets returns a copy of the definition with its secrets encrypted for storage.
+func (d *Definition) sealSecrets(box *secrets.Box) (*Definition, error) {
+	sealed := d.clone()
+	if sealed.SASL == nil {
+		return &sealed, nil
+	}
+	var err error
+	if sealed.SASL.Password, err = box.Encrypt(sealed.SASL.Password); err != nil {
+		return nil, err
+	}
+	if sealed.SASL.ClientSecret, err = box.Encrypt(sealed.SASL.ClientSecret); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openSecrets decrypts the secrets of a stored definition in place.
+func (d *Definition) openSecrets(box *secrets.Box) error {
+	if d.SASL == nil {
+		return nil
+	}
+	var err error
+	if d.SASL.Password, err = box.Decrypt(d.SASL.Password); err != nil {
+		return fmt.Errorf("cluster %s: %w", d.Name, err)
+	}
+	if d.SASL.ClientSecret, err = box.Decrypt(d.SASL.ClientSecret); err != nil {
+		return fmt.Errorf("cluster %s: %w", d.Name, err)
+	}
+	return nil
+}
+
+// securityConfig converts the settings to the Kafka client configuration.
+func (d *Definition) securityConfig() kafka.SecurityConfig {
+	var security kafka.SecurityConfig
+	if s := d.SASL; s != nil {
+		security.SASL = &kafka.SASLConfig{
+			Mechanism:    s.Mechanism,
+			Username:     s.Username,
+			Password:     s.Password,
+			TokenURL:     s.TokenURL,
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			Scopes:       s.Scopes,
+		}
+	}
+	if t := d.TLS; t != nil {
+		security.TLS = &kafka.TLSConfig{
+			CAFile:             t.CAFile,
+			CertFile:           t.CertFile,
+			KeyFile:            t.KeyFile,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+		}
+	}
+	return security
+}
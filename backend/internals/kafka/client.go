@@ -19,27 +19,45 @@ import (
 
 // Client implements KafkaService using IBM Sarama client and admin interfaces.
 type Client struct {
-	brokers []string            // List of Kafka broker addresses
-	config  *sarama.Config      // Sarama configuration
-	client  sarama.Client       // Sarama client instance
-	admin   sarama.ClusterAdmin // Sarama admin instance
+	brokers      []string            // List of Kafka broker addresses
+	config       *sarama.Config      // Sarama configuration
+	client       sarama.Client       // Sarama client instance
+	admin        sarama.ClusterAdmin // Sarama admin instance
+	capabilities models.Capabilities // Negotiated version and supported features
+}
+
+// DefaultConfig returns the Sarama configuration used for clients without security settings.
+// The protocol version is negotiated with the brokers by NewClient.
+func DefaultConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = sarama.NewManualPartitioner
+
+	// Enhanced settings for better admin operations
+	config.Metadata.RefreshFrequency = 30 * time.Second
+	config.Metadata.Full = true
+	config.Admin.Timeout = 30 * time.Second
+	return config
 }
 
 // NewClient creates a new Kafka client using Sarama.
 // brokers: list of broker addresses
-// config: optional Sarama configuration (uses defaults if nil)
+// config: optional Sarama configuration (uses DefaultConfig if nil); its Version is replaced by
+// the highest version supported by both Sarama and the reachable brokers
 // Returns a pointer to Client and error if creation fails.
 func NewClient(brokers []string, config *sarama.Config) (*Client, error) {
 	if config == nil {
-		config = sarama.NewConfig()
-		config.Version = sarama.MaxVersion
-		config.Producer.Return.Successes = true
-		config.Producer.Partitioner = sarama.NewManualPartitioner
+		config = DefaultConfig()
+	}
 
-		// Enhanced settings for better admin operations
-		config.Metadata.RefreshFrequency = 30 * time.Second
-		config.Metadata.Full = true
-		config.Admin.Timeout = 30 * time.Second
+	version, capabilities, err := negotiate(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	config.Version = version
+	if !version.IsAtLeast(sarama.V1_0_0_0) && config.Net.SASL.Mechanism != sarama.SASLTypeOAuth {
+		// SaslAuthenticate requests only exist since Kafka 1.0
+		config.Net.SASL.Version = sarama.SASLHandshakeV0
 	}
 
 	client, err := sarama.NewClient(brokers, config)
@@ -54,13 +72,31 @@ func NewClient(brokers []string, config *sarama.Config) (*Client, error) {
 	}
 
 	return &Client{
-		brokers: brokers,
-		config:  config,
-		client:  client,
-		admin:   admin,
+		brokers:      brokers,
+		config:       config,
+		client:       client,
+		admin:        admin,
+		capabilities: capabilities,
 	}, nil
 }
 
+// Capabilities returns the negotiated Kafka version and the optional features of the cluster.
+func (c *Client) Capabilities() models.Capabilities {
+	features := make(map[string]bool, len(c.capabilities.Features))
+	for feature, supported := range c.capabilities.Features {
+		features[feature] = supported
+	}
+	return models.Capabilities{KafkaVersion: c.capabilities.KafkaVersion, Features: features}
+}
+
+// require returns an UnsupportedError if the cluster lacks a feature.
+func (c *Client) require(feature string) error {
+	if !c.capabilities.Features[feature] {
+		return &UnsupportedError{Feature: feature, KafkaVersion: c.capabilities.KafkaVersion}
+	}
+	return nil
+}
+
 // NewKafkaClient is an alias for NewClient for compatibility.
 func NewKafkaClient(brokers []string, config *sarama.Config) (*Client, error) {
 	return NewClient(brokers, config)
@@ -68,7 +104,7 @@ func NewKafkaClient(brokers []string, config *sarama.Config) (*Client, error) {
 
 // CheckConnection checks if the client can connect to the Kafka cluster.
 // Returns error if no brokers are available or not connected.
-func (c *Client) CheckConnection() error {
+func (c *Client) CheckConnection(ctx context.Context) error {
 	brokers := c.client.Brokers()
 	if len(brokers) == 0 {
 		return errors.New("no brokers available")
@@ -77,7 +113,10 @@ func (c *Client) CheckConnection() error {
 		if err := b.Open(c.config); err != nil && err != sarama.ErrAlreadyConnected {
 			return err
 		}
-		connected, err := b.Connected()
+		connected, err := call(ctx, b.Connected)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil || !connected {
 			return errors.New("not connected to broker")
 		}
@@ -85,14 +124,16 @@ func (c *Client) CheckConnection() error {
 	return nil
 }
 
+// Close closes the admin and client connections.
+func (c *Client) Close() error {
+	// Closing the admin also closes the client it was created from
+	return c.admin.Close()
+}
+
 // ListTopics lists all topics in the Kafka cluster.
 // Returns a slice of Topic and error if listing fails.
-func (c *Client) ListTopics() ([]models.Topic, error) {
-	topicNames, err := c.client.Topics()
-	if err != nil {
-		return nil, err
-	}
-	details, err := c.admin.DescribeTopics(topicNames)
+func (c *Client) ListTopics(ctx context.Context) ([]models.Topic, error) {
+	details, err := c.describeTopics(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -125,29 +166,60 @@ func (c *Client) ListTopics() ([]models.Topic, error) {
 	return topics, nil
 }
 
-// CreateTopic creates a new topic with the given name, partitions, and replication factor.
+// describeTopics returns the metadata of every topic, including partition leaders and replicas.
+func (c *Client) describeTopics(ctx context.Context) ([]*sarama.TopicMetadata, error) {
+	topicNames, err := call(ctx, c.client.Topics)
+	if err != nil {
+		return nil, err
+	}
+	return call(ctx, func() ([]*sarama.TopicMetadata, error) {
+		return c.admin.DescribeTopics(topicNames)
+	})
+}
+
+// CreateTopic creates a new topic with the given name, partitions, replication factor and config
+// overrides. Configs are validated before the request is sent; configs the cluster rejects are
+// reported as ErrInvalidConfig as well.
 // Returns error if creation fails.
-func (c *Client) CreateTopic(name string, partitions, replicationFactor int) error {
+func (c *Client) CreateTopic(ctx context.Context, name string, partitions, replicationFactor int, configs map[string]string) error {
+	if err := validateTopicConfigs(configs); err != nil {
+		return err
+	}
 	detail := &sarama.TopicDetail{
 		NumPartitions:     int32(partitions),
 		ReplicationFactor: int16(replicationFactor),
 	}
-	err := c.admin.CreateTopic(name, detail, false)
-	if err == nil {
-		_ = c.client.RefreshMetadata(name)
+	if len(configs) > 0 {
+		detail.ConfigEntries = make(map[string]*string, len(configs))
+		for key, value := range configs {
+			detail.ConfigEntries[key] = &value
+		}
 	}
-	return err
+	return do(ctx, func() error {
+		err := c.admin.CreateTopic(name, detail, false)
+		var topicErr *sarama.TopicError
+		if errors.As(err, &topicErr) && topicErr.ErrMsg != nil {
+			return configError(int16(topicErr.Err), *topicErr.ErrMsg)
+		}
+		if err == nil {
+			_ = c.client.RefreshMetadata(name)
+		}
+		return err
+	})
 }
 
 // GetPartitionInfo gets partition info for a topic.
 // Returns a slice of PartitionInfo and error if retrieval fails.
-func (c *Client) GetPartitionInfo(topic string) ([]models.PartitionInfo, error) {
-	meta, err := c.client.Partitions(topic)
+func (c *Client) GetPartitionInfo(ctx context.Context, topic string) ([]models.PartitionInfo, error) {
+	meta, err := call(ctx, func() ([]int32, error) { return c.client.Partitions(topic) })
 	if err != nil {
 		return nil, err
 	}
 	var infos []models.PartitionInfo
 	for _, pid := range meta {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		leader, err := c.client.Leader(topic, pid)
 		if err != nil {
 			return nil, err
@@ -172,12 +244,13 @@ func (c *Client) GetPartitionInfo(topic string) ([]models.PartitionInfo, error)
 }
 
 // FetchMessages fetches messages from a topic with global sorting.
+// ctx: bounds the scan; the partition consumers stop as soon as it is cancelled or times out
 // topic: topic name
 // limit: number of messages to fetch
 // sortOrder: 'oldest' or 'newest'
-// Returns a slice of Message and error if fetching fails.
-func (c *Client) FetchMessages(topic string, limit int, sortOrder string) ([]models.Message, error) {
-	partitions, err := c.client.Partitions(topic)
+// Returns a slice of Message and error if fetching fails or ctx ends before the scan completes.
+func (c *Client) FetchMessages(ctx context.Context, topic string, limit int, sortOrder string) ([]models.Message, error) {
+	partitions, err := call(ctx, func() ([]int32, error) { return c.client.Partitions(topic) })
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +259,6 @@ func (c *Client) FetchMessages(topic string, limit int, sortOrder string) ([]mod
 	if err != nil {
 		return nil, err
 	}
-	defer consumer.Close()
 
 	// For proper sorting, we need to fetch more messages than the limit
 	// then sort globally and take the top N
@@ -195,9 +267,6 @@ func (c *Client) FetchMessages(topic string, limit int, sortOrder string) ([]mod
 		fetchMultiplier = 1 // If no limit, don't multiply
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
 	// Channel to collect messages from all partitions
 	messagesChan := make(chan models.Message, 1000) // Larger buffer
 	var wg sync.WaitGroup
@@ -223,16 +292,26 @@ func (c *Client) FetchMessages(topic string, limit int, sortOrder string) ([]mod
 		}(partition)
 	}
 
-	// Close channel when all goroutines are done
+	// Close channel and consumer when all goroutines are done
 	go func() {
 		wg.Wait()
 		close(messagesChan)
+		consumer.Close()
 	}()
 
-	// Collect all messages
+	// Collect all messages, giving up as soon as ctx ends; the partition goroutines then stop on their own
 	var messages []models.Message
-	for msg := range messagesChan {
-		messages = append(messages, msg)
+collect:
+	for {
+		select {
+		case msg, ok := <-messagesChan:
+			if !ok {
+				break collect
+			}
+			messages = append(messages, msg)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 
 	// Now sort all messages globally
@@ -270,11 +349,11 @@ func (c *Client) FetchMessages(topic string, limit int, sortOrder string) ([]mod
 
 // fetchNewestFromPartition fetches the newest messages from a partition
 func (c *Client) fetchNewestFromPartition(ctx context.Context, consumer sarama.Consumer, topic string, partition int32, limit int, messagesChan chan<- models.Message) {
-	newest, err := c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	newest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetNewest) })
 	if err != nil {
 		return
 	}
-	oldest, err := c.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	oldest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetOldest) })
 	if err != nil {
 		return
 	}
@@ -289,6 +368,9 @@ func (c *Client) fetchNewestFromPartition(ctx context.Context, consumer sarama.C
 		startOffset = oldest
 	}
 
+	if ctx.Err() != nil {
+		return
+	}
 	pc, err := consumer.ConsumePartition(topic, partition, startOffset)
 	if err != nil {
 		return
@@ -360,11 +442,11 @@ sendMessages:
 
 // fetchOldestFromPartition fetches the oldest messages from a partition
 func (c *Client) fetchOldestFromPartition(ctx context.Context, consumer sarama.Consumer, topic string, partition int32, limit int, messagesChan chan<- models.Message) {
-	newest, err := c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	newest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetNewest) })
 	if err != nil {
 		return
 	}
-	oldest, err := c.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	oldest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetOldest) })
 	if err != nil {
 		return
 	}
@@ -374,6 +456,9 @@ func (c *Client) fetchOldestFromPartition(ctx context.Context, consumer sarama.C
 	}
 
 	// For oldest messages, start from the beginning
+	if ctx.Err() != nil {
+		return
+	}
 	pc, err := consumer.ConsumePartition(topic, partition, oldest)
 	if err != nil {
 		return
@@ -441,13 +526,13 @@ func (c *Client) fetchOldestFromPartition(ctx context.Context, consumer sarama.C
 }
 
 // FetchRecentMessages - optimized method for getting recent messages quickly
-func (c *Client) FetchRecentMessages(topic string, limit int) ([]models.Message, error) {
-	return c.FetchMessages(topic, limit, "newest")
+func (c *Client) FetchRecentMessages(ctx context.Context, topic string, limit int) ([]models.Message, error) {
+	return c.FetchMessages(ctx, topic, limit, "newest")
 }
 
 // FetchAllMessages - method to get all available messages (use with caution)
-func (c *Client) FetchAllMessages(topic string, sortOrder string) ([]models.Message, error) {
-	return c.FetchMessages(topic, 0, sortOrder)
+func (c *Client) FetchAllMessages(ctx context.Context, topic string, sortOrder string) ([]models.Message, error) {
+	return c.FetchMessages(ctx, topic, 0, sortOrder)
 }
 
 // Helper functions
@@ -466,10 +551,13 @@ func min(a, b int) int {
 }
 
 // Produce produces a message to a topic
-func (c *Client) Produce(topic, key string, value []byte, partition int32, headers []models.MessageHeader) error {
+func (c *Client) Produce(ctx context.Context, topic, key string, value []byte, partition int32, headers []models.MessageHeader) error {
 	// Defensive: check if requested partition exists
 	if partition >= 0 {
-		partitions, err := c.client.Partitions(topic)
+		partitions, err := call(ctx, func() ([]int32, error) { return c.client.Partitions(topic) })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get partitions for topic %s: %w", topic, err)
 		}
@@ -485,11 +573,13 @@ func (c *Client) Produce(topic, key string, value []byte, partition int32, heade
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	producer, err := sarama.NewSyncProducerFromClient(c.client)
 	if err != nil {
 		return err
 	}
-	defer producer.Close()
 
 	var saramaHeaders []sarama.RecordHeader
 	for _, h := range headers {
@@ -512,7 +602,21 @@ func (c *Client) Produce(topic, key string, value []byte, partition int32, heade
 	}
 	// If partition is -1 or not specified, let the partitioner decide
 
-	actualPartition, offset, err := producer.SendMessage(msg)
+	// The message may still be delivered after ctx ends; the caller is told it timed out.
+	// The producer is closed by the send itself, as closing waits for in-flight messages.
+	type sent struct {
+		partition int32
+		offset    int64
+	}
+	result, err := call(ctx, func() (sent, error) {
+		defer producer.Close()
+		partition, offset, err := producer.SendMessage(msg)
+		return sent{partition, offset}, err
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	actualPartition, offset := result.partition, result.offset
 	if err != nil {
 		// Log error for diagnostics
 		fmt.Println("Produce error:", err.Error())
@@ -527,17 +631,11 @@ func (c *Client) Produce(topic, key string, value []byte, partition int32, heade
 }
 
 // Alternative: Create a producer with specific partitioner configuration
-func (c *Client) ProduceWithCustomPartitioner(topic, key string, value []byte, partition int32, headers []models.MessageHeader) error {
+func (c *Client) ProduceWithCustomPartitioner(ctx context.Context, topic, key string, value []byte, partition int32, headers []models.MessageHeader) error {
 	// Create a custom config for this producer with manual partitioner
 	producerConfig := *c.config // Copy the config
 	producerConfig.Producer.Partitioner = sarama.NewManualPartitioner
 
-	producer, err := sarama.NewSyncProducer(c.brokers, &producerConfig)
-	if err != nil {
-		return err
-	}
-	defer producer.Close()
-
 	var saramaHeaders []sarama.RecordHeader
 	for _, h := range headers {
 		saramaHeaders = append(saramaHeaders, sarama.RecordHeader{
@@ -554,7 +652,26 @@ func (c *Client) ProduceWithCustomPartitioner(topic, key string, value []byte, p
 		Partition: partition, // This will be respected with manual partitioner
 	}
 
-	actualPartition, offset, err := producer.SendMessage(msg)
+	// The message may still be delivered after ctx ends; the caller is told it timed out.
+	// The producer connects to the brokers itself, so it is created inside the bounded call and
+	// closed by the send, as closing waits for in-flight messages.
+	type sent struct {
+		partition int32
+		offset    int64
+	}
+	result, err := call(ctx, func() (sent, error) {
+		producer, err := sarama.NewSyncProducer(c.brokers, &producerConfig)
+		if err != nil {
+			return sent{}, err
+		}
+		defer producer.Close()
+		partition, offset, err := producer.SendMessage(msg)
+		return sent{partition, offset}, err
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	actualPartition, offset := result.partition, result.offset
 	if err != nil {
 		return fmt.Errorf("produce failed: %w", err)
 	}
@@ -566,16 +683,27 @@ func (c *Client) ProduceWithCustomPartitioner(topic, key string, value []byte, p
 }
 
 // ClearTopicMessages clears all messages from a topic by truncating to latest offset
-func (c *Client) ClearTopicMessages(topic string) error {
+func (c *Client) ClearTopicMessages(ctx context.Context, topic string) error {
+	if err := c.require(FeatureDeleteRecords); err != nil {
+		return err
+	}
+
 	// Get all partitions for the topic
-	partitions, err := c.client.Partitions(topic)
+	partitions, err := call(ctx, func() ([]int32, error) { return c.client.Partitions(topic) })
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get partitions for topic %s: %w", topic, err)
 	}
 
 	// For each partition, set the offset to the latest (effectively truncating all messages)
+	// Partitions already truncated stay truncated if ctx ends part way through
 	for _, partition := range partitions {
-		latest, err := c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+		latest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetNewest) })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get latest offset for partition %d: %w", partition, err)
 		}
@@ -598,7 +726,10 @@ func (c *Client) ClearTopicMessages(topic string) error {
 			return errors.New("no brokers available")
 		}
 
-		_, err = brokers[0].DeleteRecords(request)
+		_, err = call(ctx, func() (*sarama.DeleteRecordsResponse, error) { return brokers[0].DeleteRecords(request) })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			return fmt.Errorf("failed to delete records for partition %d: %w", partition, err)
 		}
@@ -609,11 +740,13 @@ func (c *Client) ClearTopicMessages(topic string) error {
 }
 
 // ClearTopicMessagesWithRetention clears messages by temporarily setting retention to 1ms
-func (c *Client) ClearTopicMessagesWithRetention(topic string) error {
+func (c *Client) ClearTopicMessagesWithRetention(ctx context.Context, topic string) error {
 	// First, get current topic configuration
-	configEntries, err := c.admin.DescribeConfig(sarama.ConfigResource{
-		Type: sarama.TopicResource,
-		Name: topic,
+	configEntries, err := call(ctx, func() ([]sarama.ConfigEntry, error) {
+		return c.admin.DescribeConfig(sarama.ConfigResource{
+			Type: sarama.TopicResource,
+			Name: topic,
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to describe topic config: %w", err)
@@ -639,15 +772,19 @@ func (c *Client) ClearTopicMessagesWithRetention(topic string) error {
 		alterConfig["retention.bytes"] = stringPtr("1")
 	}
 
-	err = c.admin.AlterConfig(sarama.TopicResource, topic, alterConfig, false)
+	err = do(ctx, func() error { return c.admin.AlterConfig(sarama.TopicResource, topic, alterConfig, false) })
 	if err != nil {
 		return fmt.Errorf("failed to set temporary retention: %w", err)
 	}
 
 	fmt.Printf("Set temporary retention for topic %s, waiting for cleanup...\n", topic)
 
-	// Wait for cleanup to happen (this depends on log.segment.ms and log.retention.check.interval.ms)
-	time.Sleep(10 * time.Second)
+	// Wait for cleanup to happen (this depends on log.segment.ms and log.retention.check.interval.ms).
+	// The original retention is restored even if ctx ends while waiting.
+	select {
+	case <-time.After(10 * time.Second):
+	case <-ctx.Done():
+	}
 
 	// Restore original retention settings
 	restoreConfig := map[string]*string{}
@@ -667,35 +804,62 @@ func (c *Client) ClearTopicMessagesWithRetention(topic string) error {
 		return fmt.Errorf("failed to restore original retention: %w", err)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	fmt.Printf("Successfully cleared messages and restored retention for topic: %s\n", topic)
 	return nil
 }
 
-// GetBrokers returns broker information
-func (c *Client) GetBrokers() ([]models.Broker, error) {
+// GetBrokers returns broker information. Every broker is probed for liveness; brokers that
+// still host replicas but have dropped out of the metadata are reported as unreachable.
+// Only partitions of visible topics are listed, but broker status covers every replica.
+func (c *Client) GetBrokers(ctx context.Context, visible TopicFilter) ([]models.Broker, error) {
 	brokers := c.client.Brokers()
-	topicNames, err := c.client.Topics()
-	if err != nil {
-		return nil, err
-	}
-	details, err := c.admin.DescribeTopics(topicNames)
+	details, err := c.describeTopics(ctx)
 	if err != nil {
 		return nil, err
 	}
-	// Build maps of brokerID to leaders and replicas
+	// Build maps of brokerID to leaders, replicas and replicas missing from the ISR
 	leaderMap := make(map[int32][]int)
 	replicaMap := make(map[int32][]int)
 	segmentCountMap := make(map[int32]int)
+	outOfSyncMap := make(map[int32]int)
+	hosting := make(map[int32]bool)
 	for _, topic := range details {
+		listed := visible.includes(topic.Name)
 		for _, part := range topic.Partitions {
 			leader := part.Leader
-			leaderMap[leader] = append(leaderMap[leader], int(part.ID))
-			segmentCountMap[leader]++
+			if listed {
+				leaderMap[leader] = append(leaderMap[leader], int(part.ID))
+				segmentCountMap[leader]++
+			}
+			inSync := make(map[int32]bool, len(part.Isr))
+			for _, r := range part.Isr {
+				inSync[r] = true
+			}
 			for _, r := range part.Replicas {
-				replicaMap[r] = append(replicaMap[r], int(part.ID))
+				hosting[r] = true
+				if listed {
+					replicaMap[r] = append(replicaMap[r], int(part.ID))
+				}
+				if !inSync[r] {
+					outOfSyncMap[r]++
+				}
 			}
 		}
 	}
+
+	addrs := make(map[int32]string, len(brokers))
+	for _, b := range brokers {
+		addrs[b.ID()] = b.Addr()
+	}
+	checkedAt := time.Now()
+	probes := c.probeBrokers(ctx, addrs)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var infos []models.Broker
 	for _, b := range brokers {
 		addr := b.Addr()
@@ -708,32 +872,70 @@ func (c *Client) GetBrokers() ([]models.Broker, error) {
 				port = parsed
 			}
 		}
-		infos = append(infos, models.Broker{
+		info := models.Broker{
 			ID:           id,
 			Host:         host,
 			Port:         int32(port),
 			Address:      addr,
-			Status:       "online",
+			Status:       models.BrokerOnline,
+			CheckedAt:    checkedAt,
 			SegmentCount: segmentCountMap[id],
 			Replicas:     replicaMap[id],
 			Leaders:      leaderMap[id],
+		}
+		probe := probes[id]
+		info.LatencyMs = probe.latency.Milliseconds()
+		switch {
+		case probe.err != nil:
+			info.Status = models.BrokerUnreachable
+			info.StatusDetail = probe.err.Error()
+		case probe.latency > SlowProbeThreshold:
+			info.Status = models.BrokerDegraded
+			info.StatusDetail = fmt.Sprintf("slow response (%s)", probe.latency.Round(time.Millisecond))
+		case outOfSyncMap[id] > 0:
+			info.Status = models.BrokerDegraded
+			info.StatusDetail = fmt.Sprintf("%d replicas out of sync", outOfSyncMap[id])
+		}
+		if info.Status != models.BrokerUnreachable {
+			seen := checkedAt
+			info.LastSeen = &seen
+		}
+		infos = append(infos, info)
+	}
+	for id := range hosting {
+		if _, ok := addrs[id]; ok {
+			continue
+		}
+		infos = append(infos, models.Broker{
+			ID:           id,
+			Status:       models.BrokerUnreachable,
+			StatusDetail: "not registered in the cluster metadata",
+			CheckedAt:    checkedAt,
+			Replicas:     replicaMap[id],
 		})
 	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
 	return infos, nil
 }
 
-// GetConsumers returns consumer group information
-func (c *Client) GetConsumers() ([]models.ConsumerGroup, error) {
+// GetConsumers returns consumer group information. Assignments of topics the filter rejects are left
+// out, and so are members that are only assigned such topics.
+func (c *Client) GetConsumers(ctx context.Context, visible TopicFilter) ([]models.ConsumerGroup, error) {
 	if c.admin == nil {
 		return nil, fmt.Errorf("sarama admin client not initialized")
 	}
-	groups, err := c.admin.ListConsumerGroups()
+	groups, err := call(ctx, c.admin.ListConsumerGroups)
 	if err != nil {
 		return nil, err
 	}
 	var infos []models.ConsumerGroup
 	for groupID := range groups {
-		desc, err := c.admin.DescribeConsumerGroups([]string{groupID})
+		desc, err := call(ctx, func() ([]*sarama.GroupDescription, error) {
+			return c.admin.DescribeConsumerGroups([]string{groupID})
+		})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil || len(desc) == 0 {
 			continue
 		}
@@ -753,9 +955,15 @@ func (c *Client) GetConsumers() ([]models.ConsumerGroup, error) {
 			var topics []string
 			var partitions []int32
 			for topic, parts := range assignment.Topics {
+				if !visible.includes(topic) {
+					continue
+				}
 				topics = append(topics, topic)
 				partitions = append(partitions, parts...)
 			}
+			if len(topics) == 0 && len(assignment.Topics) > 0 {
+				continue
+			}
 			infos = append(infos, models.ConsumerGroup{
 				GroupID:    groupID,
 				MemberID:   member.MemberId,
@@ -783,9 +991,9 @@ func convertReplicas(replicas []int32) []int {
 }
 
 // DeleteTopic deletes a topic using the Sarama admin client
-func (c *Client) DeleteTopic(topic string) error {
+func (c *Client) DeleteTopic(ctx context.Context, topic string) error {
 	if c.admin == nil {
 		return fmt.Errorf("admin client not initialized")
 	}
-	return c.admin.DeleteTopic(topic)
+	return do(ctx, func() error { return c.admin.DeleteTopic(topic) })
 }
@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// cluster.go - Builds the cluster overview from the DescribeCluster admin call and the topic metadata.
+// Partition health follows Kafka's own broker metrics: a partition is offline without a leader,
+// under-replicated when an assigned replica is out of sync and under min ISR when fewer replicas
+// are in sync than the topic's min.insync.replicas.
+
+// minISRConfig is the topic config holding the minimum number of in-sync replicas for acks=all writes.
+const minISRConfig = "min.insync.replicas"
+
+// GetClusterOverview returns the cluster ID, controller, brokers and rack layout together with
+// topic and partition counts and partition health. Counts and health only cover visible topics.
+func (c *Client) GetClusterOverview(ctx context.Context, visible TopicFilter) (models.ClusterOverview, error) {
+	type description struct {
+		brokers      []*sarama.Broker
+		controllerID int32
+	}
+	cluster, err := call(ctx, func() (description, error) {
+		brokers, controllerID, err := c.admin.DescribeCluster()
+		return description{brokers, controllerID}, err
+	})
+	if err != nil {
+		return models.ClusterOverview{}, err
+	}
+	described, err := c.describeTopics(ctx)
+	if err != nil {
+		return models.ClusterOverview{}, err
+	}
+	topics := make([]*sarama.TopicMetadata, 0, len(described))
+	for _, topic := range described {
+		if visible.includes(topic.Name) {
+			topics = append(topics, topic)
+		}
+	}
+
+	overview := models.ClusterOverview{
+		ControllerID: cluster.controllerID,
+		KafkaVersion: c.capabilities.KafkaVersion,
+		BrokerCount:  len(cluster.brokers),
+		Brokers:      []models.BrokerSummary{},
+		Racks:        map[string][]int32{},
+	}
+	overview.ClusterID, _ = c.clusterID(ctx) // Brokers before 0.10.1 have no cluster ID
+	if ctx.Err() != nil {
+		return models.ClusterOverview{}, ctx.Err()
+	}
+	for _, broker := range cluster.brokers {
+		overview.Brokers = append(overview.Brokers, models.BrokerSummary{
+			ID:         broker.ID(),
+			Address:    broker.Addr(),
+			Rack:       broker.Rack(),
+			Controller: broker.ID() == cluster.controllerID,
+		})
+		if rack := broker.Rack(); rack != "" {
+			overview.Racks[rack] = append(overview.Racks[rack], broker.ID())
+		}
+	}
+	sort.Slice(overview.Brokers, func(i, j int) bool { return overview.Brokers[i].ID < overview.Brokers[j].ID })
+	for _, ids := range overview.Racks {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	// min.insync.replicas is best effort: without it the under-min-ISR count is reported as unknown
+	names := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		names = append(names, topic.Name)
+	}
+	minISR, err := c.minInSyncReplicas(ctx, names)
+	if ctx.Err() != nil {
+		return models.ClusterOverview{}, ctx.Err()
+	}
+	underMinISR := 0
+	if err == nil {
+		overview.UnderMinISRPartitions = &underMinISR
+	}
+
+	for _, topic := range topics {
+		overview.TopicCount++
+		if topic.IsInternal {
+			overview.InternalTopicCount++
+		}
+		for _, partition := range topic.Partitions {
+			overview.PartitionCount++
+			overview.ReplicaCount += len(partition.Replicas)
+			if partition.Leader < 0 {
+				overview.OfflinePartitions++
+			}
+			if len(partition.Isr) < len(partition.Replicas) {
+				overview.UnderReplicatedPartitions++
+			}
+			if required, ok := minISR[topic.Name]; ok && len(partition.Isr) < required {
+				underMinISR++
+			}
+		}
+	}
+
+	switch {
+	case overview.OfflinePartitions > 0:
+		overview.Health = models.ClusterCritical
+	case overview.UnderReplicatedPartitions > 0 || underMinISR > 0:
+		overview.Health = models.ClusterDegraded
+	default:
+		overview.Health = models.ClusterHealthy
+	}
+	return overview, nil
+}
+
+// clusterID asks the controller for the cluster ID with a metadata request for no topics.
+func (c *Client) clusterID(ctx context.Context) (string, error) {
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return "", err
+	}
+	response, err := call(ctx, func() (*sarama.MetadataResponse, error) {
+		return controller.GetMetadata(sarama.NewMetadataRequest(c.config.Version, []string{}))
+	})
+	if err != nil || response.ClusterID == nil {
+		return "", err
+	}
+	return *response.ClusterID, nil
+}
+
+// minInSyncReplicas returns the effective min.insync.replicas of each topic, described in one request.
+func (c *Client) minInSyncReplicas(ctx context.Context, topics []string) (map[string]int, error) {
+	result := map[string]int{}
+	if len(topics) == 0 {
+		return result, nil
+	}
+	if !c.config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil, &UnsupportedError{Feature: "describeConfigs", KafkaVersion: c.capabilities.KafkaVersion}
+	}
+
+	resources := make([]*sarama.ConfigResource, 0, len(topics))
+	for _, topic := range topics {
+		resources = append(resources, &sarama.ConfigResource{
+			Type:        sarama.TopicResource,
+			Name:        topic,
+			ConfigNames: []string{minISRConfig},
+		})
+	}
+
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.describeConfigResources(ctx, controller, resources)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range response.Resources {
+		if resource.ErrorCode != 0 {
+			continue
+		}
+		for _, entry := range resource.Configs {
+			if entry.Name != minISRConfig {
+				continue
+			}
+			if n, err := strconv.Atoi(entry.Value); err == nil {
+				result[resource.Name] = n
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,262 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// configs.go - Describes and changes broker configs.
+// Configs are described with their synonyms, so the value at every source is visible, and changed
+// with IncrementalAlterConfigs, which only touches the listed configs instead of replacing them all.
+
+// ErrInvalidConfig is wrapped by the errors of config changes that were rejected, either by
+// validation before sending them or by the cluster.
+var ErrInvalidConfig = errors.New("invalid config change")
+
+// IsInvalidConfig reports whether err means a config change was rejected.
+func IsInvalidConfig(err error) bool {
+	return errors.Is(err, ErrInvalidConfig)
+}
+
+// IsNotFound reports whether err means the requested broker or topic does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, sarama.ErrBrokerNotFound) || errors.Is(err, sarama.ErrUnknownTopicOrPartition)
+}
+
+// configOperations maps the operations of models.ConfigChange to their protocol values.
+var configOperations = map[string]sarama.IncrementalAlterConfigsOperation{
+	models.ConfigOperationSet:      sarama.IncrementalAlterConfigsOperationSet,
+	models.ConfigOperationDelete:   sarama.IncrementalAlterConfigsOperationDelete,
+	models.ConfigOperationAppend:   sarama.IncrementalAlterConfigsOperationAppend,
+	models.ConfigOperationSubtract: sarama.IncrementalAlterConfigsOperationSubtract,
+}
+
+// GetBrokerConfig returns every config of a broker with its source and synonyms.
+func (c *Client) GetBrokerConfig(ctx context.Context, brokerID int32) ([]models.ConfigEntry, error) {
+	broker, err := c.client.Broker(brokerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.describeConfigs(ctx, broker, &sarama.ConfigResource{
+		Type: sarama.BrokerResource,
+		Name: strconv.Itoa(int(brokerID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toConfigEntries(entries), nil
+}
+
+// AlterBrokerConfig applies changes to the dynamic config of a broker, or to the cluster-wide
+// default of all brokers when clusterWide is set. With validateOnly the brokers only check the
+// changes. Configs the broker reports as read-only are rejected before anything is sent.
+func (c *Client) AlterBrokerConfig(ctx context.Context, brokerID int32, clusterWide bool, changes []models.ConfigChange, validateOnly bool) error {
+	if err := c.require(FeatureIncrementalAlterConfigs); err != nil {
+		return err
+	}
+	broker, err := c.client.Broker(brokerID)
+	if err != nil {
+		return err
+	}
+	resource := &sarama.ConfigResource{Type: sarama.BrokerResource, Name: strconv.Itoa(int(brokerID))}
+	current, err := c.describeConfigs(ctx, broker, resource)
+	if err != nil {
+		return err
+	}
+	readOnly := map[string]bool{}
+	for _, entry := range current {
+		readOnly[entry.Name] = entry.ReadOnly
+	}
+
+	entries, err := toAlterEntries(changes)
+	if err != nil {
+		return err
+	}
+	for name := range entries {
+		if readOnly[name] {
+			return fmt.Errorf("%w: %s cannot be changed while the broker is running", ErrInvalidConfig, name)
+		}
+	}
+
+	if clusterWide {
+		resource.Name = "" // The cluster-wide default is the broker resource without a name
+	}
+	return c.incrementalAlterConfigs(ctx, broker, resource, entries, validateOnly)
+}
+
+// describeConfigs describes one resource on broker. Broker configs must be described by the broker itself.
+func (c *Client) describeConfigs(ctx context.Context, broker *sarama.Broker, resource *sarama.ConfigResource) ([]*sarama.ConfigEntry, error) {
+	response, err := c.describeConfigResources(ctx, broker, []*sarama.ConfigResource{resource})
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range response.Resources {
+		if result.Name != resource.Name {
+			continue
+		}
+		if result.ErrorCode != 0 {
+			return nil, configError(result.ErrorCode, result.ErrorMsg)
+		}
+		return result.Configs, nil
+	}
+	return nil, fmt.Errorf("no configs returned for %s", resource.Name)
+}
+
+// describeConfigResources sends one DescribeConfigs request for resources, including synonyms
+// when the cluster supports them.
+func (c *Client) describeConfigResources(ctx context.Context, broker *sarama.Broker, resources []*sarama.ConfigResource) (*sarama.DescribeConfigsResponse, error) {
+	request := &sarama.DescribeConfigsRequest{Resources: resources}
+	if c.config.Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 2
+	} else if c.config.Version.IsAtLeast(sarama.V1_1_0_0) {
+		request.Version = 1
+	}
+	request.IncludeSynonyms = request.Version > 0
+	return call(ctx, func() (*sarama.DescribeConfigsResponse, error) {
+		return broker.DescribeConfigs(request)
+	})
+}
+
+// incrementalAlterConfigs sends one IncrementalAlterConfigs request for resource to broker.
+func (c *Client) incrementalAlterConfigs(ctx context.Context, broker *sarama.Broker, resource *sarama.ConfigResource,
+	entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	request := &sarama.IncrementalAlterConfigsRequest{
+		Resources: []*sarama.IncrementalAlterConfigsResource{{
+			Type:          resource.Type,
+			Name:          resource.Name,
+			ConfigEntries: entries,
+		}},
+		ValidateOnly: validateOnly,
+	}
+	response, err := call(ctx, func() (*sarama.IncrementalAlterConfigsResponse, error) {
+		return broker.IncrementalAlterConfigs(request)
+	})
+	if err != nil {
+		return err
+	}
+	for _, result := range response.Resources {
+		if result.ErrorCode != 0 {
+			return configError(result.ErrorCode, result.ErrorMsg)
+		}
+	}
+	return nil
+}
+
+// toAlterEntries validates changes and converts them to IncrementalAlterConfigs entries.
+func toAlterEntries(changes []models.ConfigChange) (map[string]sarama.IncrementalAlterConfigsEntry, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: no changes given", ErrInvalidConfig)
+	}
+	entries := make(map[string]sarama.IncrementalAlterConfigsEntry, len(changes))
+	for _, change := range changes {
+		if change.Name == "" {
+			return nil, fmt.Errorf("%w: config name is required", ErrInvalidConfig)
+		}
+		if _, ok := entries[change.Name]; ok {
+			return nil, fmt.Errorf("%w: %s is changed more than once", ErrInvalidConfig, change.Name)
+		}
+		op := change.Operation
+		if op == "" {
+			op = models.ConfigOperationSet
+		}
+		operation, ok := configOperations[op]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown operation %q for %s", ErrInvalidConfig, change.Operation, change.Name)
+		}
+		entry := sarama.IncrementalAlterConfigsEntry{Operation: operation}
+		if operation != sarama.IncrementalAlterConfigsOperationDelete {
+			if change.Value == nil {
+				return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidConfig, change.Name)
+			}
+			entry.Value = change.Value
+		}
+		entries[change.Name] = entry
+	}
+	return entries, nil
+}
+
+// configError converts the error code of a config resource to an error. Rejected changes wrap ErrInvalidConfig.
+func configError(code int16, message string) error {
+	kerr := sarama.KError(code)
+	switch kerr {
+	case sarama.ErrInvalidConfig, sarama.ErrInvalidRequest, sarama.ErrPolicyViolation:
+		if message == "" {
+			message = kerr.Error()
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, message)
+	}
+	if message != "" {
+		return fmt.Errorf("%w: %s", kerr, message)
+	}
+	return kerr
+}
+
+// toConfigEntries converts described configs, hiding the values of sensitive ones. The default
+// value is only known when the cluster describes synonyms (Kafka 1.1 and later).
+func toConfigEntries(entries []*sarama.ConfigEntry) []models.ConfigEntry {
+	result := make([]models.ConfigEntry, 0, len(entries))
+	for _, entry := range entries {
+		config := models.ConfigEntry{
+			Name:      entry.Name,
+			Value:     configValue(entry.Value, entry.Sensitive),
+			Source:    configSource(entry.Source),
+			ReadOnly:  entry.ReadOnly,
+			Sensitive: entry.Sensitive,
+		}
+		for _, synonym := range entry.Synonyms {
+			config.Synonyms = append(config.Synonyms, models.ConfigSynonym{
+				Name:   synonym.ConfigName,
+				Value:  configValue(synonym.ConfigValue, entry.Sensitive),
+				Source: configSource(synonym.Source),
+			})
+			// The first synonym from a lower source is what remains once the value is deleted
+			if config.DefaultValue == nil && synonym.Source != entry.Source {
+				config.DefaultValue = configValue(synonym.ConfigValue, entry.Sensitive)
+			}
+		}
+		if entry.Source == sarama.SourceDefault {
+			config.DefaultValue = config.Value
+		}
+		result = append(result, config)
+	}
+	return result
+}
+
+// IsSensitiveConfig reports whether a broker or topic config holds a secret by its name, for callers
+// that see a config before the broker has described it: passwords, secrets, private keys and JAAS
+// configs, including their per-listener variants.
+func IsSensitiveConfig(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "secret") ||
+		strings.HasSuffix(name, ".key") || strings.HasSuffix(name, "jaas.config")
+}
+
+func configValue(value string, sensitive bool) *string {
+	if sensitive {
+		return nil
+	}
+	return &value
+}
+
+func configSource(source sarama.ConfigSource) string {
+	switch source {
+	case sarama.SourceTopic:
+		return models.ConfigSourceTopic
+	case sarama.SourceDynamicBroker:
+		return models.ConfigSourceDynamicBroker
+	case sarama.SourceDynamicDefaultBroker:
+		return models.ConfigSourceDynamicCluster
+	case sarama.SourceStaticBroker:
+		return models.ConfigSourceStatic
+	case sarama.SourceDefault:
+		return models.ConfigSourceDefault
+	}
+	return models.ConfigSourceUnknown
+}
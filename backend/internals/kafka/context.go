@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+)
+
+// context.go - Bounds blocking Sarama calls by a request context.
+// Sarama's client and admin calls take no context, so they run in a goroutine that is abandoned
+// when the context ends; its result is discarded and it finishes on Sarama's own network timeouts.
+
+// call runs fn and returns its result, or the context's error if ctx ends first.
+func call[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1) // Buffered so an abandoned fn can still finish
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// do runs fn like call for functions without a result.
+func do(ctx context.Context, fn func() error) error {
+	_, err := call(ctx, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// elections.go - Triggers preferred and unclean leader elections.
+// A preferred election hands leadership back to the first replica of each partition, e.g. once a
+// restarted broker is in sync again but its partitions are still led elsewhere. An unclean election
+// lets an out-of-sync replica lead a partition that has no in-sync replica left, losing the messages
+// it had not copied yet, so callers have to ask for it explicitly.
+
+// ElectLeaders runs a preferred or unclean leader election for partitions (topic to partition IDs).
+// A topic without partition IDs stands for all of its partitions and an empty map for every
+// partition of the cluster. Partitions that already have their preferred leader (or any leader, for
+// unclean elections) are reported as not elected without an error.
+func (c *Client) ElectLeaders(ctx context.Context, partitions map[string][]int32, unclean bool) ([]models.LeaderElection, error) {
+	if err := c.require(FeatureElectLeaders); err != nil {
+		return nil, err
+	}
+	metadata, err := c.describeTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := electionTargets(partitions, replicaLists(metadata))
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return []models.LeaderElection{}, nil
+	}
+
+	electionType := sarama.PreferredElection
+	if unclean {
+		electionType = sarama.UncleanElection
+	}
+	results, err := call(ctx, func() (map[string]map[int32]*sarama.PartitionResult, error) {
+		return c.admin.ElectLeaders(electionType, targets)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	elections := []models.LeaderElection{}
+	var changed []string // Topics whose leaders moved, to refresh in the metadata cache
+	for topic, ids := range targets {
+		moved := false
+		for _, id := range ids {
+			election := models.LeaderElection{Topic: topic, Partition: id}
+			result, ok := results[topic][id]
+			switch {
+			case !ok:
+				election.Error = "the controller returned no result for this partition"
+			case result.ErrorCode == sarama.ErrNoError:
+				election.Elected = true
+				moved = true
+			case result.ErrorCode == sarama.ErrElectionNotNeeded:
+				// The partition already has the leader the election would pick
+			default:
+				election.Error = kafkaError(result.ErrorCode, result.ErrorMessage).Error()
+			}
+			elections = append(elections, election)
+		}
+		if moved {
+			changed = append(changed, topic)
+		}
+	}
+	sort.Slice(elections, func(i, j int) bool {
+		if elections[i].Topic != elections[j].Topic {
+			return elections[i].Topic < elections[j].Topic
+		}
+		return elections[i].Partition < elections[j].Partition
+	})
+	if len(changed) > 0 {
+		_ = c.client.RefreshMetadata(changed...)
+	}
+	return elections, nil
+}
+
+// electionTargets expands partitions to explicit partition IDs using the cluster's replica lists,
+// dropping duplicates. Unknown topics and partitions are rejected.
+func electionTargets(partitions map[string][]int32, known map[string]map[int32][]int32) (map[string][]int32, error) {
+	targets := map[string][]int32{}
+	if len(partitions) == 0 {
+		partitions = make(map[string][]int32, len(known))
+		for topic := range known {
+			partitions[topic] = nil
+		}
+	}
+	for topic, ids := range partitions {
+		replicas, ok := known[topic]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", sarama.ErrUnknownTopicOrPartition, topic)
+		}
+		if len(ids) == 0 {
+			for id := range replicas {
+				targets[topic] = append(targets[topic], id)
+			}
+			continue
+		}
+		seen := map[int32]bool{}
+		for _, id := range ids {
+			if _, ok := replicas[id]; !ok {
+				return nil, fmt.Errorf("%w: %s-%d", sarama.ErrUnknownTopicOrPartition, topic, id)
+			}
+			if !seen[id] {
+				seen[id] = true
+				targets[topic] = append(targets[topic], id)
+			}
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestElectionTargets(t *testing.T) {
+	known := map[string]map[int32][]int32{
+		"orders":   {0: {1, 2}, 1: {2, 3}, 2: {3, 1}},
+		"payments": {0: {1, 2}},
+		"empty":    {},
+	}
+	tests := []struct {
+		name       string
+		partitions map[string][]int32
+		want       map[string][]int32
+		wantErr    bool
+	}{
+		{
+			name:       "every partition of the cluster",
+			partitions: nil,
+			want:       map[string][]int32{"orders": {0, 1, 2}, "payments": {0}},
+		},
+		{
+			name:       "every partition of a topic",
+			partitions: map[string][]int32{"orders": nil},
+			want:       map[string][]int32{"orders": {0, 1, 2}},
+		},
+		{
+			name:       "listed partitions",
+			partitions: map[string][]int32{"orders": {2, 0}, "payments": {0}},
+			want:       map[string][]int32{"orders": {0, 2}, "payments": {0}},
+		},
+		{
+			name:       "duplicates dropped",
+			partitions: map[string][]int32{"orders": {1, 1, 1}},
+			want:       map[string][]int32{"orders": {1}},
+		},
+		{
+			name:       "topic without partitions",
+			partitions: map[string][]int32{"empty": nil},
+			want:       map[string][]int32{},
+		},
+		{name: "unknown topic", partitions: map[string][]int32{"refunds": nil}, wantErr: true},
+		{name: "unknown partition", partitions: map[string][]int32{"orders": {0, 3}}, wantErr: true},
+		{name: "negative partition", partitions: map[string][]int32{"payments": {-1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := electionTargets(tt.partitions, known)
+			if tt.wantErr {
+				if !errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+					t.Fatalf("error %v, want %v", err, sarama.ErrUnknownTopicOrPartition)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ids := range got {
+				sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("electionTargets(%v) = %v, want %v", tt.partitions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElectionTargetsKeepsRequest(t *testing.T) {
+	partitions := map[string][]int32{"orders": {0, 0}}
+	if _, err := electionTargets(partitions, map[string]map[int32][]int32{"orders": {0: {1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(partitions, map[string][]int32{"orders": {0, 0}}) {
+		t.Errorf("request changed to %v", partitions)
+	}
+}
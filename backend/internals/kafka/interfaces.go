@@ -1,29 +1,56 @@
 package kafka
 
 import (
+	"context"
+
 	"backend/internals/models"
 )
 
 // interfaces.go - Defines interfaces and data structures for Kafka operations.
 // Provides the KafkaService interface and related types for topics, partitions, brokers, consumers, and messages.
 
+// TopicFilter reports whether a topic may appear in a result. Operations that summarize many topics
+// leave out the ones the filter rejects; a nil filter includes every topic.
+type TopicFilter func(topic string) bool
+
+// includes reports whether the filter lets topic through.
+func (f TopicFilter) includes(topic string) bool {
+	return f == nil || f(topic)
+}
+
 // KafkaService defines the interface for all Kafka operations, including connection, topic, message, and cluster management.
+// Every operation takes the context of the HTTP request and returns the context's error once it is cancelled or times out.
 type KafkaService interface {
 	// Connection Operations
-	CheckConnection() error // Checks connectivity to the Kafka cluster
+	CheckConnection(ctx context.Context) error // Checks connectivity to the Kafka cluster
+	Close() error                              // Closes the connections to the cluster
+	Capabilities() models.Capabilities         // Negotiated Kafka version and optional features
 
 	// Topic Operations
-	ListTopics() ([]models.Topic, error)                              // Lists all topics
-	CreateTopic(name string, partitions, replicationFactor int) error // Creates a new topic
-	DeleteTopic(topic string) error                                   // Deletes a topic
-	GetPartitionInfo(topic string) ([]models.PartitionInfo, error)    // Gets partition info for a topic
+	ListTopics(ctx context.Context) ([]models.Topic, error)                                                                                           // Lists all topics
+	CreateTopic(ctx context.Context, name string, partitions, replicationFactor int, configs map[string]string) error                                 // Creates a new topic with config overrides
+	GetTopicConfig(ctx context.Context, topic string) ([]models.ConfigEntry, error)                                                                   // Gets topic configs with their defaults
+	AlterTopicConfig(ctx context.Context, topic string, changes []models.ConfigChange, validateOnly bool) error                                       // Changes or resets topic config overrides
+	DeleteTopic(ctx context.Context, topic string) error                                                                                              // Deletes a topic
+	GetPartitionInfo(ctx context.Context, topic string) ([]models.PartitionInfo, error)                                                               // Gets partition info for a topic
+	AddPartitions(ctx context.Context, topic string, count int, assignment [][]int32, validateOnly, checkKeys bool) (models.PartitionIncrease, error) // Raises the partition count of a topic
+	GetLogDirs(ctx context.Context) ([]models.BrokerLogDirs, error)                                                                                   // Gets disk usage per broker, log dir, topic and partition
 
 	// Message Operations
-	ClearTopicMessages(topic string) error                                                          // Clears all messages from a topic
-	FetchMessages(topic string, limit int, sortOrder string) ([]models.Message, error)              // Fetches messages from a topic
-	Produce(topic, key string, value []byte, partition int32, headers []models.MessageHeader) error // Produces a message
+	ClearTopicMessages(ctx context.Context, topic string) error                                                          // Clears all messages from a topic
+	FetchMessages(ctx context.Context, topic string, limit int, sortOrder string) ([]models.Message, error)              // Fetches messages from a topic
+	Produce(ctx context.Context, topic, key string, value []byte, partition int32, headers []models.MessageHeader) error // Produces a message
 
 	// Cluster Operations
-	GetBrokers() ([]models.Broker, error)          // Gets broker info
-	GetConsumers() ([]models.ConsumerGroup, error) // Gets consumer group info
+	GetClusterOverview(ctx context.Context, visible TopicFilter) (models.ClusterOverview, error)                                     // Gets cluster identity, brokers and partition health
+	GetBrokers(ctx context.Context, visible TopicFilter) ([]models.Broker, error)                                                    // Gets broker info
+	GetBrokerConfig(ctx context.Context, brokerID int32) ([]models.ConfigEntry, error)                                               // Gets broker configs with their sources
+	AlterBrokerConfig(ctx context.Context, brokerID int32, clusterWide bool, changes []models.ConfigChange, validateOnly bool) error // Changes dynamic broker configs
+	PlanReassignment(ctx context.Context, topics []string, brokers []int32) (models.ReassignmentPlan, error)                         // Plans moving topics' replicas onto brokers
+	ExecuteReassignment(ctx context.Context, moves []models.PartitionMove, throttle int64) ([]models.PartitionResult, error)         // Starts partition reassignments
+	ListReassignments(ctx context.Context) ([]models.PartitionReassignment, error)                                                   // Lists reassignments in progress
+	CancelReassignments(ctx context.Context, partitions map[string][]int32) ([]models.PartitionResult, error)                        // Cancels reassignments in progress
+	RemoveReplicationThrottle(ctx context.Context) ([]string, error)                                                                 // Removes reassignment throttles
+	ElectLeaders(ctx context.Context, partitions map[string][]int32, unclean bool) ([]models.LeaderElection, error)                  // Runs preferred or unclean leader elections
+	GetConsumers(ctx context.Context, visible TopicFilter) ([]models.ConsumerGroup, error)                                           // Gets consumer group info
 }
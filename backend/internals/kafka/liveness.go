@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// liveness.go - Probes brokers with ApiVersions requests to tell whether they are alive.
+// A dead broker stays in the client's metadata until the cluster notices it is gone, so the
+// metadata alone cannot tell a healthy broker from an unreachable one.
+
+// BrokerProbeTimeout bounds the connection and ApiVersions request of a liveness probe.
+const BrokerProbeTimeout = 3 * time.Second
+
+// SlowProbeThreshold is the probe duration above which a broker that answers is reported as degraded.
+const SlowProbeThreshold = time.Second
+
+// probeResult is the outcome of one liveness probe.
+type probeResult struct {
+	latency time.Duration
+	err     error
+}
+
+// probeBrokers probes the given brokers concurrently on fresh connections, so a broker that
+// went away is noticed even while the client still holds an open connection to it.
+func (c *Client) probeBrokers(ctx context.Context, addrs map[int32]string) map[int32]probeResult {
+	results := make(map[int32]probeResult, len(addrs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for id, addr := range addrs {
+		wg.Add(1)
+		go func(id int32, addr string) {
+			defer wg.Done()
+			start := time.Now()
+			_, err := call(ctx, func() (apiRanges, error) {
+				return probeAPIVersions(addr, c.config, BrokerProbeTimeout)
+			})
+			mu.Lock()
+			results[id] = probeResult{latency: time.Since(start), err: err}
+			mu.Unlock()
+		}(id, addr)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,138 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// logdirs.go - Reports disk usage from DescribeLogDirs requests sent to every broker.
+// Each broker only knows its own log directories, so all brokers are asked in parallel and a
+// broker that fails is reported with its error instead of failing the whole request.
+
+// GetLogDirs returns the log directories of every broker with the size of each topic and partition log.
+func (c *Client) GetLogDirs(ctx context.Context) ([]models.BrokerLogDirs, error) {
+	if err := c.require(FeatureLogDirs); err != nil {
+		return nil, err
+	}
+	request := &sarama.DescribeLogDirsRequest{} // No topics describes all of them
+	if c.config.Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 1
+	}
+
+	brokers := c.client.Brokers()
+	results := make([]models.BrokerLogDirs, len(brokers))
+	var wg sync.WaitGroup
+	for i, broker := range brokers {
+		wg.Add(1)
+		go func(i int, broker *sarama.Broker) {
+			defer wg.Done()
+			result := models.BrokerLogDirs{BrokerID: broker.ID(), LogDirs: []models.LogDir{}}
+			response, err := call(ctx, func() (*sarama.DescribeLogDirsResponse, error) {
+				_ = broker.Open(c.config) // Brokers known from metadata are connected on first use
+				return broker.DescribeLogDirs(request)
+			})
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				for _, dir := range response.LogDirs {
+					logDir := toLogDir(dir)
+					result.SizeBytes += logDir.SizeBytes
+					result.LogDirs = append(result.LogDirs, logDir)
+				}
+			}
+			results[i] = result
+		}(i, broker)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].BrokerID < results[j].BrokerID })
+	return results, nil
+}
+
+// SumTopicSizes adds up the logs of each topic, largest topic first, and returns the brokers
+// that reported an error instead of their log directories.
+func SumTopicSizes(logDirs []models.BrokerLogDirs) ([]models.TopicSize, []int32) {
+	type partitionKey struct {
+		topic     string
+		partition int32
+	}
+	topics := map[string]*models.TopicSize{}
+	partitions := map[partitionKey]bool{}
+	missing := []int32{}
+	for _, broker := range logDirs {
+		if broker.Error != "" {
+			missing = append(missing, broker.BrokerID)
+			continue
+		}
+		for _, dir := range broker.LogDirs {
+			for _, topic := range dir.Topics {
+				size := topics[topic.Topic]
+				if size == nil {
+					size = &models.TopicSize{Topic: topic.Topic}
+					topics[topic.Topic] = size
+				}
+				for _, partition := range topic.Partitions {
+					size.SizeBytes += partition.SizeBytes
+					if partition.SizeBytes > size.LargestReplicaSize {
+						size.LargestReplicaSize = partition.SizeBytes
+					}
+					if partition.Future {
+						continue
+					}
+					size.ReplicaCount++
+					key := partitionKey{topic.Topic, partition.Partition}
+					if !partitions[key] {
+						partitions[key] = true
+						size.PartitionCount++
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]models.TopicSize, 0, len(topics))
+	for _, size := range topics {
+		result = append(result, *size)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SizeBytes != result[j].SizeBytes {
+			return result[i].SizeBytes > result[j].SizeBytes
+		}
+		return result[i].Topic < result[j].Topic
+	})
+	return result, missing
+}
+
+// toLogDir converts one log directory of a DescribeLogDirs response.
+func toLogDir(dir sarama.DescribeLogDirsResponseDirMetadata) models.LogDir {
+	logDir := models.LogDir{Path: dir.Path, Topics: []models.LogDirTopic{}}
+	if dir.ErrorCode != sarama.ErrNoError {
+		logDir.Error = dir.ErrorCode.Error()
+	}
+	for _, topic := range dir.Topics {
+		logTopic := models.LogDirTopic{Topic: topic.Topic, Partitions: []models.LogDirPartition{}}
+		for _, partition := range topic.Partitions {
+			logTopic.SizeBytes += partition.Size
+			logTopic.Partitions = append(logTopic.Partitions, models.LogDirPartition{
+				Partition: partition.PartitionID,
+				SizeBytes: partition.Size,
+				OffsetLag: partition.OffsetLag,
+				Future:    partition.IsTemporary,
+			})
+		}
+		sort.Slice(logTopic.Partitions, func(i, j int) bool {
+			return logTopic.Partitions[i].Partition < logTopic.Partitions[j].Partition
+		})
+		logDir.SizeBytes += logTopic.SizeBytes
+		logDir.Topics = append(logDir.Topics, logTopic)
+	}
+	sort.Slice(logDir.Topics, func(i, j int) bool { return logDir.Topics[i].Topic < logDir.Topics[j].Topic })
+	return logDir
+}
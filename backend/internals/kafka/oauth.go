@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// oauth.go - Provides SASL/OAUTHBEARER access tokens from an OAuth 2.0 token endpoint.
+// Tokens are requested with the client credentials grant and reused until shortly before they expire.
+
+const (
+	// tokenRefreshMargin is how long before expiry a cached token is replaced.
+	tokenRefreshMargin = 30 * time.Second
+
+	// defaultTokenLifetime applies when the token endpoint does not return expires_in.
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// tokenProvider implements sarama.AccessTokenProvider. It is safe for concurrent use.
+type tokenProvider struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newTokenProvider(tokenURL, clientID, clientSecret string, scopes []string) *tokenProvider {
+	return &tokenProvider{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Token returns a cached token, fetching a new one when it is about to expire.
+func (p *tokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Add(tokenRefreshMargin).Before(p.expires) {
+		return &sarama.AccessToken{Token: p.token}, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil || tokenResponse.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access token")
+	}
+	lifetime := defaultTokenLifetime
+	if tokenResponse.ExpiresIn > 0 {
+		lifetime = time.Duration(tokenResponse.ExpiresIn) * time.Second
+	}
+	p.token, p.expires = tokenResponse.AccessToken, time.Now().Add(lifetime)
+	return &sarama.AccessToken{Token: p.token}, nil
+}
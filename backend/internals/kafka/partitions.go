@@ -0,0 +1,213 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// partitions.go - Adds partitions to existing topics.
+// Adding partitions changes which partition the default partitioner picks for a key, so unless the
+// caller has already acknowledged it, the most recent messages are sampled first and the result
+// warns when the topic carries keyed traffic.
+
+const (
+	keySampleSize    = 100                    // Recent messages checked for keys before adding partitions
+	keySampleTimeout = 2 * time.Second        // Bound on sampling, so it never holds up the change for long
+	keySampleIdle    = 250 * time.Millisecond // Wait for the next message of a partition before moving on
+)
+
+// ErrInvalidPartitions is wrapped by the errors of partition changes that were rejected, either by
+// validation before sending them or by the cluster.
+var ErrInvalidPartitions = errors.New("invalid partition change")
+
+// IsInvalidPartitions reports whether err means a partition change was rejected.
+func IsInvalidPartitions(err error) bool {
+	return errors.Is(err, ErrInvalidPartitions)
+}
+
+// AddPartitions raises the partition count of topic to count. assignment optionally lists the
+// replicas of each new partition, preferred leader first; without it the controller places them.
+// With validateOnly the controller only checks the change. With checkKeys recent messages are
+// sampled for keys first.
+func (c *Client) AddPartitions(ctx context.Context, topic string, count int, assignment [][]int32, validateOnly, checkKeys bool) (models.PartitionIncrease, error) {
+	if err := c.require(FeatureCreatePartitions); err != nil {
+		return models.PartitionIncrease{}, err
+	}
+	partitions, err := call(ctx, func() ([]int32, error) { return c.client.Partitions(topic) })
+	if err != nil {
+		return models.PartitionIncrease{}, err
+	}
+	if count <= len(partitions) {
+		return models.PartitionIncrease{}, fmt.Errorf("%w: %s already has %d partitions and partitions cannot be removed",
+			ErrInvalidPartitions, topic, len(partitions))
+	}
+	if len(assignment) > 0 {
+		replicas, err := call(ctx, func() ([]int32, error) { return c.client.Replicas(topic, partitions[0]) })
+		if err != nil {
+			return models.PartitionIncrease{}, err
+		}
+		if err := c.validateAssignment(assignment, count-len(partitions), len(replicas)); err != nil {
+			return models.PartitionIncrease{}, err
+		}
+	} else {
+		assignment = nil // Let the controller place the new partitions
+	}
+
+	result := models.PartitionIncrease{
+		Topic:          topic,
+		PreviousCount:  len(partitions),
+		PartitionCount: count,
+		ValidateOnly:   validateOnly,
+		Assignment:     assignment,
+		Warnings:       []string{},
+	}
+	if checkKeys {
+		sampleCtx, cancel := context.WithTimeout(ctx, keySampleTimeout)
+		result.SampledMessages, result.KeyedMessages, err = c.sampleKeys(sampleCtx, topic, partitions)
+		cancel()
+		result.KeysChecked = true
+		switch {
+		case ctx.Err() != nil:
+			return models.PartitionIncrease{}, ctx.Err()
+		case err != nil && result.KeyedMessages == 0:
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"The topic could not be fully checked for keyed messages (%d checked): %v", result.SampledMessages, err))
+		}
+	}
+	if result.KeyedMessages > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"%d of the %d most recent messages have keys. Producers map keys to partitions by the partition count, "+
+				"so most keys will go to a different partition after the change and their ordering is only kept for new messages.",
+			result.KeyedMessages, result.SampledMessages))
+	}
+
+	err = do(ctx, func() error {
+		return c.admin.CreatePartitions(topic, int32(count), assignment, validateOnly)
+	})
+	var partitionErr *sarama.TopicPartitionError
+	if errors.As(err, &partitionErr) {
+		return models.PartitionIncrease{}, partitionError(partitionErr)
+	}
+	if err != nil {
+		return models.PartitionIncrease{}, err
+	}
+	if !validateOnly {
+		_ = c.client.RefreshMetadata(topic)
+	}
+	return result, nil
+}
+
+// sampleKeys reads up to keySampleSize of the newest messages of topic, spread over its partitions,
+// and returns how many it read and how many of them have a key. It stops at the end of each
+// partition rather than waiting for new messages, and returns the counts so far with an error
+// when ctx ends first.
+func (c *Client) sampleKeys(ctx context.Context, topic string, partitions []int32) (sampled, keyed int, err error) {
+	consumer, err := sarama.NewConsumerFromClient(c.client)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer consumer.Close()
+
+	perPartition := int64(max(keySampleSize/len(partitions), 1))
+	for _, partition := range partitions {
+		newest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetNewest) })
+		if err != nil {
+			return sampled, keyed, err
+		}
+		oldest, err := call(ctx, func() (int64, error) { return c.client.GetOffset(topic, partition, sarama.OffsetOldest) })
+		if err != nil {
+			return sampled, keyed, err
+		}
+		start := newest - perPartition
+		if start < oldest {
+			start = oldest
+		}
+		if start >= newest {
+			continue
+		}
+		pc, err := consumer.ConsumePartition(topic, partition, start)
+		if err != nil {
+			return sampled, keyed, err
+		}
+		err = func() error {
+			defer pc.Close()
+			idle := time.NewTimer(keySampleIdle)
+			defer idle.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-idle.C:
+					return nil // Compacted away or a transaction marker: nothing more to read
+				case err := <-pc.Errors():
+					return err
+				case msg := <-pc.Messages():
+					sampled++
+					if len(msg.Key) > 0 {
+						keyed++
+					}
+					if msg.Offset >= newest-1 {
+						return nil
+					}
+					if !idle.Stop() {
+						<-idle.C
+					}
+					idle.Reset(keySampleIdle)
+				}
+			}
+		}()
+		if err != nil {
+			return sampled, keyed, err
+		}
+	}
+	return sampled, keyed, nil
+}
+
+// validateAssignment checks the replicas given for newPartitions new partitions: each must have
+// replicationFactor distinct replicas on known brokers.
+func (c *Client) validateAssignment(assignment [][]int32, newPartitions, replicationFactor int) error {
+	if len(assignment) != newPartitions {
+		return fmt.Errorf("%w: assignment lists %d partitions but %d are added", ErrInvalidPartitions, len(assignment), newPartitions)
+	}
+	brokers := map[int32]bool{}
+	for _, broker := range c.client.Brokers() {
+		brokers[broker.ID()] = true
+	}
+	for i, replicas := range assignment {
+		if len(replicas) != replicationFactor {
+			return fmt.Errorf("%w: new partition %d has %d replicas but the topic's replication factor is %d",
+				ErrInvalidPartitions, i, len(replicas), replicationFactor)
+		}
+		seen := map[int32]bool{}
+		for _, id := range replicas {
+			if !brokers[id] {
+				return fmt.Errorf("%w: broker %d does not exist", ErrInvalidPartitions, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: new partition %d lists broker %d twice", ErrInvalidPartitions, i, id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// partitionError converts the error of a CreatePartitions result. Rejected changes wrap ErrInvalidPartitions.
+func partitionError(err *sarama.TopicPartitionError) error {
+	message := err.Err.Error()
+	if err.ErrMsg != nil && *err.ErrMsg != "" {
+		message = *err.ErrMsg
+	}
+	switch err.Err {
+	case sarama.ErrInvalidPartitions, sarama.ErrInvalidReplicaAssignment, sarama.ErrInvalidReplicationFactor,
+		sarama.ErrInvalidRequest, sarama.ErrPolicyViolation:
+		return fmt.Errorf("%w: %s", ErrInvalidPartitions, message)
+	}
+	return err
+}
@@ -0,0 +1,620 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// reassignments.go - Plans, executes and tracks partition reassignments.
+// Plans start from the replica lists in the topic metadata (the same data GetBrokers reports) and
+// only replace replicas that are not on a target broker, so partitions already in place are not
+// touched. Replacements go to the least loaded target broker, preferring racks the partition does
+// not use yet. Plans are executed with AlterPartitionReassignments (Kafka 2.4+), optionally with a
+// replication throttle set the same way kafka-reassign-partitions.sh does.
+
+// Configs that throttle replication traffic while replicas are copied.
+const (
+	leaderThrottleRate        = "leader.replication.throttled.rate"
+	followerThrottleRate      = "follower.replication.throttled.rate"
+	leaderThrottledReplicas   = "leader.replication.throttled.replicas"
+	followerThrottledReplicas = "follower.replication.throttled.replicas"
+)
+
+// reassignmentTimeoutMs bounds how long the controller waits for a reassignment request to be applied.
+const reassignmentTimeoutMs = 60000
+
+// PlanReassignment proposes new replica lists that place every partition of topics on brokers.
+// Replicas already on a target broker stay where they are; the others move to the target broker
+// holding the fewest replicas, spread over racks when every target broker has one.
+func (c *Client) PlanReassignment(ctx context.Context, topics []string, brokers []int32) (models.ReassignmentPlan, error) {
+	if len(topics) == 0 {
+		return models.ReassignmentPlan{}, fmt.Errorf("%w: at least one topic is required", ErrInvalidPartitions)
+	}
+	racks := map[int32]string{}
+	for _, broker := range c.client.Brokers() {
+		racks[broker.ID()] = broker.Rack()
+	}
+	plan := models.ReassignmentPlan{Partitions: []models.PartitionMove{}, Warnings: []string{}}
+	seen := map[int32]bool{}
+	for _, id := range brokers {
+		if _, ok := racks[id]; !ok {
+			return models.ReassignmentPlan{}, fmt.Errorf("%w: broker %d does not exist", ErrInvalidPartitions, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			plan.Brokers = append(plan.Brokers, id)
+		}
+	}
+	if len(plan.Brokers) == 0 {
+		return models.ReassignmentPlan{}, fmt.Errorf("%w: at least one target broker is required", ErrInvalidPartitions)
+	}
+	sort.Slice(plan.Brokers, func(i, j int) bool { return plan.Brokers[i] < plan.Brokers[j] })
+
+	metadata, err := c.describeTopics(ctx)
+	if err != nil {
+		return models.ReassignmentPlan{}, err
+	}
+	byName := make(map[string]*sarama.TopicMetadata, len(metadata))
+	load := map[int32]int{} // Replicas per broker over the whole cluster
+	for _, topic := range metadata {
+		byName[topic.Name] = topic
+		for _, partition := range topic.Partitions {
+			for _, id := range partition.Replicas {
+				load[id]++
+			}
+		}
+	}
+	selected := make([]*sarama.TopicMetadata, 0, len(topics))
+	for _, name := range topics {
+		topic, ok := byName[name]
+		if !ok {
+			return models.ReassignmentPlan{}, fmt.Errorf("%w: %s", sarama.ErrUnknownTopicOrPartition, name)
+		}
+		selected = append(selected, topic)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+
+	// Racks only count when every target broker has one, as in Kafka's own rack-aware assignment
+	targetRacks := map[string]bool{}
+	plan.RackAware = true
+	for _, id := range plan.Brokers {
+		if racks[id] == "" {
+			plan.RackAware = false
+		}
+		targetRacks[racks[id]] = true
+	}
+	if !plan.RackAware {
+		if len(targetRacks) > 1 {
+			plan.Warnings = append(plan.Warnings, "Some target brokers have no rack, so racks were ignored.")
+		}
+		racks = nil
+	}
+
+	maxReplicas := 0
+	for _, topic := range selected {
+		partitions := append([]*sarama.PartitionMetadata(nil), topic.Partitions...)
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+		for _, partition := range partitions {
+			if len(partition.Replicas) > len(plan.Brokers) {
+				return models.ReassignmentPlan{}, fmt.Errorf("%w: %s-%d has %d replicas but only %d target brokers were given",
+					ErrInvalidPartitions, topic.Name, partition.ID, len(partition.Replicas), len(plan.Brokers))
+			}
+			maxReplicas = max(maxReplicas, len(partition.Replicas))
+			replicas := placeReplicas(partition.Replicas, plan.Brokers, racks, load)
+			moved := 0
+			for _, id := range replicas {
+				if !containsBroker(partition.Replicas, id) {
+					moved++
+				}
+			}
+			if moved == 0 && equalReplicas(replicas, partition.Replicas) {
+				plan.Unchanged++
+				continue
+			}
+			plan.MovedReplicas += moved
+			plan.Partitions = append(plan.Partitions, models.PartitionMove{
+				Topic:           topic.Name,
+				Partition:       partition.ID,
+				CurrentReplicas: partition.Replicas,
+				Replicas:        replicas,
+			})
+		}
+	}
+	if plan.RackAware && len(targetRacks) < maxReplicas {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"The target brokers span %d racks, fewer than the %d replicas of some partitions, so those keep several replicas in one rack.",
+			len(targetRacks), maxReplicas))
+	}
+	return plan, nil
+}
+
+// placeReplicas returns the replica list of one partition on targets. Replicas on a target broker
+// keep their position (so the preferred leader only changes when it moves); the others are replaced
+// by the least loaded target broker. With racks, a rack holds at most its fair share of replicas.
+// load is updated with the moves.
+func placeReplicas(current, targets []int32, racks map[int32]string, load map[int32]int) []int32 {
+	isTarget := make(map[int32]bool, len(targets))
+	rackCount := map[string]bool{}
+	for _, id := range targets {
+		isTarget[id] = true
+		rackCount[racks[id]] = true
+	}
+	perRack := (len(current) + len(rackCount) - 1) / len(rackCount)
+
+	result := make([]int32, len(current))
+	used := map[int32]bool{}
+	usedRacks := map[string]int{}
+	var free []int
+	for i, id := range current {
+		if isTarget[id] && !used[id] && usedRacks[racks[id]] < perRack {
+			result[i] = id
+			used[id] = true
+			usedRacks[racks[id]]++
+			continue
+		}
+		free = append(free, i)
+		load[id]--
+	}
+	for _, i := range free {
+		best := int32(-1)
+		for _, rackLimited := range []bool{true, false} {
+			for _, id := range targets {
+				if used[id] || (rackLimited && usedRacks[racks[id]] >= perRack) {
+					continue
+				}
+				if best == -1 || load[id] < load[best] || (load[id] == load[best] && id < best) {
+					best = id
+				}
+			}
+			if best != -1 {
+				break
+			}
+		}
+		result[i] = best
+		used[best] = true
+		usedRacks[racks[best]]++
+		load[best]++
+	}
+	return result
+}
+
+// ExecuteReassignment starts moving the partitions to their new replicas and returns the result of
+// each partition. With a positive throttle, replication of the moved partitions is limited to that
+// many bytes per second on every broker involved until RemoveReplicationThrottle is called.
+func (c *Client) ExecuteReassignment(ctx context.Context, moves []models.PartitionMove, throttle int64) ([]models.PartitionResult, error) {
+	if err := c.require(FeatureReassignments); err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("%w: no partitions given", ErrInvalidPartitions)
+	}
+	if throttle < 0 {
+		return nil, fmt.Errorf("%w: throttle must not be negative", ErrInvalidPartitions)
+	}
+	metadata, err := c.describeTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := replicaLists(metadata)
+	brokers := map[int32]bool{}
+	for _, broker := range c.client.Brokers() {
+		brokers[broker.ID()] = true
+	}
+
+	request := &sarama.AlterPartitionReassignmentsRequest{TimeoutMs: reassignmentTimeoutMs}
+	given := map[string]map[int32]bool{}
+	for _, move := range moves {
+		if _, ok := current[move.Topic][move.Partition]; !ok {
+			return nil, fmt.Errorf("%w: %s-%d", sarama.ErrUnknownTopicOrPartition, move.Topic, move.Partition)
+		}
+		if given[move.Topic][move.Partition] {
+			return nil, fmt.Errorf("%w: %s-%d is listed more than once", ErrInvalidPartitions, move.Topic, move.Partition)
+		}
+		if given[move.Topic] == nil {
+			given[move.Topic] = map[int32]bool{}
+		}
+		given[move.Topic][move.Partition] = true
+		if len(move.Replicas) == 0 {
+			return nil, fmt.Errorf("%w: %s-%d has no replicas", ErrInvalidPartitions, move.Topic, move.Partition)
+		}
+		for i, id := range move.Replicas {
+			if !brokers[id] {
+				return nil, fmt.Errorf("%w: broker %d does not exist", ErrInvalidPartitions, id)
+			}
+			if containsBroker(move.Replicas[:i], id) {
+				return nil, fmt.Errorf("%w: %s-%d lists broker %d twice", ErrInvalidPartitions, move.Topic, move.Partition, id)
+			}
+		}
+		request.AddBlock(move.Topic, move.Partition, move.Replicas)
+	}
+
+	if throttle > 0 {
+		if err := c.setReplicationThrottle(ctx, moves, current, throttle); err != nil {
+			return nil, err
+		}
+	}
+	return c.alterReassignments(ctx, request)
+}
+
+// ListReassignments returns the reassignments in progress. Progress compares the log of each adding
+// replica with the leader's and is only known on clusters that report their log directories.
+func (c *Client) ListReassignments(ctx context.Context) ([]models.PartitionReassignment, error) {
+	if err := c.require(FeatureReassignments); err != nil {
+		return nil, err
+	}
+	metadata, err := c.describeTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ongoing, err := c.ongoingReassignments(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	leaders := map[string]map[int32]int32{}
+	for _, topic := range metadata {
+		leaders[topic.Name] = map[int32]int32{}
+		for _, partition := range topic.Partitions {
+			leaders[topic.Name][partition.ID] = partition.Leader
+		}
+	}
+
+	// Log sizes are best effort: without them the progress is reported as unknown
+	type replicaKey struct {
+		topic     string
+		partition int32
+		broker    int32
+	}
+	var sizes map[replicaKey]int64
+	if len(ongoing) > 0 && c.capabilities.Features[FeatureLogDirs] {
+		if logDirs, err := c.GetLogDirs(ctx); err == nil {
+			sizes = map[replicaKey]int64{}
+			for _, broker := range logDirs {
+				for _, dir := range broker.LogDirs {
+					for _, topic := range dir.Topics {
+						for _, partition := range topic.Partitions {
+							if !partition.Future {
+								sizes[replicaKey{topic.Topic, partition.Partition, broker.BrokerID}] = partition.SizeBytes
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result := make([]models.PartitionReassignment, 0, len(ongoing))
+	for topic, partitions := range ongoing {
+		for partition, status := range partitions {
+			reassignment := models.PartitionReassignment{
+				Topic:            topic,
+				Partition:        partition,
+				Replicas:         nonNil(status.Replicas),
+				AddingReplicas:   nonNil(status.AddingReplicas),
+				RemovingReplicas: nonNil(status.RemovingReplicas),
+			}
+			leaderSize, ok := sizes[replicaKey{topic, partition, leaders[topic][partition]}]
+			if ok {
+				progress := 1.0
+				for _, id := range status.AddingReplicas {
+					copied := sizes[replicaKey{topic, partition, id}]
+					if leaderSize > 0 {
+						progress = math.Min(progress, float64(copied)/float64(leaderSize))
+					}
+				}
+				reassignment.Progress = &progress
+			}
+			result = append(result, reassignment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Topic != result[j].Topic {
+			return result[i].Topic < result[j].Topic
+		}
+		return result[i].Partition < result[j].Partition
+	})
+	return result, nil
+}
+
+// CancelReassignments stops the reassignments of the given partitions (topic to partition IDs), or of
+// every partition being reassigned when partitions is empty. Cancelled partitions return to their
+// original replicas.
+func (c *Client) CancelReassignments(ctx context.Context, partitions map[string][]int32) ([]models.PartitionResult, error) {
+	if err := c.require(FeatureReassignments); err != nil {
+		return nil, err
+	}
+	if len(partitions) == 0 {
+		metadata, err := c.describeTopics(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ongoing, err := c.ongoingReassignments(ctx, metadata)
+		if err != nil {
+			return nil, err
+		}
+		partitions = map[string][]int32{}
+		for topic, statuses := range ongoing {
+			for partition := range statuses {
+				partitions[topic] = append(partitions[topic], partition)
+			}
+		}
+		if len(partitions) == 0 {
+			return []models.PartitionResult{}, nil
+		}
+	}
+
+	request := &sarama.AlterPartitionReassignmentsRequest{TimeoutMs: reassignmentTimeoutMs}
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			request.AddBlock(topic, id, nil) // No replicas cancels the reassignment
+		}
+	}
+	return c.alterReassignments(ctx, request)
+}
+
+// RemoveReplicationThrottle removes the replication throttle set by ExecuteReassignment from every
+// broker and topic and returns the topics that had throttled replicas.
+func (c *Client) RemoveReplicationThrottle(ctx context.Context) ([]string, error) {
+	if err := c.require(FeatureIncrementalAlterConfigs); err != nil {
+		return nil, err
+	}
+	rates := map[string]sarama.IncrementalAlterConfigsEntry{
+		leaderThrottleRate:   {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+		followerThrottleRate: {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+	}
+	for _, broker := range c.client.Brokers() {
+		resource := &sarama.ConfigResource{Type: sarama.BrokerResource, Name: strconv.Itoa(int(broker.ID()))}
+		if err := c.incrementalAlterConfigs(ctx, broker, resource, rates, false); err != nil {
+			return nil, fmt.Errorf("broker %d: %w", broker.ID(), err)
+		}
+	}
+
+	names, err := call(ctx, c.client.Topics)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]*sarama.ConfigResource, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, &sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+	}
+	response, err := c.describeConfigResources(ctx, controller, resources)
+	if err != nil {
+		return nil, err
+	}
+	cleared := []string{}
+	for _, result := range response.Resources {
+		entries := map[string]sarama.IncrementalAlterConfigsEntry{}
+		for _, entry := range result.Configs {
+			if (entry.Name == leaderThrottledReplicas || entry.Name == followerThrottledReplicas) && entry.Value != "" {
+				entries[entry.Name] = sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationDelete}
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		resource := &sarama.ConfigResource{Type: sarama.TopicResource, Name: result.Name}
+		if err := c.incrementalAlterConfigs(ctx, controller, resource, entries, false); err != nil {
+			return nil, fmt.Errorf("topic %s: %w", result.Name, err)
+		}
+		cleared = append(cleared, result.Name)
+	}
+	sort.Strings(cleared)
+	return cleared, nil
+}
+
+// setReplicationThrottle limits replication of the moved partitions: the current replicas are
+// throttled as leaders and the new ones as followers, and every broker involved gets the rate.
+func (c *Client) setReplicationThrottle(ctx context.Context, moves []models.PartitionMove, current map[string]map[int32][]int32, rate int64) error {
+	leaders := map[string][]string{}
+	followers := map[string][]string{}
+	brokers := map[int32]bool{}
+	for _, move := range moves {
+		replicas := current[move.Topic][move.Partition]
+		for _, id := range replicas {
+			leaders[move.Topic] = append(leaders[move.Topic], fmt.Sprintf("%d:%d", move.Partition, id))
+			brokers[id] = true
+		}
+		for _, id := range move.Replicas {
+			if !containsBroker(replicas, id) {
+				followers[move.Topic] = append(followers[move.Topic], fmt.Sprintf("%d:%d", move.Partition, id))
+			}
+			brokers[id] = true
+		}
+	}
+
+	value := strconv.FormatInt(rate, 10)
+	rates := map[string]sarama.IncrementalAlterConfigsEntry{
+		leaderThrottleRate:   {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &value},
+		followerThrottleRate: {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &value},
+	}
+	for id := range brokers {
+		broker, err := c.client.Broker(id)
+		if err != nil {
+			return err
+		}
+		resource := &sarama.ConfigResource{Type: sarama.BrokerResource, Name: strconv.Itoa(int(id))}
+		if err := c.incrementalAlterConfigs(ctx, broker, resource, rates, false); err != nil {
+			return fmt.Errorf("broker %d: %w", id, err)
+		}
+	}
+
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return err
+	}
+	for topic, replicas := range leaders {
+		leaderValue := strings.Join(replicas, ",")
+		entries := map[string]sarama.IncrementalAlterConfigsEntry{
+			leaderThrottledReplicas: {Operation: sarama.IncrementalAlterConfigsOperationAppend, Value: &leaderValue},
+		}
+		if len(followers[topic]) > 0 {
+			followerValue := strings.Join(followers[topic], ",")
+			entries[followerThrottledReplicas] = sarama.IncrementalAlterConfigsEntry{
+				Operation: sarama.IncrementalAlterConfigsOperationAppend,
+				Value:     &followerValue,
+			}
+		}
+		resource := &sarama.ConfigResource{Type: sarama.TopicResource, Name: topic}
+		if err := c.incrementalAlterConfigs(ctx, controller, resource, entries, false); err != nil {
+			return fmt.Errorf("topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// ongoingReassignments lists the reassignments in progress for the partitions in metadata.
+func (c *Client) ongoingReassignments(ctx context.Context, metadata []*sarama.TopicMetadata) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	request := &sarama.ListPartitionReassignmentsRequest{TimeoutMs: reassignmentTimeoutMs}
+	for _, topic := range metadata {
+		ids := make([]int32, 0, len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			ids = append(ids, partition.ID)
+		}
+		request.AddBlock(topic.Name, ids)
+	}
+	if len(metadata) == 0 {
+		return nil, nil // An empty request would not list anything either
+	}
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return nil, err
+	}
+	response, err := call(ctx, func() (*sarama.ListPartitionReassignmentsResponse, error) {
+		return controller.ListPartitionReassignments(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorCode != sarama.ErrNoError {
+		return nil, kafkaError(response.ErrorCode, response.ErrorMessage)
+	}
+	return response.TopicStatus, nil
+}
+
+// alterReassignments sends an AlterPartitionReassignments request to the controller and returns
+// the result of each partition.
+func (c *Client) alterReassignments(ctx context.Context, request *sarama.AlterPartitionReassignmentsRequest) ([]models.PartitionResult, error) {
+	controller, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return nil, err
+	}
+	response, err := call(ctx, func() (*sarama.AlterPartitionReassignmentsResponse, error) {
+		return controller.AlterPartitionReassignments(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorCode != sarama.ErrNoError {
+		return nil, kafkaError(response.ErrorCode, response.ErrorMessage)
+	}
+	results := []models.PartitionResult{}
+	for topic, partitions := range response.Errors {
+		for partition, block := range partitions {
+			result := models.PartitionResult{Topic: topic, Partition: partition}
+			if err := reassignmentError(block); err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Topic != results[j].Topic {
+			return results[i].Topic < results[j].Topic
+		}
+		return results[i].Partition < results[j].Partition
+	})
+	return results, nil
+}
+
+// errReassignmentResult is reported for partitions whose reassignment result could not be read.
+var errReassignmentResult = errors.New("the result could not be read; check the reassignment status before retrying")
+
+// reassignmentError returns the error of one partition of an AlterPartitionReassignments response.
+// Sarama does not export the fields of these results, so they are read with reflection. If they
+// cannot be found, e.g. after a Sarama upgrade renamed them, the partition is reported as failed
+// rather than as reassigned.
+func reassignmentError(block any) error {
+	value := reflect.ValueOf(block)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errReassignmentResult
+	}
+	value = value.Elem()
+	code := value.FieldByName("errorCode")
+	if !code.IsValid() || code.Kind() != reflect.Int16 {
+		return errReassignmentResult
+	}
+	if code.Int() == 0 {
+		return nil
+	}
+	var message *string
+	if field := value.FieldByName("errorMessage"); field.IsValid() && field.Kind() == reflect.Pointer &&
+		!field.IsNil() && field.Elem().Kind() == reflect.String {
+		text := field.Elem().String()
+		message = &text
+	}
+	return kafkaError(sarama.KError(code.Int()), message)
+}
+
+// kafkaError combines an error code with the broker's message.
+func kafkaError(code sarama.KError, message *string) error {
+	if message != nil && *message != "" {
+		return fmt.Errorf("%w: %s", code, *message)
+	}
+	return code
+}
+
+// replicaLists maps each topic and partition to its replicas.
+func replicaLists(metadata []*sarama.TopicMetadata) map[string]map[int32][]int32 {
+	replicas := make(map[string]map[int32][]int32, len(metadata))
+	for _, topic := range metadata {
+		replicas[topic.Name] = make(map[int32][]int32, len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			replicas[topic.Name][partition.ID] = partition.Replicas
+		}
+	}
+	return replicas
+}
+
+func containsBroker(ids []int32, id int32) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func equalReplicas(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNil(ids []int32) []int32 {
+	if ids == nil {
+		return []int32{}
+	}
+	return ids
+}
@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestPlaceReplicas(t *testing.T) {
+	racks := map[int32]string{1: "a", 2: "a", 3: "b", 4: "b", 5: "c", 6: "c"}
+	tests := []struct {
+		name     string
+		current  []int32
+		targets  []int32
+		racks    map[int32]string
+		load     map[int32]int
+		want     []int32
+		wantLoad map[int32]int
+	}{
+		{
+			name:     "already placed",
+			current:  []int32{2, 3},
+			targets:  []int32{1, 2, 3},
+			load:     map[int32]int{2: 1, 3: 1},
+			want:     []int32{2, 3},
+			wantLoad: map[int32]int{2: 1, 3: 1},
+		},
+		{
+			name:     "drain a broker",
+			current:  []int32{1, 3, 5},
+			targets:  []int32{2, 3, 4, 5},
+			load:     map[int32]int{1: 1, 3: 1, 5: 1},
+			want:     []int32{2, 3, 5},
+			wantLoad: map[int32]int{1: 0, 2: 1, 3: 1, 5: 1},
+		},
+		{
+			name:     "preferred leader moves",
+			current:  []int32{1, 2},
+			targets:  []int32{2, 3},
+			load:     map[int32]int{1: 1, 2: 1},
+			want:     []int32{3, 2},
+			wantLoad: map[int32]int{1: 0, 2: 1, 3: 1},
+		},
+		{
+			name:     "least loaded broker first",
+			current:  []int32{1, 2},
+			targets:  []int32{3, 4},
+			load:     map[int32]int{1: 1, 2: 1, 3: 1},
+			want:     []int32{4, 3},
+			wantLoad: map[int32]int{1: 0, 2: 0, 3: 2, 4: 1},
+		},
+		{
+			name:     "load beats broker ID",
+			current:  []int32{1},
+			targets:  []int32{2, 3},
+			load:     map[int32]int{1: 1, 2: 5, 3: 1},
+			want:     []int32{3},
+			wantLoad: map[int32]int{1: 0, 2: 5, 3: 2},
+		},
+		{
+			name:     "replicas sharing a rack are spread",
+			current:  []int32{3, 4, 1},
+			targets:  []int32{1, 2, 3, 4, 5, 6},
+			racks:    racks,
+			load:     map[int32]int{1: 1, 3: 1, 4: 1},
+			want:     []int32{3, 5, 1},
+			wantLoad: map[int32]int{1: 1, 3: 1, 4: 0, 5: 1},
+		},
+		{
+			name:     "more replicas than racks",
+			current:  []int32{1, 2, 3},
+			targets:  []int32{1, 2, 3, 4},
+			racks:    racks,
+			load:     map[int32]int{1: 1, 2: 1, 3: 1},
+			want:     []int32{1, 2, 3},
+			wantLoad: map[int32]int{1: 1, 2: 1, 3: 1},
+		},
+		{
+			name:     "new replicas avoid used racks",
+			current:  []int32{7, 8},
+			targets:  []int32{1, 2, 3},
+			racks:    map[int32]string{1: "a", 2: "a", 3: "b"},
+			load:     map[int32]int{7: 1, 8: 1},
+			want:     []int32{1, 3},
+			wantLoad: map[int32]int{1: 1, 3: 1, 7: 0, 8: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := placeReplicas(tt.current, tt.targets, tt.racks, tt.load)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("placeReplicas(%v) = %v, want %v", tt.current, got, tt.want)
+			}
+			for id, want := range tt.wantLoad {
+				if tt.load[id] != want {
+					t.Errorf("load of broker %d = %d, want %d", id, tt.load[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestReassignmentError(t *testing.T) {
+	message := "partition is being reassigned"
+	response := &sarama.AlterPartitionReassignmentsResponse{}
+	response.AddError("orders", 0, sarama.ErrNoError, nil)
+	response.AddError("orders", 1, sarama.ErrNoReassignmentInProgress, nil)
+	response.AddError("orders", 2, sarama.ErrReassignmentInProgress, &message)
+
+	tests := []struct {
+		name      string
+		block     any
+		wantErr   error
+		wantMatch string
+	}{
+		{name: "success", block: response.Errors["orders"][0]},
+		{name: "error code", block: response.Errors["orders"][1], wantErr: sarama.ErrNoReassignmentInProgress},
+		{name: "error message", block: response.Errors["orders"][2], wantErr: sarama.ErrReassignmentInProgress, wantMatch: message},
+		// Unreadable results fail closed instead of passing as reassigned
+		{name: "nil", block: nil, wantErr: errReassignmentResult},
+		{name: "not a pointer", block: struct{ errorCode sarama.KError }{}, wantErr: errReassignmentResult},
+		{name: "renamed fields", block: &struct{ code sarama.KError }{}, wantErr: errReassignmentResult},
+		{name: "retyped code", block: &struct{ errorCode string }{"0"}, wantErr: errReassignmentResult},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := reassignmentError(tt.block)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMatch) {
+				t.Errorf("error %q does not contain %q", err, tt.wantMatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// scram.go - Implements the client side of SCRAM authentication (RFC 5802) for Sarama.
+// Channel binding is not supported ("n" GS2 header), matching Kafka. Passwords are used as given,
+// without SASLprep normalization, which is sufficient for ASCII credentials.
+
+// minSCRAMIterations is the lowest iteration count accepted from the server (Kafka's own minimum).
+const minSCRAMIterations = 4096
+
+// scramClient performs one SCRAM exchange. It implements sarama.SCRAMClient.
+type scramClient struct {
+	newHash func() hash.Hash
+
+	username string
+	password string
+	authzID  string
+
+	step            int
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+	done            bool
+}
+
+func newSCRAMClient(newHash func() hash.Hash) *scramClient {
+	return &scramClient{newHash: newHash}
+}
+
+// Begin prepares the exchange for the given credentials.
+func (s *scramClient) Begin(username, password, authzID string) error {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate SCRAM nonce: %w", err)
+	}
+	s.username, s.password, s.authzID = username, password, authzID
+	s.clientNonce = base64.RawStdEncoding.EncodeToString(nonce)
+	s.step = 0
+	s.done = false
+	return nil
+}
+
+// Step returns the response to a server challenge.
+func (s *scramClient) Step(challenge string) (string, error) {
+	s.step++
+	switch s.step {
+	case 1:
+		return s.clientFirst(), nil
+	case 2:
+		return s.clientFinal(challenge)
+	case 3:
+		s.done = true
+		return "", s.verifyServerFinal(challenge)
+	default:
+		return "", errors.New("SCRAM exchange already finished")
+	}
+}
+
+// Done reports whether the exchange is complete.
+func (s *scramClient) Done() bool {
+	return s.done
+}
+
+// gs2Header is the GS2 header without channel binding.
+func (s *scramClient) gs2Header() string {
+	if s.authzID == "" {
+		return "n,,"
+	}
+	return "n,a=" + escapeSCRAMName(s.authzID) + ","
+}
+
+func (s *scramClient) clientFirst() string {
+	s.clientFirstBare = "n=" + escapeSCRAMName(s.username) + ",r=" + s.clientNonce
+	return s.gs2Header() + s.clientFirstBare
+}
+
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	attrs := parseSCRAMAttributes(serverFirst)
+	if msg, ok := attrs["e"]; ok {
+		return "", fmt.Errorf("SCRAM server error: %s", msg)
+	}
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, s.clientNonce) || len(nonce) == len(s.clientNonce) {
+		return "", errors.New("SCRAM server nonce does not extend the client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil || len(salt) == 0 {
+		return "", errors.New("SCRAM server sent an invalid salt")
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < minSCRAMIterations {
+		return "", fmt.Errorf("SCRAM server sent an invalid iteration count %q", attrs["i"])
+	}
+
+	saltedPassword := pbkdf2.Key([]byte(s.password), salt, iterations, s.newHash().Size(), s.newHash)
+	clientKey := s.hmac(saltedPassword, "Client Key")
+	storedKey := s.hash(clientKey)
+	withoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(s.gs2Header())) + ",r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + withoutProof
+
+	clientSignature := s.hmac(storedKey, authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	s.serverSignature = s.hmac(s.hmac(saltedPassword, "Server Key"), authMessage)
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (s *scramClient) verifyServerFinal(serverFinal string) error {
+	attrs := parseSCRAMAttributes(serverFinal)
+	if msg, ok := attrs["e"]; ok {
+		return fmt.Errorf("SCRAM server error: %s", msg)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || subtle.ConstantTimeCompare(signature, s.serverSignature) != 1 {
+		return errors.New("SCRAM server signature does not match")
+	}
+	return nil
+}
+
+func (s *scramClient) hmac(key []byte, message string) []byte {
+	mac := hmac.New(s.newHash, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+func (s *scramClient) hash(data []byte) []byte {
+	h := s.newHash()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// escapeSCRAMName encodes the characters that are special in SCRAM attribute values.
+func escapeSCRAMName(name string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(name)
+}
+
+// parseSCRAMAttributes splits a SCRAM message into its single-letter attributes.
+func parseSCRAMAttributes(message string) map[string]string {
+	attrs := map[string]string{}
+	for _, part := range strings.Split(message, ",") {
+		if len(part) >= 2 && part[1] == '=' {
+			attrs[part[:1]] = part[2:]
+		}
+	}
+	return attrs
+}
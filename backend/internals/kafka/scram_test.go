@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+// RFC 7677 section 3 example exchange for SCRAM-SHA-256 (user "user", password "pencil").
+const (
+	rfc7677ClientNonce = "rOprNGfwEbeRWgbNEkqO"
+	rfc7677ServerFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+	rfc7677ClientFinal = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	rfc7677ServerFinal = "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+)
+
+// newRFC7677Client returns a SCRAM-SHA-256 client that has sent the RFC 7677 client-first message.
+func newRFC7677Client(t *testing.T) *scramClient {
+	t.Helper()
+	s := newSCRAMClient(sha256.New)
+	if err := s.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+	s.clientNonce = rfc7677ClientNonce
+	first, err := s.Step("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "n,,n=user,r="+rfc7677ClientNonce {
+		t.Fatalf("client-first %q", first)
+	}
+	return s
+}
+
+func TestSCRAMRFC7677(t *testing.T) {
+	s := newRFC7677Client(t)
+	final, err := s.Step(rfc7677ServerFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final != rfc7677ClientFinal {
+		t.Errorf("client-final %q, want %q", final, rfc7677ClientFinal)
+	}
+	if s.Done() {
+		t.Error("exchange done before the server-final message")
+	}
+	if _, err := s.Step(rfc7677ServerFinal); err != nil {
+		t.Errorf("server signature rejected: %v", err)
+	}
+	if !s.Done() {
+		t.Error("exchange not done after the server-final message")
+	}
+	if _, err := s.Step(""); err == nil {
+		t.Error("step after the end of the exchange accepted")
+	}
+}
+
+func TestSCRAMRejectsServerMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverFirst string
+		serverFinal string
+		wantErr     string
+	}{
+		{"server error", "e=unknown-user", "", "server error"},
+		{"foreign nonce", strings.Replace(rfc7677ServerFirst, "rOprNGfwEbeRWgbNEkqO", "XXXXXXXXXXXXXXXXXXXX", 1), "", "nonce"},
+		{"unextended nonce", "r=" + rfc7677ClientNonce + ",s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096", "", "nonce"},
+		{"invalid salt", strings.Replace(rfc7677ServerFirst, "s=W22ZaJ0SNY7soEsUEjb6gQ==", "s=!!", 1), "", "salt"},
+		{"too few iterations", strings.Replace(rfc7677ServerFirst, "i=4096", "i=1", 1), "", "iteration"},
+		{"wrong server signature", rfc7677ServerFirst, "v=" + strings.Repeat("A", 43) + "=", "signature"},
+		{"server-final error", rfc7677ServerFirst, "e=invalid-proof", "server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newRFC7677Client(t)
+			_, err := s.Step(tt.serverFirst)
+			if err == nil && tt.serverFinal != "" {
+				_, err = s.Step(tt.serverFinal)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSCRAMEscapesNames(t *testing.T) {
+	s := newSCRAMClient(sha256.New)
+	if err := s.Begin("a=b,c", "pencil", "admin,x"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.Step("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "n,a=admin=2Cx,n=a=3Db=2Cc,r=" + s.clientNonce; first != want {
+		t.Errorf("client-first %q, want %q", first, want)
+	}
+}
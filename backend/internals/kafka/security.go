@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/IBM/sarama"
+)
+
+// security.go - Builds Sarama configurations for clusters secured with SASL and/or TLS.
+// Supported SASL mechanisms are PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 and OAUTHBEARER (with tokens
+// fetched from an OAuth 2.0 token endpoint using the client credentials grant).
+
+// SASL mechanism names accepted in SASLConfig.Mechanism.
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	SASLMechanismSCRAMSHA512 = "SCRAM-SHA-512"
+	SASLMechanismOAuthBearer = "OAUTHBEARER"
+)
+
+// SASLMechanisms lists the supported SASL mechanisms.
+var SASLMechanisms = []string{SASLMechanismPlain, SASLMechanismSCRAMSHA256, SASLMechanismSCRAMSHA512, SASLMechanismOAuthBearer}
+
+// SASLConfig holds SASL credentials.
+type SASLConfig struct {
+	Mechanism    string   // One of SASLMechanisms
+	Username     string   // PLAIN and SCRAM user
+	Password     string   // PLAIN and SCRAM password
+	TokenURL     string   // OAUTHBEARER token endpoint
+	ClientID     string   // OAUTHBEARER client ID
+	ClientSecret string   // OAUTHBEARER client secret
+	Scopes       []string // OAUTHBEARER scopes to request
+}
+
+// TLSConfig holds TLS settings. File paths are read when the client is created.
+type TLSConfig struct {
+	CAFile             string // PEM CA bundle used to verify brokers (system roots if empty)
+	CertFile           string // PEM client certificate for mTLS
+	KeyFile            string // PEM client private key for mTLS
+	InsecureSkipVerify bool   // Skip broker certificate verification (development only)
+}
+
+// SecurityConfig selects how a client authenticates and encrypts its connections.
+// A nil SASL or TLS field disables that layer.
+type SecurityConfig struct {
+	SASL *SASLConfig
+	TLS  *TLSConfig
+}
+
+// NewConfig returns DefaultConfig with the security settings applied.
+func NewConfig(security SecurityConfig) (*sarama.Config, error) {
+	config := DefaultConfig()
+
+	if t := security.TLS; t != nil {
+		tlsConfig, err := newTLSConfig(t)
+		if err != nil {
+			return nil, err
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if s := security.SASL; s != nil {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Handshake = true
+		switch s.Mechanism {
+		case SASLMechanismPlain:
+			config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+			config.Net.SASL.User = s.Username
+			config.Net.SASL.Password = s.Password
+		case SASLMechanismSCRAMSHA256:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			config.Net.SASL.User = s.Username
+			config.Net.SASL.Password = s.Password
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(sha256.New) }
+		case SASLMechanismSCRAMSHA512:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			config.Net.SASL.User = s.Username
+			config.Net.SASL.Password = s.Password
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(sha512.New) }
+		case SASLMechanismOAuthBearer:
+			config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+			config.Net.SASL.TokenProvider = newTokenProvider(s.TokenURL, s.ClientID, s.ClientSecret, s.Scopes)
+		default:
+			return nil, fmt.Errorf("unsupported SASL mechanism %q", s.Mechanism)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid client configuration: %w", err)
+	}
+	return config, nil
+}
+
+// newTLSConfig loads the CA bundle and client key pair.
+func newTLSConfig(t *TLSConfig) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA file contains no PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
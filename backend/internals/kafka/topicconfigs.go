@@ -0,0 +1,281 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// topicconfigs.go - Reads, validates and changes topic configs.
+// Keys and values are checked against the topic configs Kafka defines before anything is sent, so
+// typos are reported with the expected type instead of a broker error. Clusters without
+// IncrementalAlterConfigs (before Kafka 2.3) receive the topic's full set of overrides instead.
+
+// configKind is the value type of a topic config.
+type configKind int
+
+const (
+	kindString configKind = iota
+	kindBoolean
+	kindInt
+	kindLong
+	kindDouble
+	kindEnum // One of values
+	kindList // Comma-separated list, limited to values when set
+)
+
+// configSpec describes the values a topic config accepts.
+type configSpec struct {
+	kind   configKind
+	min    float64  // Smallest number accepted by int, long and double configs
+	max    float64  // Largest number accepted by int, long and double configs
+	values []string // Values accepted by enum and list configs (any value for lists when empty)
+}
+
+func boolean() configSpec { return configSpec{kind: kindBoolean} }
+func text() configSpec    { return configSpec{kind: kindString} }
+func intAtLeast(min float64) configSpec {
+	return configSpec{kind: kindInt, min: min, max: math.MaxInt32}
+}
+func longAtLeast(min float64) configSpec {
+	return configSpec{kind: kindLong, min: min, max: math.MaxInt64}
+}
+func oneOf(values ...string) configSpec  { return configSpec{kind: kindEnum, values: values} }
+func listOf(values ...string) configSpec { return configSpec{kind: kindList, values: values} }
+
+// topicConfigSpecs lists the topic-level configs of Kafka and the values they accept.
+var topicConfigSpecs = map[string]configSpec{
+	"cleanup.policy":                          listOf("compact", "delete"),
+	"compression.type":                        oneOf("uncompressed", "zstd", "lz4", "snappy", "gzip", "producer"),
+	"compression.gzip.level":                  {kind: kindInt, min: -1, max: 9},
+	"compression.lz4.level":                   {kind: kindInt, min: 1, max: 17},
+	"compression.zstd.level":                  {kind: kindInt, min: -131072, max: 22},
+	"delete.retention.ms":                     longAtLeast(0),
+	"file.delete.delay.ms":                    longAtLeast(0),
+	"flush.messages":                          longAtLeast(1),
+	"flush.ms":                                longAtLeast(0),
+	"follower.replication.throttled.replicas": listOf(),
+	"index.interval.bytes":                    intAtLeast(0),
+	"leader.replication.throttled.replicas":   listOf(),
+	"local.retention.bytes":                   longAtLeast(-2),
+	"local.retention.ms":                      longAtLeast(-2),
+	"max.compaction.lag.ms":                   longAtLeast(1),
+	"max.message.bytes":                       intAtLeast(0),
+	"message.downconversion.enable":           boolean(),
+	"message.format.version":                  text(),
+	"message.timestamp.after.max.ms":          longAtLeast(0),
+	"message.timestamp.before.max.ms":         longAtLeast(0),
+	"message.timestamp.difference.max.ms":     longAtLeast(0),
+	"message.timestamp.type":                  oneOf("CreateTime", "LogAppendTime"),
+	"min.cleanable.dirty.ratio":               {kind: kindDouble, min: 0, max: 1},
+	"min.compaction.lag.ms":                   longAtLeast(0),
+	"min.insync.replicas":                     intAtLeast(1),
+	"preallocate":                             boolean(),
+	"remote.log.copy.disable":                 boolean(),
+	"remote.log.delete.on.disable":            boolean(),
+	"remote.storage.enable":                   boolean(),
+	"retention.bytes":                         longAtLeast(-1),
+	"retention.ms":                            longAtLeast(-1),
+	"segment.bytes":                           intAtLeast(14),
+	"segment.index.bytes":                     intAtLeast(4),
+	"segment.jitter.ms":                       longAtLeast(0),
+	"segment.ms":                              longAtLeast(1),
+	"unclean.leader.election.enable":          boolean(),
+}
+
+// ValidateTopicConfig checks that name is a topic config and value has its type.
+func ValidateTopicConfig(name, value string) error {
+	spec, ok := topicConfigSpecs[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown topic config %q", ErrInvalidConfig, name)
+	}
+	return spec.validate(name, value)
+}
+
+func (s configSpec) validate(name, value string) error {
+	switch s.kind {
+	case kindBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %s must be true or false", ErrInvalidConfig, name)
+		}
+	case kindInt, kindLong:
+		bits := 64
+		if s.kind == kindInt {
+			bits = 32
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, bits)
+		if err != nil || float64(n) < s.min || float64(n) > s.max {
+			return fmt.Errorf("%w: %s must be a whole number %s", ErrInvalidConfig, name, s.describeRange())
+		}
+	case kindDouble:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || n < s.min || n > s.max {
+			return fmt.Errorf("%w: %s must be a number %s", ErrInvalidConfig, name, s.describeRange())
+		}
+	case kindEnum:
+		for _, allowed := range s.values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s must be one of %s", ErrInvalidConfig, name, strings.Join(s.values, ", "))
+	case kindList:
+		if len(s.values) == 0 {
+			return nil
+		}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			valid := false
+			for _, allowed := range s.values {
+				valid = valid || item == allowed
+			}
+			if !valid {
+				return fmt.Errorf("%w: %s must be a comma-separated list of %s", ErrInvalidConfig, name, strings.Join(s.values, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func (s configSpec) describeRange() string {
+	switch {
+	case s.max == math.MaxInt32 || s.max == math.MaxInt64:
+		return fmt.Sprintf("of at least %v", s.min)
+	default:
+		return fmt.Sprintf("between %v and %v", s.min, s.max)
+	}
+}
+
+// validateTopicConfigs checks the configs given when creating a topic.
+func validateTopicConfigs(configs map[string]string) error {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report the first invalid config consistently
+	for _, name := range names {
+		if err := ValidateTopicConfig(name, configs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTopicConfig returns every config of a topic. Overrides have the source "topic"; the other
+// configs show the broker or Kafka default the topic inherits.
+func (c *Client) GetTopicConfig(ctx context.Context, topic string) ([]models.ConfigEntry, error) {
+	entries, err := c.describeTopicConfig(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	return toConfigEntries(entries), nil
+}
+
+// AlterTopicConfig applies changes to the config overrides of a topic. Deleting a config resets it
+// to its default. With validateOnly the brokers only check the changes.
+func (c *Client) AlterTopicConfig(ctx context.Context, topic string, changes []models.ConfigChange, validateOnly bool) error {
+	entries, err := toAlterEntries(changes)
+	if err != nil {
+		return err
+	}
+	current, err := c.describeTopicConfig(ctx, topic)
+	if err != nil {
+		return err
+	}
+	described := map[string]bool{}
+	for _, entry := range current {
+		described[entry.Name] = true
+	}
+	for _, change := range changes {
+		spec, known := topicConfigSpecs[change.Name]
+		if !known {
+			// Configs added by newer Kafka versions are accepted as described by the broker
+			if !described[change.Name] {
+				return fmt.Errorf("%w: unknown topic config %q", ErrInvalidConfig, change.Name)
+			}
+			continue
+		}
+		if change.Value != nil && entries[change.Name].Operation != sarama.IncrementalAlterConfigsOperationDelete {
+			if err := spec.validate(change.Name, *change.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	broker, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return err
+	}
+	resource := &sarama.ConfigResource{Type: sarama.TopicResource, Name: topic}
+	if c.capabilities.Features[FeatureIncrementalAlterConfigs] {
+		return c.incrementalAlterConfigs(ctx, broker, resource, entries, validateOnly)
+	}
+	return c.replaceTopicConfig(ctx, broker, resource, current, entries, validateOnly)
+}
+
+// replaceTopicConfig emulates an incremental change on clusters before Kafka 2.3, whose
+// AlterConfigs request replaces all overrides of a topic: the current overrides are sent along
+// with the changed ones. Sensitive overrides cannot be read back, so topics with them are refused.
+func (c *Client) replaceTopicConfig(ctx context.Context, broker *sarama.Broker, resource *sarama.ConfigResource,
+	current []*sarama.ConfigEntry, entries map[string]sarama.IncrementalAlterConfigsEntry, validateOnly bool) error {
+	overrides := map[string]*string{}
+	for _, entry := range current {
+		if entry.Source != sarama.SourceTopic && (entry.Source != sarama.SourceUnknown || entry.Default) {
+			continue
+		}
+		if entry.Sensitive {
+			return &UnsupportedError{Feature: "changing topics with sensitive overrides", KafkaVersion: c.capabilities.KafkaVersion}
+		}
+		value := entry.Value
+		overrides[entry.Name] = &value
+	}
+	for name, entry := range entries {
+		switch entry.Operation {
+		case sarama.IncrementalAlterConfigsOperationSet:
+			overrides[name] = entry.Value
+		case sarama.IncrementalAlterConfigsOperationDelete:
+			delete(overrides, name)
+		default:
+			return &UnsupportedError{Feature: "append and subtract config operations", KafkaVersion: c.capabilities.KafkaVersion}
+		}
+	}
+	request := &sarama.AlterConfigsRequest{
+		Resources: []*sarama.AlterConfigsResource{{
+			Type:          resource.Type,
+			Name:          resource.Name,
+			ConfigEntries: overrides,
+		}},
+		ValidateOnly: validateOnly,
+	}
+	if c.config.Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 1
+	}
+	response, err := call(ctx, func() (*sarama.AlterConfigsResponse, error) {
+		return broker.AlterConfigs(request)
+	})
+	if err != nil {
+		return err
+	}
+	for _, result := range response.Resources {
+		if result.ErrorCode != 0 {
+			return configError(result.ErrorCode, result.ErrorMsg)
+		}
+	}
+	return nil
+}
+
+// describeTopicConfig describes the configs of a topic on any broker.
+func (c *Client) describeTopicConfig(ctx context.Context, topic string) ([]*sarama.ConfigEntry, error) {
+	broker, err := call(ctx, c.client.Controller)
+	if err != nil {
+		return nil, err
+	}
+	return c.describeConfigs(ctx, broker, &sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+}
@@ -0,0 +1,237 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internals/models"
+
+	"github.com/IBM/sarama"
+)
+
+// versions.go - Negotiates the Kafka protocol version and detects optional cluster features.
+// Brokers advertise the request types and versions they accept in an ApiVersions response; the
+// newest release whose APIs are all present is used as the client version, so older brokers are
+// never sent requests they cannot parse.
+
+// Optional features reported in models.Capabilities.
+const (
+	FeatureDeleteRecords           = "deleteRecords"
+	FeatureIncrementalAlterConfigs = "incrementalAlterConfigs"
+	FeatureACLs                    = "acls"
+	FeatureSCRAMCredentials        = "scramCredentials"
+	FeatureQuotas                  = "quotas"
+	FeatureTransactions            = "transactions"
+	FeatureLogDirs                 = "logDirs"
+	FeatureCreatePartitions        = "createPartitions"
+	FeatureReassignments           = "partitionReassignments"
+	FeatureElectLeaders            = "electLeaders"
+)
+
+// Kafka protocol API keys used to detect versions and features.
+const (
+	apiProduce                      int16 = 0
+	apiFetch                        int16 = 1
+	apiApiVersions                  int16 = 18
+	apiCreateTopics                 int16 = 19
+	apiDeleteRecords                int16 = 21
+	apiInitProducerID               int16 = 22
+	apiAddPartitionsToTxn           int16 = 24
+	apiAddOffsetsToTxn              int16 = 25
+	apiEndTxn                       int16 = 26
+	apiTxnOffsetCommit              int16 = 28
+	apiDescribeAcls                 int16 = 29
+	apiCreateAcls                   int16 = 30
+	apiDeleteAcls                   int16 = 31
+	apiSaslAuthenticate             int16 = 36
+	apiDescribeLogDirs              int16 = 35
+	apiCreatePartitions             int16 = 37
+	apiCreateDelegationToken        int16 = 38
+	apiElectLeaders                 int16 = 43
+	apiIncrementalAlterConfigs      int16 = 44
+	apiAlterPartitionReassignments  int16 = 45
+	apiListPartitionReassignments   int16 = 46
+	apiDescribeClientQuotas         int16 = 48
+	apiAlterClientQuotas            int16 = 49
+	apiDescribeUserScramCredentials int16 = 50
+	apiAlterUserScramCredentials    int16 = 51
+	apiDescribeCluster              int16 = 60
+	apiDescribeTransactions         int16 = 65
+	apiGetTelemetrySubscriptions    int16 = 71
+	apiDescribeTopicPartitions      int16 = 75
+)
+
+// featureAPIs lists the APIs every broker must support for a feature to be available.
+var featureAPIs = map[string][]int16{
+	FeatureDeleteRecords:           {apiDeleteRecords},
+	FeatureIncrementalAlterConfigs: {apiIncrementalAlterConfigs},
+	FeatureACLs:                    {apiDescribeAcls, apiCreateAcls, apiDeleteAcls},
+	FeatureSCRAMCredentials:        {apiDescribeUserScramCredentials, apiAlterUserScramCredentials},
+	FeatureQuotas:                  {apiDescribeClientQuotas, apiAlterClientQuotas},
+	FeatureTransactions:            {apiInitProducerID, apiAddPartitionsToTxn, apiAddOffsetsToTxn, apiEndTxn, apiTxnOffsetCommit},
+	FeatureLogDirs:                 {apiDescribeLogDirs},
+	FeatureCreatePartitions:        {apiCreatePartitions},
+	FeatureReassignments:           {apiAlterPartitionReassignments, apiListPartitionReassignments},
+	FeatureElectLeaders:            {apiElectLeaders},
+}
+
+// featureMinVersions lists the API versions some features need beyond the API being present.
+// Sarama only encodes ElectLeaders correctly as version 2, which also adds unclean elections (Kafka 2.4).
+var featureMinVersions = map[string]map[int16]int16{
+	FeatureElectLeaders: {apiElectLeaders: 2},
+}
+
+// versionMarkers identifies releases by an API (at a minimum max version) that first appeared in
+// them, newest first. Kafka 4.0 is recognised by a 3.8+ broker dropping Produce versions below 3 (KIP-896).
+var versionMarkers = []struct {
+	version    sarama.KafkaVersion
+	apiKey     int16
+	maxVersion int16
+}{
+	{sarama.V3_8_0_0, apiDescribeTopicPartitions, 0},
+	{sarama.V3_7_0_0, apiGetTelemetrySubscriptions, 0},
+	{sarama.V3_0_0_0, apiDescribeTransactions, 0},
+	{sarama.V2_8_0_0, apiDescribeCluster, 0},
+	{sarama.V2_7_0_0, apiDescribeUserScramCredentials, 0},
+	{sarama.V2_6_0_0, apiDescribeClientQuotas, 0},
+	{sarama.V2_4_0_0, apiAlterPartitionReassignments, 0},
+	{sarama.V2_3_0_0, apiIncrementalAlterConfigs, 0},
+	{sarama.V2_2_0_0, apiElectLeaders, 0},
+	{sarama.V2_1_0_0, apiProduce, 7},
+	{sarama.V2_0_0_0, apiFetch, 8},
+	{sarama.V1_1_0_0, apiCreateDelegationToken, 0},
+	{sarama.V1_0_0_0, apiSaslAuthenticate, 0},
+	{sarama.V0_11_0_0, apiDeleteRecords, 0},
+	{sarama.V0_10_1_0, apiCreateTopics, 0},
+	{sarama.V0_10_0_0, apiApiVersions, 0},
+}
+
+// probeTimeout bounds each ApiVersions probe made while connecting.
+const probeTimeout = 10 * time.Second
+
+// apiRanges maps API keys to the version range a broker accepts.
+type apiRanges map[int16]sarama.ApiVersionsResponseKey
+
+// detectVersion returns the newest known release whose APIs the broker supports.
+func (a apiRanges) detectVersion() sarama.KafkaVersion {
+	if _, ok := a[apiDescribeTopicPartitions]; ok && a[apiProduce].MinVersion >= 3 {
+		return sarama.V4_0_0_0
+	}
+	for _, marker := range versionMarkers {
+		if api, ok := a[marker.apiKey]; ok && api.MaxVersion >= marker.maxVersion {
+			return marker.version
+		}
+	}
+	return sarama.V0_10_0_0
+}
+
+// supports reports whether the broker accepts every listed API, at least at the given minimum versions.
+func (a apiRanges) supports(apiKeys []int16, minVersions map[int16]int16) bool {
+	for _, key := range apiKeys {
+		api, ok := a[key]
+		if !ok || api.MaxVersion < minVersions[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// negotiate probes the bootstrap brokers that can be reached and returns the highest version
+// supported by all of them and by Sarama, together with the features they all support.
+func negotiate(brokers []string, config *sarama.Config) (sarama.KafkaVersion, models.Capabilities, error) {
+	var probed []apiRanges
+	var errs []error
+	for _, addr := range brokers {
+		ranges, err := probeAPIVersions(addr, config, probeTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+		probed = append(probed, ranges)
+	}
+	if len(probed) == 0 {
+		return sarama.KafkaVersion{}, models.Capabilities{}, fmt.Errorf("failed to probe broker API versions: %w", errors.Join(errs...))
+	}
+
+	version := sarama.MaxVersion
+	for _, ranges := range probed {
+		if detected := ranges.detectVersion(); !detected.IsAtLeast(version) {
+			version = detected
+		}
+	}
+	capabilities := models.Capabilities{KafkaVersion: version.String(), Features: map[string]bool{}}
+	for feature, apiKeys := range featureAPIs {
+		capabilities.Features[feature] = true
+		for _, ranges := range probed {
+			if !ranges.supports(apiKeys, featureMinVersions[feature]) {
+				capabilities.Features[feature] = false
+			}
+		}
+	}
+	return version, capabilities, nil
+}
+
+// probeAPIVersions asks one broker for its supported API versions. The request is sent before
+// SASL authentication, which brokers allow, so credentials are only checked by the real client.
+// The network timeouts of config are capped at timeout.
+func probeAPIVersions(addr string, config *sarama.Config, timeout time.Duration) (apiRanges, error) {
+	probeConfig := sarama.NewConfig()
+	probeConfig.ClientID = config.ClientID
+	probeConfig.Version = sarama.V0_10_0_0 // Keeps Broker.Open from sending its own ApiVersions request
+	probeConfig.Net.TLS = config.Net.TLS
+	probeConfig.Net.Proxy = config.Net.Proxy
+	probeConfig.Net.DialTimeout = shorter(config.Net.DialTimeout, timeout)
+	probeConfig.Net.ReadTimeout = shorter(config.Net.ReadTimeout, timeout)
+	probeConfig.Net.WriteTimeout = shorter(config.Net.WriteTimeout, timeout)
+
+	broker := sarama.NewBroker(addr)
+	if err := broker.Open(probeConfig); err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+
+	response, err := broker.ApiVersions(&sarama.ApiVersionsRequest{Version: 0})
+	if err != nil {
+		return nil, err
+	}
+	if kerr := sarama.KError(response.ErrorCode); kerr != sarama.ErrNoError {
+		return nil, kerr
+	}
+	ranges := apiRanges{}
+	for _, key := range response.ApiKeys {
+		ranges[key.ApiKey] = key
+	}
+	return ranges, nil
+}
+
+// shorter returns the shorter of two durations.
+func shorter(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ErrUnsupported is wrapped by the errors of operations the cluster does not support.
+var ErrUnsupported = errors.New("operation not supported by this cluster")
+
+// UnsupportedError reports a feature missing from the cluster.
+type UnsupportedError struct {
+	Feature      string
+	KafkaVersion string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by this cluster (Kafka protocol %s)", e.Feature, e.KafkaVersion)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupported
+}
+
+// IsUnsupported reports whether err means the cluster does not support an operation, either
+// detected up front or rejected by a broker.
+func IsUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported) || errors.Is(err, sarama.ErrUnsupportedVersion)
+}
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internals/audit"
+	"backend/internals/kafka"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audit.go - Records mutating API operations in the audit log.
+// Audited routes are declared in a table next to the permission table; the middleware captures the
+// request parameters before the handler runs and writes one entry once the response is complete.
+
+// AuditedRoutes maps "METHOD /full/route/path" to the audit action recorded for it.
+var AuditedRoutes = map[string]string{
+	"POST /api/login":             "auth.login",
+	"POST /api/login/mfa":         "auth.login-mfa",
+	"POST /api/login/mfa/enroll":  "mfa.enroll",
+	"GET /api/auth/oidc/callback": "auth.oidc-login",
+	"POST /api/logout":            "auth.logout",
+	"POST /api/change-password":   "auth.change-password",
+
+	"POST /api/clusters":            "cluster.create",
+	"PUT /api/clusters/:cluster":    "cluster.update",
+	"DELETE /api/clusters/:cluster": "cluster.delete",
+
+	"POST /api/clusters/:cluster/topics":                     "topic.create",
+	"DELETE /api/clusters/:cluster/topics/:name":             "topic.delete",
+	"DELETE /api/clusters/:cluster/topics/:name/messages":    "topic.clear",
+	"PUT /api/clusters/:cluster/topics/:name/config":         "topic.config",
+	"POST /api/clusters/:cluster/topics/:name/partitions":    "topic.partitions",
+	"POST /api/clusters/:cluster/produce":                    "message.produce",
+	"PUT /api/clusters/:cluster/brokers/:id/config":          "broker.config",
+	"POST /api/clusters/:cluster/reassignments":              "reassignment.start",
+	"POST /api/clusters/:cluster/reassignments/cancel":       "reassignment.cancel",
+	"DELETE /api/clusters/:cluster/reassignments/throttle":   "reassignment.throttle-clear",
+	"POST /api/clusters/:cluster/leader-elections/preferred": "partition.elect-preferred",
+	"POST /api/clusters/:cluster/leader-elections/unclean":   "partition.elect-unclean",
+
+	"POST /api/users":                          "user.create",
+	"PUT /api/users/:username":                 "user.update",
+	"DELETE /api/users/:username":              "user.delete",
+	"POST /api/users/:username/reset-password": "user.reset-password",
+
+	"POST /api/mfa/enroll":            "mfa.enroll",
+	"POST /api/mfa/activate":          "mfa.enable",
+	"POST /api/mfa/recovery-codes":    "mfa.recovery-codes",
+	"POST /api/mfa/disable":           "mfa.disable",
+	"PUT /api/mfa/policy":             "mfa.policy",
+	"DELETE /api/users/:username/mfa": "user.reset-mfa",
+
+	"POST /api/api-keys":       "api-key.create",
+	"DELETE /api/api-keys/:id": "api-key.delete",
+
+	"DELETE /api/login-lockouts/users/:username": "lockout.clear-user",
+	"DELETE /api/login-lockouts/ips/:ip":         "lockout.clear-ip",
+}
+
+// redactedFields lists request fields whose values are never written to the audit log.
+var redactedFields = map[string]bool{
+	"password":        true,
+	"currentPassword": true,
+	"newPassword":     true,
+	"refreshToken":    true,
+	"clientSecret":    true,
+	"mfaToken":        true,
+	"code":            true,
+	"state":           true,
+}
+
+// routeRedactedFields lists fields redacted on specific routes only, such as message payloads.
+// Elsewhere the same names carry values worth auditing, e.g. config values.
+var routeRedactedFields = map[string]map[string]bool{
+	"POST /api/clusters/:cluster/produce": {"key": true, "value": true, "headers": true},
+}
+
+// configsField is the request field holding config changes, either a list of { "name", "value" }
+// changes or a map of names to values. Only the values of sensitive configs are redacted.
+const configsField = "configs"
+
+// targetFields lists, in order of preference, the route parameters and body fields naming the target.
+var targetFields = []string{"name", "username", "id", "ip", "topic", "cluster"}
+
+// maxAuditBodySize bounds the request body parsed for parameters; larger bodies are not recorded.
+const maxAuditBodySize = 64 * 1024
+
+// AuditMiddleware writes an audit entry for every request to a route in AuditedRoutes.
+// It must run before authentication so denied requests are recorded too.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action, ok := AuditedRoutes[routeKey(c.Request.Method, c.FullPath())]
+		logger := audit.Default()
+		if !ok || logger == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		params := auditParams(c)
+		completed := false
+		// Deferred so requests whose handler panics are recorded as well
+		defer func() {
+			entry := audit.Entry{
+				Time:      time.Now().UTC(),
+				ClientIP:  c.ClientIP(),
+				Action:    action,
+				Method:    c.Request.Method,
+				Route:     c.FullPath(),
+				Cluster:   c.Param("cluster"),
+				Target:    auditTarget(c, params),
+				Params:    redactParams(routeKey(c.Request.Method, c.FullPath()), params),
+				Status:    c.Writer.Status(),
+				Outcome:   audit.OutcomeSuccess,
+				Reason:    c.GetString(audit.ReasonKey),
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if !completed {
+				entry.Status = http.StatusInternalServerError
+			}
+			if entry.Status >= 400 {
+				entry.Outcome = audit.OutcomeFailure
+			}
+			if user, ok := c.Get("user"); ok {
+				entry.User, _ = user.(string)
+			} else if username, ok := params["username"].(string); ok && action == "auth.login" {
+				// Failed logins record the attempted username
+				entry.User = username
+			}
+
+			if err := logger.Record(entry); err != nil {
+				log.Printf("Failed to write audit entry for %s: %v", action, err)
+			}
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+// auditParams collects the query parameters and JSON body fields of a request.
+// The body is restored so handlers can still read it.
+func auditParams(c *gin.Context) map[string]interface{} {
+	params := map[string]interface{}{}
+	for name, values := range c.Request.URL.Query() {
+		if len(values) == 1 {
+			params[name] = values[0]
+		} else {
+			params[name] = values
+		}
+	}
+
+	// Handlers bind JSON regardless of the declared content type, so always try to parse the body
+	if c.Request.Body == nil {
+		return params
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBodySize+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	if err != nil || len(body) > maxAuditBodySize {
+		return params
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) == nil {
+		for name, value := range fields {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// auditTarget returns the topic, user or key an operation applies to.
+func auditTarget(c *gin.Context, params map[string]interface{}) string {
+	for _, field := range targetFields {
+		if value := c.Param(field); value != "" {
+			return value
+		}
+	}
+	for _, field := range targetFields {
+		if value, ok := params[field].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// redactParams replaces sensitive values such as passwords, the message payloads of route and the
+// values of secret configs, including fields of nested objects (e.g. cluster SASL credentials).
+func redactParams(route string, params map[string]interface{}) map[string]interface{} {
+	if configs, ok := params[configsField]; ok {
+		redactConfigs(configs)
+	}
+	redactValue(params, routeRedactedFields[route])
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// redactValue redacts sensitive fields of decoded JSON objects in place, together with the
+// route-specific fields in extra.
+func redactValue(value interface{}, extra map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if redactedFields[name] || extra[name] {
+				v[name] = "[REDACTED]"
+			} else {
+				redactValue(field, extra)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactValue(item, extra)
+		}
+	}
+}
+
+// redactConfigs redacts the values of sensitive configs in a config change list or name to value map.
+func redactConfigs(configs interface{}) {
+	switch v := configs.(type) {
+	case map[string]interface{}:
+		for name := range v {
+			if kafka.IsSensitiveConfig(name) {
+				v[name] = "[REDACTED]"
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			change, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _ := change["name"].(string); kafka.IsSensitiveConfig(name) {
+				if _, ok := change["value"]; ok {
+					change["value"] = "[REDACTED]"
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+		body  string
+		want  string
+	}{
+		{
+			name:  "passwords everywhere",
+			route: "POST /api/users",
+			body:  `{"username":"bob","password":"x","sasl":{"password":"y"}}`,
+			want:  `{"password":"[REDACTED]","sasl":{"password":"[REDACTED]"},"username":"bob"}`,
+		},
+		{
+			name:  "message payloads on produce",
+			route: "POST /api/clusters/:cluster/produce",
+			body:  `{"topic":"orders","key":"k","value":"v","headers":[{"key":"h","value":"x"}]}`,
+			want:  `{"headers":"[REDACTED]","key":"[REDACTED]","topic":"orders","value":"[REDACTED]"}`,
+		},
+		{
+			name:  "config values kept",
+			route: "PUT /api/clusters/:cluster/topics/:name/config",
+			body:  `{"configs":[{"name":"retention.ms","value":"1000"},{"name":"cleanup.policy","operation":"delete"}]}`,
+			want:  `{"configs":[{"name":"retention.ms","value":"1000"},{"name":"cleanup.policy","operation":"delete"}]}`,
+		},
+		{
+			name:  "sensitive config values redacted",
+			route: "PUT /api/clusters/:cluster/brokers/:id/config",
+			body:  `{"configs":[{"name":"listener.name.sasl_ssl.ssl.keystore.password","value":"s"},{"name":"log.cleaner.threads","value":"2"}]}`,
+			want:  `{"configs":[{"name":"listener.name.sasl_ssl.ssl.keystore.password","value":"[REDACTED]"},{"name":"log.cleaner.threads","value":"2"}]}`,
+		},
+		{
+			name:  "topic creation config map",
+			route: "POST /api/clusters/:cluster/topics",
+			body:  `{"name":"orders","configs":{"retention.ms":"1000","sasl.jaas.config":"secret"}}`,
+			want:  `{"configs":{"retention.ms":"1000","sasl.jaas.config":"[REDACTED]"},"name":"orders"}`,
+		},
+		{
+			name:  "mfa codes",
+			route: "POST /api/login/mfa",
+			body:  `{"mfaToken":"t","code":"123456"}`,
+			want:  `{"code":"[REDACTED]","mfaToken":"[REDACTED]"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.body), &params); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(redactParams(tt.route, params))
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
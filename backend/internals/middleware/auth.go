@@ -1,25 +1,38 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
-	"time"
 
-	"backend/internals/utils"
+	"backend/internals/auth"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // auth.go - Provides JWT authentication middleware for protecting API routes.
-// Validates JWT tokens, extracts user claims, and attaches them to the request context.
+// Validates JWT tokens, rejects revoked ones, extracts user claims, and attaches them to the request context.
+// Personal API keys are accepted in place of a JWT.
 
-// JWTMiddleware validates JWT tokens and adds claims to the request context.
+// APIKeyHeader is the request header carrying a personal API key.
+const APIKeyHeader = "X-API-Key"
+
+// JWTMiddleware validates JWT tokens (or API keys) and adds claims to the request context.
 // Use this middleware to protect routes that require authentication.
 func JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+
+		// Personal API keys are accepted in X-API-Key or as a Bearer credential
+		if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+		if bearer := strings.TrimPrefix(authHeader, "Bearer "); auth.IsAPIKey(bearer) {
+			authenticateAPIKey(c, bearer)
+			return
+		}
+
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or malformed token"})
 			return
@@ -27,42 +40,71 @@ func JWTMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Ensure the signing method is HMAC
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return getJWTSecret(), nil
-		})
-
-		if err != nil || !token.Valid {
+		// Parse and validate the token, consulting the revocation list
+		claims, err := auth.ParseAccessToken(tokenString)
+		switch {
+		case errors.Is(err, auth.ErrTokenExpired):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+			return
+		case errors.Is(err, auth.ErrTokenRevoked):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+			return
+		case errors.Is(err, auth.ErrInvalidToken):
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		// Extract claims and attach user info to context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() > int64(exp) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-				return
-			}
-			// Store user info (e.g., username or user ID) in context for downstream handlers
-			c.Set("user", claims["sub"])
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
+		// Store user info (e.g., username or user ID), role and token ID in context for downstream handlers
+		c.Set("user", claims["sub"])
+		c.Set("role", claims["role"])
+		c.Set("groups", claimStrings(claims["groups"]))
+		c.Set("source", claims["src"])
+		c.Set("tokenID", claims["jti"])
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("tokenExpiry", exp.Time)
 		}
 
 		c.Next()
 	}
 }
 
-// Use env var for JWT secret, fallback to constant
-func getJWTSecret() []byte {
-	secret := os.Getenv(utils.JWTSecretKeyEnv)
-	if secret == "" {
-		secret = utils.DefaultJWTSecret
+// authenticateAPIKey validates a personal API key and adds its owner and scopes to the request context.
+func authenticateAPIKey(c *gin.Context, apiKey string) {
+	identity, scopes, err := auth.AuthenticateAPIKey(apiKey)
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyExpired):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key expired"})
+		return
+	case errors.Is(err, auth.ErrInvalidAPIKey):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	case err != nil:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set("user", identity.Username)
+	c.Set("role", identity.Role)
+	c.Set("groups", identity.Groups)
+	c.Set("source", identity.Source)
+	c.Set("scopes", scopes)
+	c.Next()
+}
+
+// claimStrings converts a JSON array claim into a string slice, ignoring non-string entries.
+func claimStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
 	}
-	return []byte(secret)
+	return result
 }
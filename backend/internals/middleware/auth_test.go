@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internals/auth"
+	"backend/internals/store"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, utils.UsersFileName)
+	users := "username,password,role,disabled\nalice,alice-pass,operator,false\ncarol,carol-pass,admin,true\n"
+	if err := os.WriteFile(path, []byte(users), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store.Initialize(&store.Backend{Users: store.NewCSVUserStore(path), Documents: store.NewFileDocumentStore(dir)})
+
+	create := func(username, role string, scopes []string, ttl time.Duration) string {
+		t.Helper()
+		_, raw, err := auth.CreateAPIKey(&auth.Identity{Username: username, Role: role, Source: auth.CSVBackendName}, "test", scopes, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	readOnly := create("alice", utils.RoleOperator, []string{string(PermRead)}, 0)
+	producer := create("alice", utils.RoleOperator, []string{string(PermRead), string(PermProduce)}, 0)
+	clusterAdmin := create("alice", utils.RoleOperator, []string{string(PermClusterAdmin)}, 0)
+	expired := create("alice", utils.RoleOperator, []string{string(PermRead)}, time.Nanosecond)
+	disabled := create("carol", utils.RoleAdmin, []string{string(PermRead)}, 0)
+	time.Sleep(time.Millisecond)
+
+	r := gin.New()
+	r.Use(JWTMiddleware(), PermissionMiddleware())
+	for _, route := range []string{"GET /api/clusters", "POST /api/clusters/:cluster/produce", "POST /api/clusters"} {
+		if _, ok := RoutePermissions[route]; !ok {
+			t.Fatalf("route %s is not in RoutePermissions", route)
+		}
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/clusters", ok)
+	r.POST("/api/clusters/:cluster/produce", ok)
+	r.POST("/api/clusters", ok)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		want   int
+	}{
+		{"read scope on a read route", readOnly, http.MethodGet, "/api/clusters", http.StatusOK},
+		{"read scope on a produce route", readOnly, http.MethodPost, "/api/clusters/x/produce", http.StatusForbidden},
+		{"produce scope on a produce route", producer, http.MethodPost, "/api/clusters/x/produce", http.StatusOK},
+		{"scope beyond the owner's role", clusterAdmin, http.MethodPost, "/api/clusters", http.StatusForbidden},
+		{"expired key", expired, http.MethodGet, "/api/clusters", http.StatusUnauthorized},
+		{"disabled owner", disabled, http.MethodGet, "/api/clusters", http.StatusForbidden},
+		{"unknown key", auth.APIKeyPrefix + "unknown.secret", http.MethodGet, "/api/clusters", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(APIKeyHeader, tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"backend/internals/cluster"
+	"backend/internals/kafka"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cluster.go - Resolves the :cluster route parameter to a leased client of that cluster.
+// Each request carries its own cluster, so users working with different clusters never affect each other.
+
+// KafkaServiceKey is the gin context key holding the client of the requested cluster.
+// The client is only valid until the request completes.
+const KafkaServiceKey = "kafkaService"
+
+// ClusterKey is the gin context key holding the name of the requested cluster.
+const ClusterKey = "cluster"
+
+// ClusterMiddleware leases the client of the cluster named by the :cluster parameter from registry
+// for the duration of the request. Unknown clusters receive 404 Not Found and unreachable ones 502 Bad Gateway.
+// Connecting counts against the deadline set by TimeoutMiddleware, which must run first: a cluster still
+// connecting when it passes receives 504 Gateway Timeout.
+// Must be used after PermissionMiddleware so unauthorized requests never open connections.
+func ClusterMiddleware(registry *cluster.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("cluster")
+		lease, err := registry.Acquire(c.Request.Context(), name)
+		switch {
+		case errors.Is(err, cluster.ErrClusterNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Cluster not found", "cluster": name})
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Timed out connecting to the cluster", "cluster": name})
+			return
+		case errors.Is(err, context.Canceled):
+			c.AbortWithStatusJSON(StatusClientClosedRequest, gin.H{"error": "Request canceled"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": err.Error(), "cluster": name})
+			return
+		}
+		defer lease.Release()
+		c.Set(ClusterKey, name)
+		c.Set(KafkaServiceKey, lease.Client())
+		c.Next()
+	}
+}
+
+// KafkaService returns the client set by ClusterMiddleware.
+func KafkaService(c *gin.Context) kafka.KafkaService {
+	return c.MustGet(KafkaServiceKey).(kafka.KafkaService)
+}
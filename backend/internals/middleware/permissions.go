@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"backend/internals/policy"
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// permissions.go - Provides role-based authorization for API routes.
+// Every protected route is mapped to the permission it requires in a declarative table,
+// and each role is granted a fixed set of permissions.
+
+// Permission is a capability required to call an API route.
+type Permission string
+
+const (
+	PermAuthenticated Permission = "authenticated" // Any logged-in user (e.g. changing their own password)
+	PermRead          Permission = "read"          // Browse topics, messages, brokers and consumers
+	PermProduce       Permission = "produce"       // Produce messages
+	PermTopicAdmin    Permission = "topic-admin"   // Create and delete topics, clear messages
+	PermClusterAdmin  Permission = "cluster-admin" // User management and cluster-wide administration
+)
+
+// rolePermissions lists the permissions granted to each role.
+var rolePermissions = map[string][]Permission{
+	utils.RoleViewer:   {PermAuthenticated, PermRead},
+	utils.RoleProducer: {PermAuthenticated, PermRead, PermProduce},
+	utils.RoleOperator: {PermAuthenticated, PermRead, PermProduce, PermTopicAdmin},
+	utils.RoleAdmin:    {PermAuthenticated, PermRead, PermProduce, PermTopicAdmin, PermClusterAdmin},
+}
+
+// PublicRoutes lists the routes that are reachable without authentication.
+var PublicRoutes = map[string]bool{
+	"POST /api/login":             true,
+	"POST /api/login/mfa":         true,
+	"POST /api/login/mfa/enroll":  true,
+	"POST /api/token/refresh":     true,
+	"GET /api/auth/providers":     true,
+	"GET /api/auth/oidc/login":    true,
+	"GET /api/auth/oidc/callback": true,
+}
+
+// RoutePermissions maps "METHOD /full/route/path" to the permission required to call it.
+// Routes missing from this table are denied.
+var RoutePermissions = map[string]Permission{
+	"GET /api/clusters":             PermRead,
+	"GET /api/clusters/:cluster":    PermRead,
+	"POST /api/clusters":            PermClusterAdmin,
+	"PUT /api/clusters/:cluster":    PermClusterAdmin,
+	"DELETE /api/clusters/:cluster": PermClusterAdmin,
+
+	"GET /api/clusters/:cluster/check-connection":        PermRead,
+	"GET /api/clusters/:cluster/capabilities":            PermRead,
+	"GET /api/clusters/:cluster/overview":                PermRead,
+	"GET /api/clusters/:cluster/brokers/history":         PermRead,
+	"GET /api/clusters/:cluster/topics":                  PermRead,
+	"GET /api/clusters/:cluster/topics/:name/messages":   PermRead,
+	"GET /api/clusters/:cluster/topics/:name/partitions": PermRead,
+	"GET /api/clusters/:cluster/topics/:name/config":     PermRead,
+	"GET /api/clusters/:cluster/consumers":               PermRead,
+	"GET /api/clusters/:cluster/brokers":                 PermRead,
+	"GET /api/clusters/:cluster/brokers/:id/config":      PermRead,
+	"GET /api/clusters/:cluster/log-dirs":                PermRead,
+	"GET /api/clusters/:cluster/largest-topics":          PermRead,
+	"GET /api/clusters/:cluster/reassignments":           PermRead,
+	"POST /api/clusters/:cluster/reassignments/plan":     PermRead,
+
+	"POST /api/clusters/:cluster/produce": PermProduce,
+
+	"PUT /api/clusters/:cluster/brokers/:id/config":        PermClusterAdmin,
+	"POST /api/clusters/:cluster/reassignments":            PermClusterAdmin,
+	"POST /api/clusters/:cluster/reassignments/cancel":     PermClusterAdmin,
+	"DELETE /api/clusters/:cluster/reassignments/throttle": PermClusterAdmin,
+	"POST /api/clusters/:cluster/leader-elections/unclean": PermClusterAdmin,
+
+	"POST /api/clusters/:cluster/topics":                     PermTopicAdmin,
+	"DELETE /api/clusters/:cluster/topics/:name":             PermTopicAdmin,
+	"DELETE /api/clusters/:cluster/topics/:name/messages":    PermTopicAdmin,
+	"PUT /api/clusters/:cluster/topics/:name/config":         PermTopicAdmin,
+	"POST /api/clusters/:cluster/topics/:name/partitions":    PermTopicAdmin,
+	"POST /api/clusters/:cluster/leader-elections/preferred": PermTopicAdmin,
+
+	"POST /api/change-password": PermAuthenticated,
+	"POST /api/logout":          PermAuthenticated,
+
+	"GET /api/api-keys":        PermAuthenticated,
+	"POST /api/api-keys":       PermAuthenticated,
+	"DELETE /api/api-keys/:id": PermAuthenticated,
+
+	"GET /api/users":                           PermClusterAdmin,
+	"POST /api/users":                          PermClusterAdmin,
+	"PUT /api/users/:username":                 PermClusterAdmin,
+	"DELETE /api/users/:username":              PermClusterAdmin,
+	"POST /api/users/:username/reset-password": PermClusterAdmin,
+
+	"GET /api/mfa":                 PermAuthenticated,
+	"POST /api/mfa/enroll":         PermAuthenticated,
+	"POST /api/mfa/activate":       PermAuthenticated,
+	"POST /api/mfa/recovery-codes": PermAuthenticated,
+	"POST /api/mfa/disable":        PermAuthenticated,
+
+	"GET /api/mfa/policy":             PermClusterAdmin,
+	"PUT /api/mfa/policy":             PermClusterAdmin,
+	"DELETE /api/users/:username/mfa": PermClusterAdmin,
+
+	"GET /api/audit": PermClusterAdmin,
+
+	"GET /api/login-lockouts":                    PermClusterAdmin,
+	"DELETE /api/login-lockouts/users/:username": PermClusterAdmin,
+	"DELETE /api/login-lockouts/ips/:ip":         PermClusterAdmin,
+
+	"GET /api/connections": PermClusterAdmin,
+}
+
+// RouteTopicActions maps routes with a :name topic parameter to the policy action they perform.
+var RouteTopicActions = map[string]policy.Action{
+	"GET /api/clusters/:cluster/topics/:name/messages":    policy.ActionRead,
+	"GET /api/clusters/:cluster/topics/:name/partitions":  policy.ActionRead,
+	"GET /api/clusters/:cluster/topics/:name/config":      policy.ActionRead,
+	"DELETE /api/clusters/:cluster/topics/:name":          policy.ActionAdmin,
+	"DELETE /api/clusters/:cluster/topics/:name/messages": policy.ActionAdmin,
+	"PUT /api/clusters/:cluster/topics/:name/config":      policy.ActionAdmin,
+	"POST /api/clusters/:cluster/topics/:name/partitions": policy.ActionAdmin,
+}
+
+// HasPermission reports whether role is granted permission.
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// hasScope reports whether an API key with the given scopes may use permission.
+// Routes open to any user (PermAuthenticated) manage the account itself and are never reachable with a key.
+func hasScope(scopes []string, permission Permission) bool {
+	for _, scope := range scopes {
+		if Permission(scope) == permission && permission != PermAuthenticated {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyScopes lists the permissions that can be granted to an API key.
+var APIKeyScopes = []Permission{PermRead, PermProduce, PermTopicAdmin, PermClusterAdmin}
+
+// IsAPIKeyScope reports whether scope can be granted to an API key.
+func IsAPIKeyScope(scope string) bool {
+	for _, p := range APIKeyScopes {
+		if Permission(scope) == p {
+			return true
+		}
+	}
+	return false
+}
+
+// routeKey builds the RoutePermissions key for a method and route path.
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// PermissionMiddleware enforces RoutePermissions using the role set by JWTMiddleware.
+// Denied requests receive 403 Forbidden with a consistent body naming the required permission.
+func PermissionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permission, ok := RoutePermissions[routeKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			abortForbidden(c, "")
+			return
+		}
+		role, _ := c.Get("role")
+		roleName, _ := role.(string)
+		if !HasPermission(roleName, permission) {
+			abortForbidden(c, permission)
+			return
+		}
+		// API keys are further limited to their scopes
+		if scopes, ok := c.Get("scopes"); ok && !hasScope(scopes.([]string), permission) {
+			abortForbidden(c, permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+// TopicPolicyMiddleware enforces per-topic policies on routes with a :name topic parameter.
+// Must be used after JWTMiddleware.
+func TopicPolicyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action, ok := RouteTopicActions[routeKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			c.Next()
+			return
+		}
+		topic := c.Param("name")
+		if !policy.Allowed(policy.SubjectFromContext(c), topic, action) {
+			AbortTopicForbidden(c, topic, action)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortTopicForbidden aborts the request with the standard 403 response body for a topic policy denial.
+func AbortTopicForbidden(c *gin.Context, topic string, action policy.Action) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":      "Insufficient permissions",
+		"permission": action,
+		"topic":      topic,
+	})
+}
+
+// abortForbidden aborts the request with the standard 403 response body.
+func abortForbidden(c *gin.Context, required Permission) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":      "Insufficient permissions",
+		"permission": required,
+	})
+}
+
+// ValidateRoutePermissions checks that every registered /api route is either public
+// or has an entry in RoutePermissions, so new routes cannot ship without a permission.
+func ValidateRoutePermissions(routes gin.RoutesInfo) error {
+	var missing []string
+	for _, r := range routes {
+		key := routeKey(r.Method, r.Path)
+		if !strings.HasPrefix(r.Path, "/api/") || PublicRoutes[key] {
+			continue
+		}
+		if _, ok := RoutePermissions[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without a permission: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
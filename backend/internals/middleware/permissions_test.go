@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// grantedRoles lists the roles expected to hold each permission, independently of rolePermissions.
+var grantedRoles = map[Permission][]string{
+	PermAuthenticated: {utils.RoleViewer, utils.RoleProducer, utils.RoleOperator, utils.RoleAdmin},
+	PermRead:          {utils.RoleViewer, utils.RoleProducer, utils.RoleOperator, utils.RoleAdmin},
+	PermProduce:       {utils.RoleProducer, utils.RoleOperator, utils.RoleAdmin},
+	PermTopicAdmin:    {utils.RoleOperator, utils.RoleAdmin},
+	PermClusterAdmin:  {utils.RoleAdmin},
+}
+
+var allRoles = []string{utils.RoleViewer, utils.RoleProducer, utils.RoleOperator, utils.RoleAdmin, "", "unknown"}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newPermissionRouter registers routes behind PermissionMiddleware. The role and API key scopes
+// normally set by JWTMiddleware are taken from the X-Role and X-Scopes request headers.
+func newPermissionRouter(routes []string) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", c.GetHeader("X-Role"))
+		if scopes := c.GetHeader("X-Scopes"); scopes != "" {
+			c.Set("scopes", strings.Split(scopes, ","))
+		}
+	})
+	r.Use(PermissionMiddleware())
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		r.Handle(method, path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	return r
+}
+
+// requestPath fills the parameters of a route path with placeholder values.
+func requestPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "x"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func serve(r *gin.Engine, method, path, role, scopes string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Role", role)
+	if scopes != "" {
+		req.Header.Set("X-Scopes", scopes)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRoutePermissionsPerRole(t *testing.T) {
+	routes := make([]string, 0, len(RoutePermissions))
+	for route := range RoutePermissions {
+		routes = append(routes, route)
+	}
+	r := newPermissionRouter(routes)
+
+	for route, permission := range RoutePermissions {
+		granted, ok := grantedRoles[permission]
+		if !ok {
+			t.Errorf("%s requires unknown permission %q", route, permission)
+			continue
+		}
+		method, path, _ := strings.Cut(route, " ")
+		for _, role := range allRoles {
+			want := http.StatusForbidden
+			if contains(granted, role) {
+				want = http.StatusOK
+			}
+			if w := serve(r, method, requestPath(path), role, ""); w.Code != want {
+				t.Errorf("%s as %q: status %d, want %d", route, role, w.Code, want)
+			}
+		}
+	}
+}
+
+func TestRoutePermissionsAPIKeyScopes(t *testing.T) {
+	r := newPermissionRouter([]string{
+		"GET /api/clusters/:cluster/topics",
+		"POST /api/clusters/:cluster/produce",
+		"POST /api/change-password",
+	})
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		scopes string
+		want   int
+	}{
+		{"scope granted", http.MethodGet, "/api/clusters/x/topics", "read", http.StatusOK},
+		{"scope missing", http.MethodPost, "/api/clusters/x/produce", "read", http.StatusForbidden},
+		{"one of several scopes", http.MethodPost, "/api/clusters/x/produce", "read,produce", http.StatusOK},
+		{"account routes need a session", http.MethodPost, "/api/change-password", "authenticated", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(r, tt.method, tt.path, utils.RoleAdmin, tt.scopes); w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownRouteDenied(t *testing.T) {
+	r := newPermissionRouter([]string{"GET /api/unmapped", "DELETE /api/clusters/:cluster/topics"})
+	for _, route := range []string{"GET /api/unmapped", "DELETE /api/clusters/:cluster/topics"} {
+		method, path, _ := strings.Cut(route, " ")
+		w := serve(r, method, requestPath(path), utils.RoleAdmin, "")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want %d", route, w.Code, http.StatusForbidden)
+		}
+		if !strings.Contains(w.Body.String(), "Insufficient permissions") {
+			t.Errorf("%s: body %s", route, w.Body.String())
+		}
+	}
+}
+
+func TestRoleTablesAgree(t *testing.T) {
+	for permission, granted := range grantedRoles {
+		for _, role := range allRoles {
+			if got, want := HasPermission(role, permission), contains(granted, role); got != want {
+				t.Errorf("HasPermission(%q, %q) = %v, want %v", role, permission, got, want)
+			}
+		}
+	}
+	for route := range RouteTopicActions {
+		if _, ok := RoutePermissions[route]; !ok {
+			t.Errorf("topic policy route %s has no permission", route)
+		}
+	}
+	for route := range AuditedRoutes {
+		if _, ok := RoutePermissions[route]; !ok && !PublicRoutes[route] {
+			t.Errorf("audited route %s is neither public nor in RoutePermissions", route)
+		}
+	}
+}
+
+func TestValidateRoutePermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		routes  gin.RoutesInfo
+		missing string
+	}{
+		{"mapped", gin.RoutesInfo{{Method: "GET", Path: "/api/clusters"}}, ""},
+		{"public", gin.RoutesInfo{{Method: "POST", Path: "/api/login"}}, ""},
+		{"outside /api", gin.RoutesInfo{{Method: "GET", Path: "/healthz"}}, ""},
+		{"unmapped", gin.RoutesInfo{{Method: "GET", Path: "/api/clusters"}, {Method: "PATCH", Path: "/api/clusters"}}, "PATCH /api/clusters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRoutePermissions(tt.routes)
+			switch {
+			case tt.missing == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.missing != "" && (err == nil || !strings.Contains(err.Error(), tt.missing)):
+				t.Errorf("error %v, want it to name %s", err, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeout.go - Bounds Kafka requests with per-route deadlines.
+// Handlers pass the request context to the Kafka client, so an operation stops when its deadline
+// passes or the client disconnects. KAFKA_TIMEOUT and KAFKA_ROUTE_TIMEOUTS override the defaults.
+
+// StatusClientClosedRequest is the de facto status for requests abandoned by the client.
+const StatusClientClosedRequest = 499
+
+// DefaultKafkaTimeout applies to Kafka routes without an entry in RouteTimeouts.
+const DefaultKafkaTimeout = 30 * time.Second
+
+// RouteTimeouts maps "METHOD /full/route/path" to the default deadline of routes that need
+// a different one than DefaultKafkaTimeout.
+var RouteTimeouts = map[string]time.Duration{
+	"GET /api/clusters/:cluster/check-connection":         10 * time.Second,
+	"GET /api/clusters/:cluster/topics/:name/messages":    15 * time.Second,
+	"DELETE /api/clusters/:cluster/topics/:name/messages": 60 * time.Second,
+}
+
+// TimeoutConfig holds the deadlines applied by TimeoutMiddleware.
+type TimeoutConfig struct {
+	Default time.Duration            // Deadline of routes without an entry in Routes
+	Routes  map[string]time.Duration // Deadlines keyed like RouteTimeouts
+}
+
+// TimeoutConfigFromEnv returns RouteTimeouts and DefaultKafkaTimeout overridden by KAFKA_TIMEOUT
+// (e.g. "45s") and KAFKA_ROUTE_TIMEOUTS, a comma-separated list of route=duration pairs such as
+// "GET /api/clusters/:cluster/topics/:name/messages=1m".
+func TimeoutConfigFromEnv() (TimeoutConfig, error) {
+	config := TimeoutConfig{Default: DefaultKafkaTimeout, Routes: map[string]time.Duration{}}
+	for route, timeout := range RouteTimeouts {
+		config.Routes[route] = timeout
+	}
+
+	if value := os.Getenv(utils.KafkaTimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return TimeoutConfig{}, fmt.Errorf("invalid %s: %q", utils.KafkaTimeoutEnv, value)
+		}
+		config.Default = timeout
+	}
+
+	for _, pair := range strings.Split(os.Getenv(utils.KafkaRouteTimeoutsEnv), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		separator := strings.LastIndex(pair, "=")
+		if separator < 0 {
+			return TimeoutConfig{}, fmt.Errorf("invalid %s entry %q: expected route=duration", utils.KafkaRouteTimeoutsEnv, pair)
+		}
+		route := strings.Join(strings.Fields(pair[:separator]), " ")
+		if _, ok := RoutePermissions[route]; !ok {
+			return TimeoutConfig{}, fmt.Errorf("invalid %s entry %q: unknown route %q", utils.KafkaRouteTimeoutsEnv, pair, route)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(pair[separator+1:]))
+		if err != nil || timeout <= 0 {
+			return TimeoutConfig{}, fmt.Errorf("invalid %s entry %q: bad duration", utils.KafkaRouteTimeoutsEnv, pair)
+		}
+		config.Routes[route] = timeout
+	}
+	return config, nil
+}
+
+// For returns the deadline of a route.
+func (t TimeoutConfig) For(method, path string) time.Duration {
+	if timeout, ok := t.Routes[routeKey(method, path)]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// TimeoutMiddleware replaces the request context with one that ends after the route's deadline.
+// Handlers report an expired deadline as 504 Gateway Timeout.
+func TimeoutMiddleware(config TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), config.For(c.Request.Method, c.FullPath()))
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -1,13 +1,42 @@
 package models
 
+import "time"
+
+// Broker liveness states reported in Broker.Status.
+const (
+	BrokerOnline      = "online"      // Answered the liveness probe promptly and keeps its replicas in sync
+	BrokerDegraded    = "degraded"    // Answered, but slowly or with replicas out of sync
+	BrokerUnreachable = "unreachable" // Did not answer the liveness probe
+)
+
 // Broker represents a Kafka broker and its metadata.
 type Broker struct {
-	ID           int32  `json:"id"`           // Broker ID
-	Host         string `json:"host"`         // Hostname
-	Port         int32  `json:"port"`         // Port number
-	Address      string `json:"address"`      // Full address
-	Status       string `json:"status"`       // Broker status
-	SegmentCount int    `json:"segmentCount"` // Number of log segments
-	Replicas     []int  `json:"replicas"`     // Replica partitions
-	Leaders      []int  `json:"leaders"`      // Leader partitions
+	ID           int32      `json:"id"`                     // Broker ID
+	Host         string     `json:"host"`                   // Hostname
+	Port         int32      `json:"port"`                   // Port number
+	Address      string     `json:"address"`                // Full address
+	Status       string     `json:"status"`                 // online, degraded or unreachable
+	StatusDetail string     `json:"statusDetail,omitempty"` // Why the broker is degraded or unreachable
+	LatencyMs    int64      `json:"latencyMs"`              // Duration of the liveness probe in milliseconds
+	CheckedAt    time.Time  `json:"checkedAt"`              // When the liveness probe ran
+	LastSeen     *time.Time `json:"lastSeen"`               // Last time the broker answered a probe (null if never seen)
+	SegmentCount int        `json:"segmentCount"`           // Number of partitions the broker leads (see GetLogDirs for disk usage)
+	Replicas     []int      `json:"replicas"`               // Replica partitions
+	Leaders      []int      `json:"leaders"`                // Leader partitions
+}
+
+// BrokerStatusSample is one liveness probe result in a broker's status history.
+type BrokerStatusSample struct {
+	Time      time.Time `json:"time"`             // When the probe ran
+	Status    string    `json:"status"`           // online, degraded or unreachable
+	Detail    string    `json:"detail,omitempty"` // Why the broker was degraded or unreachable
+	LatencyMs int64     `json:"latencyMs"`        // Duration of the probe in milliseconds
+}
+
+// BrokerHistory is the recent status history of one broker, oldest sample first.
+type BrokerHistory struct {
+	ID       int32                `json:"id"`       // Broker ID
+	Address  string               `json:"address"`  // Last known address
+	LastSeen *time.Time           `json:"lastSeen"` // Last time the broker answered a probe (null if never seen)
+	Samples  []BrokerStatusSample `json:"samples"`  // Probe results, oldest first
 }
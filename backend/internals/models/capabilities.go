@@ -0,0 +1,7 @@
+package models
+
+// Capabilities describes the Kafka version and optional features supported by a cluster.
+type Capabilities struct {
+	KafkaVersion string          `json:"kafkaVersion"` // Protocol version negotiated with the brokers (e.g. "3.6.0")
+	Features     map[string]bool `json:"features"`     // Optional features and whether every probed broker supports them
+}
@@ -0,0 +1,34 @@
+package models
+
+// ClusterOverview summarizes a Kafka cluster: identity, brokers and partition health.
+type ClusterOverview struct {
+	ClusterID                 string             `json:"clusterId"`                 // Cluster ID reported by the brokers (empty before Kafka 0.10.1)
+	ControllerID              int32              `json:"controllerId"`              // Broker ID of the active controller (-1 if unknown)
+	KafkaVersion              string             `json:"kafkaVersion"`              // Negotiated protocol version
+	BrokerCount               int                `json:"brokerCount"`               // Number of live brokers in the metadata
+	Brokers                   []BrokerSummary    `json:"brokers"`                   // Live brokers sorted by ID
+	Racks                     map[string][]int32 `json:"racks"`                     // Rack name to broker IDs (brokers without a rack are omitted)
+	TopicCount                int                `json:"topicCount"`                // Number of topics, including internal ones
+	InternalTopicCount        int                `json:"internalTopicCount"`        // Number of internal topics (e.g. __consumer_offsets)
+	PartitionCount            int                `json:"partitionCount"`            // Total number of partitions
+	ReplicaCount              int                `json:"replicaCount"`              // Total number of partition replicas
+	UnderReplicatedPartitions int                `json:"underReplicatedPartitions"` // Partitions with fewer in-sync replicas than replicas
+	OfflinePartitions         int                `json:"offlinePartitions"`         // Partitions without a leader
+	UnderMinISRPartitions     *int               `json:"underMinIsrPartitions"`     // Partitions with fewer in-sync replicas than min.insync.replicas (null if unknown)
+	Health                    string             `json:"health"`                    // healthy, degraded or critical
+}
+
+// BrokerSummary identifies a broker in a ClusterOverview.
+type BrokerSummary struct {
+	ID         int32  `json:"id"`         // Broker ID
+	Address    string `json:"address"`    // host:port
+	Rack       string `json:"rack"`       // Rack, if configured
+	Controller bool   `json:"controller"` // Whether the broker is the active controller
+}
+
+// Cluster health values reported in ClusterOverview.
+const (
+	ClusterHealthy  = "healthy"  // All partitions are fully replicated
+	ClusterDegraded = "degraded" // Some partitions are under-replicated or below min.insync.replicas
+	ClusterCritical = "critical" // Some partitions have no leader
+)
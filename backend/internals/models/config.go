@@ -0,0 +1,44 @@
+package models
+
+// Config sources reported in ConfigEntry.Source, from highest to lowest precedence.
+const (
+	ConfigSourceTopic          = "topic"          // Override set on the topic
+	ConfigSourceDynamicBroker  = "dynamicBroker"  // Dynamic config of this broker
+	ConfigSourceDynamicCluster = "dynamicCluster" // Dynamic cluster-wide default for all brokers
+	ConfigSourceStatic         = "static"         // server.properties of the broker
+	ConfigSourceDefault        = "default"        // Kafka's built-in default
+	ConfigSourceUnknown        = "unknown"        // Not reported by the broker (Kafka before 1.1)
+)
+
+// Config change operations accepted in ConfigChange.Operation.
+const (
+	ConfigOperationSet      = "set"      // Set the value
+	ConfigOperationDelete   = "delete"   // Remove the value, falling back to the next source
+	ConfigOperationAppend   = "append"   // Add the value to a list config
+	ConfigOperationSubtract = "subtract" // Remove the value from a list config
+)
+
+// ConfigEntry is a config of a broker or topic and where its value comes from.
+type ConfigEntry struct {
+	Name         string          `json:"name"`                   // Config name
+	Value        *string         `json:"value"`                  // Current value (null for sensitive configs)
+	Source       string          `json:"source"`                 // Where the value comes from (see ConfigSource constants)
+	DefaultValue *string         `json:"defaultValue,omitempty"` // Value the config falls back to when deleted (omitted when unknown)
+	ReadOnly     bool            `json:"readOnly"`               // Whether the config cannot be changed at runtime
+	Sensitive    bool            `json:"sensitive"`              // Whether the value is a secret the broker does not reveal
+	Synonyms     []ConfigSynonym `json:"synonyms,omitempty"`     // Values from all sources, highest precedence first
+}
+
+// ConfigSynonym is the value of a config at one source.
+type ConfigSynonym struct {
+	Name   string  `json:"name"`   // Config name at this source (e.g. log.retention.ms for a topic's retention.ms)
+	Value  *string `json:"value"`  // Value at this source (null for sensitive configs)
+	Source string  `json:"source"` // Source of the value
+}
+
+// ConfigChange is one change of a config.
+type ConfigChange struct {
+	Name      string  `json:"name"`      // Config name
+	Value     *string `json:"value"`     // New value; unused when deleting
+	Operation string  `json:"operation"` // set (default), delete, append or subtract
+}
@@ -0,0 +1,41 @@
+package models
+
+// BrokerLogDirs is the disk usage of one broker, per log directory.
+type BrokerLogDirs struct {
+	BrokerID  int32    `json:"brokerId"`        // Broker ID
+	SizeBytes int64    `json:"sizeBytes"`       // Size of all logs on the broker
+	Error     string   `json:"error,omitempty"` // Why the broker could not be described
+	LogDirs   []LogDir `json:"logDirs"`         // Log directories of the broker
+}
+
+// LogDir is one log directory of a broker.
+type LogDir struct {
+	Path      string        `json:"path"`            // Absolute path on the broker
+	SizeBytes int64         `json:"sizeBytes"`       // Size of all logs in the directory
+	Error     string        `json:"error,omitempty"` // Set when the directory is offline (e.g. after a disk failure)
+	Topics    []LogDirTopic `json:"topics"`          // Topics with logs in the directory, sorted by name
+}
+
+// LogDirTopic is the part of a topic stored in one log directory.
+type LogDirTopic struct {
+	Topic      string            `json:"topic"`      // Topic name
+	SizeBytes  int64             `json:"sizeBytes"`  // Size of the topic's logs in the directory
+	Partitions []LogDirPartition `json:"partitions"` // Partition logs, sorted by partition ID
+}
+
+// LogDirPartition is the log of one partition replica.
+type LogDirPartition struct {
+	Partition int32 `json:"partition"` // Partition ID
+	SizeBytes int64 `json:"sizeBytes"` // Size of the log segments
+	OffsetLag int64 `json:"offsetLag"` // Messages behind the high watermark, or behind the current log for a future log
+	Future    bool  `json:"future"`    // Whether this is a future log being moved here from another directory
+}
+
+// TopicSize is the disk usage of a topic over all brokers.
+type TopicSize struct {
+	Topic              string `json:"topic"`              // Topic name
+	SizeBytes          int64  `json:"sizeBytes"`          // Size of all replica logs, including future logs
+	PartitionCount     int    `json:"partitionCount"`     // Number of partitions with logs
+	ReplicaCount       int    `json:"replicaCount"`       // Number of replica logs, excluding future logs
+	LargestReplicaSize int64  `json:"largestReplicaSize"` // Size of the largest replica log
+}
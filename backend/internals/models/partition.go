@@ -17,3 +17,24 @@ type Partition struct {
 	InSyncReplicas  []int `json:"inSyncReplicas"`  // In-sync replica broker IDs
 	OfflineReplicas []int `json:"offlineReplicas"` // Offline replica broker IDs
 }
+
+// PartitionIncrease is the result of adding partitions to a topic.
+type PartitionIncrease struct {
+	Topic           string    `json:"topic"`                // Topic name
+	PreviousCount   int       `json:"previousCount"`        // Partition count before the change
+	PartitionCount  int       `json:"partitionCount"`       // Partition count after the change
+	ValidateOnly    bool      `json:"validateOnly"`         // Whether the change was only validated
+	Assignment      [][]int32 `json:"assignment,omitempty"` // Replicas of each new partition when given explicitly
+	KeysChecked     bool      `json:"keysChecked"`          // Whether recent messages were sampled for keys
+	SampledMessages int       `json:"sampledMessages"`      // Recent messages checked for keys
+	KeyedMessages   int       `json:"keyedMessages"`        // Sampled messages that have a key
+	Warnings        []string  `json:"warnings"`             // Consequences of the change to review before applying it
+}
+
+// LeaderElection is the outcome of a leader election for one partition.
+type LeaderElection struct {
+	Topic     string `json:"topic"`           // Topic name
+	Partition int32  `json:"partition"`       // Partition ID
+	Elected   bool   `json:"elected"`         // Whether a new leader was elected; false without error if none was needed
+	Error     string `json:"error,omitempty"` // Why no leader could be elected
+}
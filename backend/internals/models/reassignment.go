@@ -0,0 +1,36 @@
+package models
+
+// ReassignmentPlan is a proposed placement of the replicas of some topics on a set of brokers.
+type ReassignmentPlan struct {
+	Brokers       []int32         `json:"brokers"`       // Brokers the replicas are placed on
+	RackAware     bool            `json:"rackAware"`     // Whether replicas were spread over racks
+	Partitions    []PartitionMove `json:"partitions"`    // Partitions whose replicas change
+	Unchanged     int             `json:"unchanged"`     // Partitions already placed on the brokers
+	MovedReplicas int             `json:"movedReplicas"` // Replicas copied to another broker
+	Warnings      []string        `json:"warnings"`      // Limits of the plan to review before executing it
+}
+
+// PartitionMove is the new replica list of one partition. Plans can be executed as returned.
+type PartitionMove struct {
+	Topic           string  `json:"topic"`                     // Topic name
+	Partition       int32   `json:"partition"`                 // Partition ID
+	CurrentReplicas []int32 `json:"currentReplicas,omitempty"` // Replicas before the move (informational)
+	Replicas        []int32 `json:"replicas"`                  // Replicas after the move, preferred leader first
+}
+
+// PartitionReassignment is a reassignment in progress.
+type PartitionReassignment struct {
+	Topic            string   `json:"topic"`            // Topic name
+	Partition        int32    `json:"partition"`        // Partition ID
+	Replicas         []int32  `json:"replicas"`         // Current replica set, including adding and removing replicas
+	AddingReplicas   []int32  `json:"addingReplicas"`   // Replicas being copied
+	RemovingReplicas []int32  `json:"removingReplicas"` // Replicas removed once the copies are in sync
+	Progress         *float64 `json:"progress"`         // Share of the leader's log the slowest adding replica holds (null if unknown)
+}
+
+// PartitionResult is the outcome of an operation on one partition.
+type PartitionResult struct {
+	Topic     string `json:"topic"`           // Topic name
+	Partition int32  `json:"partition"`       // Partition ID
+	Error     string `json:"error,omitempty"` // Why the operation failed for this partition
+}
@@ -8,4 +8,5 @@ type Topic struct {
 	Internal          bool            `json:"internal"`          // Whether the topic is internal
 	PartitionCount    int             `json:"partitionCount"`    // Number of partitions
 	ReplicationFactor int             `json:"replicationFactor"` // Replication factor
+	SizeBytes         *int64          `json:"sizeBytes"`         // On-disk size of all replicas (null if not requested or unknown)
 }
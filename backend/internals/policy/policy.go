@@ -0,0 +1,215 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"backend/internals/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// policy.go - Implements per-topic access policies loaded from a JSON file.
+// Rules grant actions on topics matching glob patterns to users or groups.
+// When no policy file is present every authenticated user keeps the access granted by their role.
+//
+// Example policy file:
+//
+//	{
+//	  "groups": { "team-payments": ["alice", "bob"] },
+//	  "rules": [
+//	    { "groups": ["team-payments"], "actions": ["read"], "topics": ["payments.*"] },
+//	    { "groups": ["team-payments"], "actions": ["produce"], "topics": ["payments.commands.*"] }
+//	  ]
+//	}
+
+// Action is an operation on a topic that a rule can grant.
+type Action string
+
+const (
+	ActionRead    Action = "read"    // Browse a topic and its messages
+	ActionProduce Action = "produce" // Produce messages to a topic
+	ActionAdmin   Action = "admin"   // Create, delete or clear a topic
+)
+
+// Rule grants actions on topics matching any pattern to the listed users and groups.
+type Rule struct {
+	Users   []string `json:"users"`   // Usernames the rule applies to
+	Groups  []string `json:"groups"`  // Groups the rule applies to
+	Actions []Action `json:"actions"` // Granted actions
+	Topics  []string `json:"topics"`  // Topic glob patterns (e.g. "payments.*")
+}
+
+// Policy is a set of rules plus an optional static group membership table.
+type Policy struct {
+	Groups map[string][]string `json:"groups"` // Group name to member usernames
+	Rules  []Rule              `json:"rules"`  // Access rules
+}
+
+// Subject identifies the caller a policy decision is made for.
+type Subject struct {
+	Username string   // Authenticated username
+	Role     string   // Global role of the user
+	Groups   []string // Groups asserted by the identity provider, if any
+}
+
+var (
+	current   *Policy
+	currentMu sync.RWMutex
+)
+
+// Load reads and validates a policy file.
+func Load(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadDefault loads the policy file named by the POLICY_FILE environment variable,
+// falling back to data/policies.json. A missing file disables policy enforcement.
+func LoadDefault() error {
+	filePath := os.Getenv(utils.PolicyFileEnv)
+	if filePath == "" {
+		filePath = filepath.Join(utils.UsersDataDir, utils.PolicyFileName)
+	}
+	p, err := Load(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		Initialize(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	Initialize(p)
+	return nil
+}
+
+// Initialize sets the active policy. A nil policy disables enforcement.
+func Initialize(p *Policy) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = p
+}
+
+// Enabled reports whether a policy is active.
+func Enabled() bool {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current != nil
+}
+
+// Allowed reports whether subject may perform action on topic under the active policy.
+func Allowed(subject Subject, topic string, action Action) bool {
+	currentMu.RLock()
+	p := current
+	currentMu.RUnlock()
+	if p == nil {
+		return true
+	}
+	return p.Allows(subject, topic, action)
+}
+
+// Validate checks that all actions are known and all topic patterns are valid globs.
+func (p *Policy) Validate() error {
+	for i, rule := range p.Rules {
+		for _, a := range rule.Actions {
+			if a != ActionRead && a != ActionProduce && a != ActionAdmin {
+				return fmt.Errorf("rule %d: unknown action %q", i, a)
+			}
+		}
+		for _, pattern := range rule.Topics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %d: invalid topic pattern %q: %v", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Allows reports whether subject may perform action on topic.
+// Admins are never restricted; everyone else needs a matching rule.
+func (p *Policy) Allows(subject Subject, topic string, action Action) bool {
+	if subject.Role == utils.RoleAdmin {
+		return true
+	}
+	groups := p.groupsOf(subject)
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(subject.Username, groups) || !rule.grants(action) {
+			continue
+		}
+		for _, pattern := range rule.Topics {
+			if ok, _ := path.Match(pattern, topic); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupsOf returns the groups of subject from the policy file and the identity provider.
+func (p *Policy) groupsOf(subject Subject) map[string]bool {
+	groups := make(map[string]bool)
+	for _, g := range subject.Groups {
+		groups[g] = true
+	}
+	for group, members := range p.Groups {
+		for _, m := range members {
+			if m == subject.Username {
+				groups[group] = true
+			}
+		}
+	}
+	return groups
+}
+
+func (r Rule) appliesTo(username string, groups map[string]bool) bool {
+	for _, u := range r.Users {
+		if u == username {
+			return true
+		}
+	}
+	for _, g := range r.Groups {
+		if groups[g] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) grants(action Action) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// SubjectFromContext builds a Subject from the values set by the authentication middleware.
+func SubjectFromContext(c *gin.Context) Subject {
+	var subject Subject
+	if v, ok := c.Get("user"); ok {
+		subject.Username, _ = v.(string)
+	}
+	if v, ok := c.Get("role"); ok {
+		subject.Role, _ = v.(string)
+	}
+	if v, ok := c.Get("groups"); ok {
+		subject.Groups, _ = v.([]string)
+	}
+	return subject
+}
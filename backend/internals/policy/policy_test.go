@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"testing"
+
+	"backend/internals/utils"
+)
+
+func TestAllows(t *testing.T) {
+	p := &Policy{
+		Groups: map[string][]string{
+			"team-payments": {"alice", "bob"},
+			"auditors":      {"dave"},
+		},
+		Rules: []Rule{
+			{Groups: []string{"team-payments"}, Actions: []Action{ActionRead}, Topics: []string{"payments.*"}},
+			{Groups: []string{"team-payments"}, Actions: []Action{ActionProduce}, Topics: []string{"payments.commands.*"}},
+			{Users: []string{"bob"}, Actions: []Action{ActionAdmin}, Topics: []string{"payments.events"}},
+			{Groups: []string{"auditors", "sso-auditors"}, Actions: []Action{ActionRead}, Topics: []string{"audit-*", "orders.v[0-9]"}},
+			{Users: []string{"carol"}, Actions: []Action{ActionRead, ActionProduce}, Topics: []string{"*"}},
+		},
+	}
+	alice := Subject{Username: "alice", Role: utils.RoleOperator}
+	bob := Subject{Username: "bob", Role: utils.RoleOperator}
+	dave := Subject{Username: "dave", Role: utils.RoleViewer}
+	erin := Subject{Username: "erin", Role: utils.RoleViewer, Groups: []string{"sso-auditors"}}
+	tests := []struct {
+		name    string
+		subject Subject
+		topic   string
+		action  Action
+		want    bool
+	}{
+		{"group member reads matching topic", alice, "payments.events", ActionRead, true},
+		{"glob does not cross into other topics", alice, "orders.events", ActionRead, false},
+		{"glob needs the separator", alice, "payments", ActionRead, false},
+		{"'*' matches dots within a name", alice, "payments.commands.refund", ActionRead, true},
+		{"read does not imply produce", alice, "payments.events", ActionProduce, false},
+		{"produce on narrower pattern", alice, "payments.commands.refund", ActionProduce, true},
+		{"user rule", bob, "payments.events", ActionAdmin, true},
+		{"user rule is not shared with the group", alice, "payments.events", ActionAdmin, false},
+		{"prefix pattern", dave, "audit-2024", ActionRead, true},
+		{"prefix pattern is anchored", dave, "old-audit-2024", ActionRead, false},
+		{"character class", dave, "orders.v2", ActionRead, true},
+		{"character class matches one character", dave, "orders.v10", ActionRead, false},
+		{"group from the identity provider", erin, "audit-2024", ActionRead, true},
+		{"identity provider group does not add static groups", erin, "payments.events", ActionRead, false},
+		{"wildcard rule", Subject{Username: "carol", Role: utils.RoleProducer}, "anything", ActionProduce, true},
+		{"wildcard rule grants only its actions", Subject{Username: "carol", Role: utils.RoleProducer}, "anything", ActionAdmin, false},
+		{"no matching rule", Subject{Username: "mallory", Role: utils.RoleOperator}, "payments.events", ActionRead, false},
+		{"admin bypass", Subject{Username: "root", Role: utils.RoleAdmin}, "secret.topic", ActionAdmin, true},
+		{"admin name without the admin role", Subject{Username: "admin", Role: utils.RoleViewer}, "payments.events", ActionRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Allows(tt.subject, tt.topic, tt.action); got != tt.want {
+				t.Errorf("Allows(%s, %q, %s) = %v, want %v", tt.subject.Username, tt.topic, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"valid", Rule{Users: []string{"alice"}, Actions: []Action{ActionRead, ActionProduce, ActionAdmin}, Topics: []string{"payments.*", "orders.v[0-9]"}}, false},
+		{"unknown action", Rule{Users: []string{"alice"}, Actions: []Action{"delete"}, Topics: []string{"*"}}, true},
+		{"invalid pattern", Rule{Users: []string{"alice"}, Actions: []Action{ActionRead}, Topics: []string{"orders.v[0-9"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Policy{Rules: []Rule{tt.rule}}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllowedWithoutPolicy(t *testing.T) {
+	defer Initialize(nil)
+	viewer := Subject{Username: "alice", Role: utils.RoleViewer}
+
+	Initialize(nil)
+	if Enabled() || !Allowed(viewer, "payments.events", ActionAdmin) {
+		t.Error("without a policy every topic is allowed")
+	}
+	Initialize(&Policy{})
+	if !Enabled() || Allowed(viewer, "payments.events", ActionRead) {
+		t.Error("an empty policy allows nothing")
+	}
+}
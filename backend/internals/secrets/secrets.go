@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend/internals/utils"
+)
+
+// secrets.go - Encrypts credentials stored at rest (e.g. cluster SASL passwords) with AES-256-GCM.
+// The key is derived from SECRETS_KEY when set; otherwise a random key is generated once and kept
+// in data/secret.key with owner-only permissions. Losing the key makes stored secrets unreadable.
+// The generated key lives next to the data it encrypts, so a copy or backup of data/ includes both;
+// it is meant for development, and deployments should set SECRETS_KEY from a separate secret store.
+
+// encryptedPrefix marks encrypted values and versions the format.
+const encryptedPrefix = "enc:v1:"
+
+// ErrUndecryptable is returned for values that were not encrypted with the current key.
+var ErrUndecryptable = errors.New("stored secret cannot be decrypted (was the secrets key changed?)")
+
+// Box encrypts and decrypts secrets. It is safe for concurrent use.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox creates a box from a 32-byte key.
+func NewBox(key []byte) (*Box, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// NewBoxFromEnv creates a box keyed by SECRETS_KEY, or by the generated data/secret.key file.
+func NewBoxFromEnv() (*Box, error) {
+	if passphrase := os.Getenv(utils.SecretsKeyEnv); passphrase != "" {
+		key := sha256.Sum256([]byte(passphrase))
+		return NewBox(key[:])
+	}
+	key, err := loadOrCreateKey(utils.DataFilePath(utils.SecretsKeyFileName))
+	if err != nil {
+		return nil, err
+	}
+	return NewBox(key)
+}
+
+// loadOrCreateKey reads the base64 key at path, generating it on first use.
+func loadOrCreateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid secrets key file %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	// O_EXCL so two processes starting together cannot overwrite each other's key
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return loadOrCreateKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets key: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypt returns the encrypted form of plaintext. Empty values stay empty.
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a value produced by Encrypt. Empty values stay empty.
+func (b *Box) Decrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", ErrUndecryptable
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrUndecryptable
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrUndecryptable
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend/internals/utils"
+)
+
+func newTestBox(t *testing.T, seed byte) *Box {
+	t.Helper()
+	box, err := NewBox(bytes.Repeat([]byte{seed}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestBoxRoundTrip(t *testing.T) {
+	box := newTestBox(t, 1)
+	for _, plaintext := range []string{"", "p", "pencil", "pässwörd with spaces", strings.Repeat("x", 4096)} {
+		sealed, err := box.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Single characters may turn up in the base64 ciphertext by chance
+		if plaintext != "" && (!strings.HasPrefix(sealed, encryptedPrefix) || (len(plaintext) > 1 && strings.Contains(sealed, plaintext))) {
+			t.Errorf("Encrypt(%q) = %q, want an opaque prefixed value", plaintext, sealed)
+		}
+		got, err := box.Decrypt(sealed)
+		if err != nil || got != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q, %v", plaintext, got, err)
+		}
+	}
+
+	// Each encryption uses a fresh nonce
+	first, _ := box.Encrypt("pencil")
+	second, _ := box.Encrypt("pencil")
+	if first == second {
+		t.Error("encrypting the same value twice gave the same result")
+	}
+}
+
+func TestBoxRejectsUndecryptable(t *testing.T) {
+	box := newTestBox(t, 1)
+	sealed, err := box.Encrypt("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamper := func(i int) string {
+		b := append([]byte{}, raw...)
+		b[i] ^= 1
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name  string
+		box   *Box
+		value string
+	}{
+		{"wrong key", newTestBox(t, 2), sealed},
+		{"tampered nonce", box, tamper(0)},
+		{"tampered ciphertext", box, tamper(len(raw) / 2)},
+		{"tampered tag", box, tamper(len(raw) - 1)},
+		{"truncated", box, encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:len(raw)-1])},
+		{"shorter than a nonce", box, encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:4])},
+		{"not base64", box, encryptedPrefix + "!!"},
+		{"plain text", box, "pencil"},
+		{"unknown version", box, "enc:v2:" + strings.TrimPrefix(sealed, encryptedPrefix)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.box.Decrypt(tt.value); !errors.Is(err, ErrUndecryptable) {
+				t.Errorf("Decrypt = %q, %v, want %v", got, err, ErrUndecryptable)
+			}
+		})
+	}
+}
+
+func TestNewBoxKeySize(t *testing.T) {
+	for _, size := range []int{0, 15, 31, 33} {
+		if _, err := NewBox(make([]byte, size)); err == nil {
+			t.Errorf("NewBox accepted a %d-byte key", size)
+		}
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "secret.key")
+	key, err := loadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("generated a %d-byte key", len(key))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode %v, want 0600", info.Mode().Perm())
+	}
+	again, err := loadOrCreateKey(path)
+	if err != nil || !bytes.Equal(again, key) {
+		t.Errorf("reloaded key differs (%v)", err)
+	}
+
+	if err := os.WriteFile(path, []byte("too short\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadOrCreateKey(path); err == nil {
+		t.Error("invalid key file accepted")
+	}
+}
+
+func TestNewBoxFromEnv(t *testing.T) {
+	t.Setenv(utils.SecretsKeyEnv, "correct horse battery staple")
+	box, err := NewBoxFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := box.Encrypt("pencil")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same passphrase derives the same key, another one cannot decrypt
+	same, _ := NewBoxFromEnv()
+	if got, err := same.Decrypt(sealed); err != nil || got != "pencil" {
+		t.Errorf("same passphrase: %q, %v", got, err)
+	}
+	t.Setenv(utils.SecretsKeyEnv, "another passphrase")
+	other, _ := NewBoxFromEnv()
+	if _, err := other.Decrypt(sealed); !errors.Is(err, ErrUndecryptable) {
+		t.Errorf("other passphrase: error %v, want %v", err, ErrUndecryptable)
+	}
+}
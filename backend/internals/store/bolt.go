@@ -0,0 +1,472 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"backend/internals/audit"
+	"backend/internals/utils"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bolt.go - Implements UserStore, DocumentStore and an audit sink in a single embedded bbolt database.
+// The schema is versioned in the meta bucket and upgraded by the migrations below when the database
+// is opened. Migration 2 imports users.csv and the JSON documents of the file backend once, so an
+// existing installation can switch STORE_BACKEND to bolt without recreating accounts. Migration 3
+// imports the cluster definitions, which were added to the file backend after migration 2 shipped.
+
+var (
+	metaBucket      = []byte("meta")
+	usersBucket     = []byte("users")
+	documentsBucket = []byte("documents")
+	auditBucket     = []byte("audit")
+
+	schemaVersionKey = []byte("schemaVersion")
+	importedAtKey    = []byte("importedAt")
+)
+
+// importedDocuments are the file backend documents copied into the database by the importer.
+var importedDocuments = []string{
+	utils.APIKeysFileName,
+	utils.MFAFileName,
+	utils.RefreshTokensFileName,
+	utils.RevocationsFileName,
+}
+
+// migration upgrades the schema to version.
+type migration struct {
+	version     int
+	description string
+	apply       func(s *BoltStore, tx *bolt.Tx) error
+}
+
+// migrations are applied in order to databases with a lower schema version.
+// Never edit a released migration; append a new one instead.
+var migrations = []migration{
+	{1, "create buckets", migrateCreateBuckets},
+	{2, "import file backend data", migrateImportFiles},
+	{3, "import cluster definitions", migrateImportClusters},
+}
+
+// boltUser is the stored form of a user.
+type boltUser struct {
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BoltStore stores users, documents and audit entries in a bbolt database. It is safe for concurrent use.
+type BoltStore struct {
+	db        *bolt.DB
+	importDir string
+}
+
+// OpenBoltStore opens (creating if needed) the database at path and applies pending migrations.
+// Data of the file backend is imported from the data directory on the first open.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	return openBoltStore(path, utils.UsersDataDir)
+}
+
+// openBoltStore opens the database at path, importing file backend data from importDir.
+func openBoltStore(path, importDir string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %v", path, err)
+	}
+	s := &BoltStore{db: db, importDir: importDir}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// SchemaVersion returns the schema version of the database.
+func (s *BoltStore) SchemaVersion() (int, error) {
+	version := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		version = schemaVersion(tx)
+		return nil
+	})
+	return version, err
+}
+
+func schemaVersion(tx *bolt.Tx) int {
+	meta := tx.Bucket(metaBucket)
+	if meta == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(string(meta.Get(schemaVersionKey)))
+	return version
+}
+
+// migrate applies each pending migration in its own transaction.
+func (s *BoltStore) migrate() error {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].version; version > latest {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, latest)
+	}
+	for _, m := range migrations {
+		if version >= m.version {
+			continue
+		}
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			if err := m.apply(s, tx); err != nil {
+				return err
+			}
+			return tx.Bucket(metaBucket).Put(schemaVersionKey, []byte(strconv.Itoa(m.version)))
+		})
+		if err != nil {
+			return fmt.Errorf("database migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+		log.Printf("Applied database migration %d: %s", m.version, m.description)
+		version = m.version
+	}
+	return nil
+}
+
+func migrateCreateBuckets(s *BoltStore, tx *bolt.Tx) error {
+	for _, name := range [][]byte{metaBucket, usersBucket, documentsBucket, auditBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateImportFiles copies users.csv and the JSON documents of the file backend into the database.
+// The source files are left in place. An empty store is seeded with the default admin account.
+func migrateImportFiles(s *BoltStore, tx *bolt.Tx) error {
+	users := tx.Bucket(usersBucket)
+	now := time.Now().UTC()
+
+	csvPath := filepath.Join(s.importDir, utils.UsersFileName)
+	if _, err := os.Stat(csvPath); err == nil {
+		imported, _, err := ReadUsersCSV(csvPath)
+		if err != nil {
+			return err
+		}
+		for _, u := range imported {
+			if err := putUser(users, &boltUser{
+				Username: u.Username, Password: u.Password, Role: u.Role, Disabled: u.Disabled,
+				CreatedAt: now, UpdatedAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+		log.Printf("Imported %d users from %s", len(imported), csvPath)
+	}
+
+	docs := tx.Bucket(documentsBucket)
+	for _, name := range importedDocuments {
+		if err := s.importDocument(docs, name); err != nil {
+			return err
+		}
+	}
+
+	if k, _ := users.Cursor().First(); k == nil {
+		admin, err := defaultAdmin()
+		if err != nil {
+			return err
+		}
+		if err := putUser(users, &boltUser{
+			Username: admin.Username, Password: admin.Password, Role: admin.Role,
+			CreatedAt: now, UpdatedAt: now,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(metaBucket).Put(importedAtKey, []byte(now.Format(time.RFC3339)))
+}
+
+// migrateImportClusters copies the cluster definitions of the file backend into the database, unless
+// clusters were already defined in it.
+func migrateImportClusters(s *BoltStore, tx *bolt.Tx) error {
+	docs := tx.Bucket(documentsBucket)
+	if docs.Get([]byte(utils.ClustersFileName)) != nil {
+		return nil
+	}
+	return s.importDocument(docs, utils.ClustersFileName)
+}
+
+// importDocument copies the named JSON document from the import directory, if it exists.
+func (s *BoltStore) importDocument(docs *bolt.Bucket, name string) error {
+	path := filepath.Join(s.importDir, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("failed to parse %s: invalid JSON", path)
+	}
+	if err := docs.Put([]byte(name), data); err != nil {
+		return err
+	}
+	log.Printf("Imported %s", path)
+	return nil
+}
+
+// Close closes the database.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func putUser(b *bolt.Bucket, u *boltUser) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(u.Username), data)
+}
+
+func getUser(b *bolt.Bucket, username string) (*boltUser, error) {
+	data := b.Get([]byte(username))
+	if data == nil {
+		return nil, utils.ErrUserNotFound
+	}
+	u := &boltUser{}
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("failed to decode user %s: %v", username, err)
+	}
+	return u, nil
+}
+
+func (u *boltUser) user() User {
+	return User{Username: u.Username, Password: u.Password, Role: u.Role, Disabled: u.Disabled}
+}
+
+// updateUser applies fn to the named user and persists the result.
+func (s *BoltStore) updateUser(username string, fn func(u *boltUser)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		u, err := getUser(b, username)
+		if err != nil {
+			return err
+		}
+		fn(u)
+		u.UpdatedAt = time.Now().UTC()
+		return putUser(b, u)
+	})
+}
+
+// GetUser retrieves a user by username.
+func (s *BoltStore) GetUser(username string) (*User, error) {
+	var user User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		u, err := getUser(tx.Bucket(usersBucket), username)
+		if err != nil {
+			return err
+		}
+		user = u.user()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns all users sorted by username.
+func (s *BoltStore) ListUsers() ([]User, error) {
+	users := []User{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Keys are iterated in byte order, which is the username order
+		return tx.Bucket(usersBucket).ForEach(func(k, v []byte) error {
+			u := &boltUser{}
+			if err := json.Unmarshal(v, u); err != nil {
+				return fmt.Errorf("failed to decode user %s: %v", k, err)
+			}
+			users = append(users, u.user())
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CreateUser adds a new user with the given password and role.
+func (s *BoltStore) CreateUser(username, password, role string) error {
+	if err := utils.ValidateUsername(username); err != nil {
+		return err
+	}
+	if !utils.IsValidRole(role) {
+		return utils.ErrInvalidRole
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(username)) != nil {
+			return utils.ErrUserExists
+		}
+		now := time.Now().UTC()
+		return putUser(b, &boltUser{Username: username, Password: hash, Role: role, CreatedAt: now, UpdatedAt: now})
+	})
+}
+
+// UpdateUserPassword hashes newPassword and stores it for the user.
+func (s *BoltStore) UpdateUserPassword(username, newPassword string) error {
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.updateUser(username, func(u *boltUser) { u.Password = hash })
+}
+
+// UpdateUserRole changes the role of an existing user.
+func (s *BoltStore) UpdateUserRole(username, role string) error {
+	if !utils.IsValidRole(role) {
+		return utils.ErrInvalidRole
+	}
+	return s.updateUser(username, func(u *boltUser) { u.Role = role })
+}
+
+// SetUserDisabled enables or disables an existing user.
+func (s *BoltStore) SetUserDisabled(username string, disabled bool) error {
+	return s.updateUser(username, func(u *boltUser) { u.Disabled = disabled })
+}
+
+// DeleteUser removes a user.
+func (s *BoltStore) DeleteUser(username string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(username)) == nil {
+			return utils.ErrUserNotFound
+		}
+		return b.Delete([]byte(username))
+	})
+}
+
+// ReadDocument decodes the named document into v. A missing document leaves v unchanged.
+func (s *BoltStore) ReadDocument(name string, v interface{}) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(documentsBucket).Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// WriteDocument replaces the named document.
+func (s *BoltStore) WriteDocument(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(documentsBucket).Put([]byte(name), data)
+	})
+}
+
+// BoltAuditLog is an audit.Sink storing entries in the database, keeping the newest maxEntries.
+type BoltAuditLog struct {
+	db         *bolt.DB
+	maxEntries uint64
+}
+
+// AuditLog returns an audit sink backed by the database that keeps at most maxEntries entries.
+func (s *BoltStore) AuditLog(maxEntries int) *BoltAuditLog {
+	return &BoltAuditLog{db: s.db, maxEntries: uint64(maxEntries)}
+}
+
+var _ audit.Sink = (*BoltAuditLog)(nil)
+
+// auditKey encodes a sequence number so keys sort in insertion order.
+func auditKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// Record stores an entry, dropping the oldest entries beyond the retention limit.
+func (l *BoltAuditLog) Record(entry audit.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	return l.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(auditKey(seq), data); err != nil {
+			return err
+		}
+		if seq <= l.maxEntries {
+			return nil
+		}
+		oldest := auditKey(seq - l.maxEntries)
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, oldest) <= 0; k, _ = c.Next() {
+			expired = append(expired, k)
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Query returns the entries matching filter, newest first.
+func (l *BoltAuditLog) Query(filter audit.Filter) ([]audit.Entry, error) {
+	entries := []audit.Entry{}
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(auditBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var entry audit.Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				continue
+			}
+			if !filter.Matches(entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Close is a no-op; the database is closed with the store.
+func (l *BoltAuditLog) Close() error {
+	return nil
+}
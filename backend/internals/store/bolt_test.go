@@ -0,0 +1,321 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"backend/internals/utils"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// writeImportFiles writes file backend data into dir: a users.csv with the given content and
+// documents by name.
+func writeImportFiles(t *testing.T, dir, usersCSV string, documents map[string]string) {
+	t.Helper()
+	if usersCSV != "" {
+		if err := os.WriteFile(filepath.Join(dir, utils.UsersFileName), []byte(usersCSV), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range documents {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// seedDatabase creates a database at path as an older release left it, with the migrations up to
+// version applied.
+func seedDatabase(t *testing.T, path, importDir string, version int) {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := &BoltStore{db: db, importDir: importDir}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, m := range migrations {
+			if m.version > version {
+				break
+			}
+			if err := m.apply(s, tx); err != nil {
+				return err
+			}
+			if err := tx.Bucket(metaBucket).Put(schemaVersionKey, []byte(strconv.Itoa(m.version))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.version, i+1)
+		}
+		if m.description == "" || m.apply == nil {
+			t.Errorf("migration %d is incomplete", m.version)
+		}
+	}
+}
+
+func TestOpenBoltStoreFresh(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openBoltStore(filepath.Join(dir, "db", "kafka-ui.db"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if version, err := s.SchemaVersion(); err != nil || version != latestSchemaVersion() {
+		t.Fatalf("schema version %d (%v), want %d", version, err, latestSchemaVersion())
+	}
+	users, err := s.ListUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != utils.DefaultAdminUsername || users[0].Role != utils.RoleAdmin {
+		t.Fatalf("users %+v, want only the default admin", users)
+	}
+	if !utils.IsPasswordHash(users[0].Password) {
+		t.Error("default admin password is not hashed")
+	}
+}
+
+func TestImportFileBackend(t *testing.T) {
+	tests := []struct {
+		name      string
+		usersCSV  string
+		documents map[string]string
+		wantUsers map[string]string // Username to role
+		wantDocs  map[string]string // Document to a value it must contain
+	}{
+		{
+			name:      "current layout",
+			usersCSV:  "username,password,role,disabled\nadmin,$2a$10$abc,admin,false\nbob,pw,producer,true\n",
+			documents: map[string]string{utils.APIKeysFileName: `[{"id":"k1"}]`, utils.MFAFileName: `{"bob":{"enabled":true}}`, utils.ClustersFileName: `[{"name":"prod"}]`},
+			wantUsers: map[string]string{"admin": utils.RoleAdmin, "bob": utils.RoleProducer},
+			wantDocs:  map[string]string{utils.APIKeysFileName: "k1", utils.MFAFileName: "enabled", utils.ClustersFileName: "prod"},
+		},
+		{
+			name:      "legacy layout without roles",
+			usersCSV:  "username,password\nadmin,x\ncarol,y\n",
+			wantUsers: map[string]string{"admin": utils.RoleAdmin, "carol": utils.RoleViewer},
+		},
+		{
+			name:      "documents only",
+			documents: map[string]string{utils.RefreshTokensFileName: `{"t1":{"username":"admin"}}`},
+			wantUsers: map[string]string{utils.DefaultAdminUsername: utils.RoleAdmin},
+			wantDocs:  map[string]string{utils.RefreshTokensFileName: "t1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeImportFiles(t, dir, tt.usersCSV, tt.documents)
+			s, err := openBoltStore(filepath.Join(dir, "kafka-ui.db"), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			users, err := s.ListUsers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != len(tt.wantUsers) {
+				t.Errorf("imported %d users, want %d", len(users), len(tt.wantUsers))
+			}
+			for _, u := range users {
+				if role, ok := tt.wantUsers[u.Username]; !ok || role != u.Role {
+					t.Errorf("user %s with role %s, want role %q", u.Username, u.Role, role)
+				}
+			}
+			for name, want := range tt.wantDocs {
+				var doc json.RawMessage
+				if err := s.ReadDocument(name, &doc); err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(doc), want) {
+					t.Errorf("document %s = %v, want it to contain %q", name, doc, want)
+				}
+			}
+			// The source files are left in place
+			for name := range tt.documents {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("%s was removed: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestImportRunsOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kafka-ui.db")
+	writeImportFiles(t, dir, "username,password,role,disabled\nadmin,x,admin,false\n", nil)
+	s, err := openBoltStore(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser("dave", "pw", utils.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Files changed after the import are not imported again, and users created since are kept
+	writeImportFiles(t, dir, "username,password,role,disabled\nadmin,x,admin,false\nerin,y,admin,false\n", nil)
+	s, err = openBoltStore(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.GetUser("erin"); err == nil {
+		t.Error("users.csv was imported a second time")
+	}
+	if _, err := s.GetUser("dave"); err != nil {
+		t.Errorf("user created after the import is missing: %v", err)
+	}
+}
+
+func TestImportFailureRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kafka-ui.db")
+	writeImportFiles(t, dir, "username,password,role,disabled\nbob,x,viewer,false\n", map[string]string{utils.MFAFileName: "{not json"})
+	if _, err := openBoltStore(path, dir); err == nil || !strings.Contains(err.Error(), "migration 2") {
+		t.Fatalf("error %v, want migration 2 to fail", err)
+	}
+
+	// The failed migration left no partial import behind and runs again once the file is fixed
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if version := schemaVersion(tx); version != 1 {
+			t.Errorf("schema version %d after the failed import, want 1", version)
+		}
+		if k, _ := tx.Bucket(usersBucket).Cursor().First(); k != nil {
+			t.Errorf("user %s was imported by the failed migration", k)
+		}
+		return nil
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeImportFiles(t, dir, "", map[string]string{utils.MFAFileName: "{}"})
+	s, err := openBoltStore(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.GetUser("bob"); err != nil {
+		t.Errorf("bob was not imported on retry: %v", err)
+	}
+}
+
+func TestMigrateFromOlderSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kafka-ui.db")
+	seedDatabase(t, path, dir, 1)
+	writeImportFiles(t, dir, "username,password,role,disabled\nbob,x,operator,false\n", nil)
+
+	s, err := openBoltStore(path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if version, _ := s.SchemaVersion(); version != latestSchemaVersion() {
+		t.Errorf("schema version %d, want %d", version, latestSchemaVersion())
+	}
+	if u, err := s.GetUser("bob"); err != nil || u.Role != utils.RoleOperator {
+		t.Errorf("bob = %+v, %v; want an imported operator", u, err)
+	}
+}
+
+func TestRejectNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kafka-ui.db")
+	seedDatabase(t, path, dir, latestSchemaVersion())
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(schemaVersionKey, []byte(strconv.Itoa(latestSchemaVersion()+1)))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openBoltStore(path, dir); err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("error %v, want the newer schema to be rejected", err)
+	}
+}
+
+func TestImportClustersAfterFileImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // Cluster definitions already in the database
+		file     string // clusters.json in the data directory
+		want     string
+	}{
+		{name: "imported", file: `[{"name":"prod"}]`, want: "prod"},
+		{name: "no file", want: ""},
+		{name: "existing definitions kept", existing: `[{"name":"staging"}]`, file: `[{"name":"prod"}]`, want: "staging"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "kafka-ui.db")
+			// A database that ran the file import before cluster definitions were imported
+			seedDatabase(t, path, dir, 2)
+			if tt.existing != "" {
+				db, err := bolt.Open(path, 0600, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = db.Update(func(tx *bolt.Tx) error {
+					return tx.Bucket(documentsBucket).Put([]byte(utils.ClustersFileName), []byte(tt.existing))
+				})
+				db.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.file != "" {
+				writeImportFiles(t, dir, "", map[string]string{utils.ClustersFileName: tt.file})
+			}
+
+			s, err := openBoltStore(path, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			var doc json.RawMessage
+			if err := s.ReadDocument(utils.ClustersFileName, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if (tt.want == "" && len(doc) != 0) || !strings.Contains(string(doc), tt.want) {
+				t.Errorf("cluster definitions %s, want %q", doc, tt.want)
+			}
+		})
+	}
+}
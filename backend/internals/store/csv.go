@@ -0,0 +1,293 @@
+package store
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/internals/utils"
+)
+
+// csv.go - Implements UserStore on top of the users.csv file.
+// The file is created (with a default admin) on first use, parsed once and re-read only when it
+// changes on disk. Files written by older versions (username,password only) are upgraded in place.
+
+// usersHeader is the current column layout of users.csv.
+var usersHeader = []string{"username", "password", "role", "disabled"}
+
+// CSVUserStore stores users in a CSV file. It is safe for concurrent use.
+type CSVUserStore struct {
+	path string
+
+	mu       sync.Mutex
+	ensured  bool
+	cache    []User
+	cacheMod time.Time
+	cacheLen int64
+}
+
+// NewCSVUserStore creates a store backed by the CSV file at path.
+func NewCSVUserStore(path string) *CSVUserStore {
+	return &CSVUserStore{path: path}
+}
+
+// ensureFile creates the file with a default admin user if it doesn't exist. Callers must hold s.mu.
+func (s *CSVUserStore) ensureFile() error {
+	if s.ensured {
+		return nil
+	}
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		admin, err := defaultAdmin()
+		if err != nil {
+			return err
+		}
+		if err := s.writeUsers([]User{admin}); err != nil {
+			return err
+		}
+	}
+	s.ensured = true
+	return nil
+}
+
+// readUsers returns a copy of all users, parsing the file only if it changed since the last read.
+// Callers must hold s.mu.
+func (s *CSVUserStore) readUsers() ([]User, error) {
+	if err := s.ensureFile(); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open users file: %v", err)
+	}
+	if s.cache == nil || !info.ModTime().Equal(s.cacheMod) || info.Size() != s.cacheLen {
+		users, err := s.parseFile()
+		if err != nil {
+			return nil, err
+		}
+		// Stat again: parsing may have upgraded the file
+		if info, err = os.Stat(s.path); err != nil {
+			return nil, fmt.Errorf("failed to open users file: %v", err)
+		}
+		s.cache, s.cacheMod, s.cacheLen = users, info.ModTime(), info.Size()
+	}
+	return append([]User{}, s.cache...), nil
+}
+
+// parseFile loads all users from the CSV file, upgrading legacy layouts in place.
+func (s *CSVUserStore) parseFile() ([]User, error) {
+	users, legacy, err := ReadUsersCSV(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		if err := s.writeUsers(users); err != nil {
+			return nil, fmt.Errorf("failed to upgrade users file: %v", err)
+		}
+	}
+	return users, nil
+}
+
+// ReadUsersCSV parses a users.csv file. legacy reports whether it uses an older column layout.
+// Rows written before roles existed get the least-privileged role, except the bootstrap admin user,
+// which keeps full access so the file can still be administered; each upgrade is logged.
+func ReadUsersCSV(path string) (users []User, legacy bool, err error) {
+	file, err := os.OpenFile(path, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open users file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read users file: %v", err)
+	}
+	if len(records) == 0 {
+		return []User{}, false, nil
+	}
+
+	users = make([]User, 0, len(records)-1)
+	// Skip header row
+	for _, record := range records[1:] {
+		if len(record) < 2 {
+			continue
+		}
+		user := User{
+			Username: record[0],
+			Password: record[1],
+		}
+		if len(record) > 2 && record[2] != "" {
+			user.Role = record[2]
+		} else {
+			user.Role = utils.RoleViewer
+			if user.Username == utils.DefaultAdminUsername {
+				user.Role = utils.RoleAdmin
+			}
+			log.Printf("Users file %s: legacy user %s without a role was given the %s role", path, user.Username, user.Role)
+		}
+		if len(record) > 3 {
+			user.Disabled, _ = strconv.ParseBool(record[3])
+		}
+		users = append(users, user)
+	}
+	return users, len(records[0]) < len(usersHeader), nil
+}
+
+// writeUsers replaces the CSV file contents with the given users.
+// The file is written to a temporary path first and renamed so a failed write never truncates it.
+// Callers must hold s.mu.
+func (s *CSVUserStore) writeUsers(users []User) error {
+	tmpPath := s.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create users file: %v", err)
+	}
+
+	records := [][]string{usersHeader}
+	for _, u := range users {
+		records = append(records, []string{u.Username, u.Password, u.Role, strconv.FormatBool(u.Disabled)})
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(records); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace users file: %v", err)
+	}
+	s.cache = nil
+	return nil
+}
+
+// updateUser applies fn to the named user and persists the result.
+func (s *CSVUserStore) updateUser(username string, fn func(u *User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.readUsers()
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if users[i].Username == username {
+			fn(&users[i])
+			return s.writeUsers(users)
+		}
+	}
+	return utils.ErrUserNotFound
+}
+
+// GetUser retrieves a user by username.
+func (s *CSVUserStore) GetUser(username string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.readUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.Username == username {
+			user := u
+			return &user, nil
+		}
+	}
+	return nil, utils.ErrUserNotFound
+}
+
+// ListUsers returns all users sorted by username.
+func (s *CSVUserStore) ListUsers() ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.readUsers()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// CreateUser adds a new user with the given password and role.
+func (s *CSVUserStore) CreateUser(username, password, role string) error {
+	if err := utils.ValidateUsername(username); err != nil {
+		return err
+	}
+	if !utils.IsValidRole(role) {
+		return utils.ErrInvalidRole
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.readUsers()
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Username == username {
+			return utils.ErrUserExists
+		}
+	}
+	return s.writeUsers(append(users, User{Username: username, Password: hash, Role: role}))
+}
+
+// UpdateUserPassword hashes newPassword and stores it for the user.
+func (s *CSVUserStore) UpdateUserPassword(username, newPassword string) error {
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.updateUser(username, func(u *User) { u.Password = hash })
+}
+
+// UpdateUserRole changes the role of an existing user.
+func (s *CSVUserStore) UpdateUserRole(username, role string) error {
+	if !utils.IsValidRole(role) {
+		return utils.ErrInvalidRole
+	}
+	return s.updateUser(username, func(u *User) { u.Role = role })
+}
+
+// SetUserDisabled enables or disables an existing user.
+func (s *CSVUserStore) SetUserDisabled(username string, disabled bool) error {
+	return s.updateUser(username, func(u *User) { u.Disabled = disabled })
+}
+
+// DeleteUser removes a user.
+func (s *CSVUserStore) DeleteUser(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.readUsers()
+	if err != nil {
+		return err
+	}
+	for i, u := range users {
+		if u.Username == username {
+			return s.writeUsers(append(users[:i], users[i+1:]...))
+		}
+	}
+	return utils.ErrUserNotFound
+}
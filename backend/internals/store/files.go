@@ -0,0 +1,29 @@
+package store
+
+import (
+	"path/filepath"
+
+	"backend/internals/utils"
+)
+
+// files.go - Implements DocumentStore with one JSON file per document in a directory.
+
+// FileDocumentStore stores each document as <dir>/<name>.
+type FileDocumentStore struct {
+	dir string
+}
+
+// NewFileDocumentStore creates a document store in dir.
+func NewFileDocumentStore(dir string) *FileDocumentStore {
+	return &FileDocumentStore{dir: dir}
+}
+
+// ReadDocument decodes the named document into v. A missing file leaves v unchanged.
+func (s *FileDocumentStore) ReadDocument(name string, v interface{}) error {
+	return utils.ReadJSONFile(filepath.Join(s.dir, name), v)
+}
+
+// WriteDocument atomically replaces the named document.
+func (s *FileDocumentStore) WriteDocument(name string, v interface{}) error {
+	return utils.WriteJSONFile(filepath.Join(s.dir, name), v)
+}
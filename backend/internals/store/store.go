@@ -0,0 +1,149 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"backend/internals/audit"
+	"backend/internals/utils"
+)
+
+// store.go - Defines the persistence interfaces for users and other backend state and selects the
+// configured backend with STORE_BACKEND: "file" (default; users.csv plus JSON documents in data/)
+// or "bolt" (a single embedded bbolt database that also holds the audit log).
+
+// FileBackendName and BoltBackendName are the STORE_BACKEND values.
+const (
+	FileBackendName = "file"
+	BoltBackendName = "bolt"
+)
+
+// User represents a user in the system, with a username, password hash, role and status.
+type User struct {
+	Username string // Username of the user
+	Password string // bcrypt hash of the password (legacy rows may still hold plain text)
+	Role     string // Role of the user (viewer, producer, operator, admin)
+	Disabled bool   // Whether the account is disabled
+}
+
+// UserStore persists local user accounts.
+// Implementations return utils.ErrUserNotFound, utils.ErrUserExists, utils.ErrInvalidRole and
+// utils.ErrInvalidUsername for the corresponding conditions.
+type UserStore interface {
+	// GetUser returns the named user.
+	GetUser(username string) (*User, error)
+	// ListUsers returns all users sorted by username.
+	ListUsers() ([]User, error)
+	// CreateUser adds a user, hashing the password.
+	CreateUser(username, password, role string) error
+	// UpdateUserPassword hashes and stores a new password.
+	UpdateUserPassword(username, newPassword string) error
+	// UpdateUserRole changes the role of a user.
+	UpdateUserRole(username, role string) error
+	// SetUserDisabled enables or disables a user.
+	SetUserDisabled(username string, disabled bool) error
+	// DeleteUser removes a user.
+	DeleteUser(username string) error
+}
+
+// DocumentStore persists small named JSON documents: API keys, two-factor state, session
+// revocations and saved settings.
+type DocumentStore interface {
+	// ReadDocument decodes the named document into v. A missing document leaves v unchanged.
+	ReadDocument(name string, v interface{}) error
+	// WriteDocument replaces the named document with v encoded as JSON.
+	WriteDocument(name string, v interface{}) error
+}
+
+// Backend is an opened storage backend.
+type Backend struct {
+	Name      string
+	Users     UserStore
+	Documents DocumentStore
+	Audit     audit.Sink // Audit sink provided by the backend; nil to use the audit log file
+	Close     func() error
+}
+
+var (
+	users     UserStore     = NewCSVUserStore(utils.DataFilePath(utils.UsersFileName))
+	documents DocumentStore = NewFileDocumentStore(utils.UsersDataDir)
+)
+
+// Initialize sets the stores used by the rest of the backend.
+func Initialize(b *Backend) {
+	users = b.Users
+	documents = b.Documents
+}
+
+// Users returns the configured user store.
+func Users() UserStore {
+	return users
+}
+
+// Documents returns the configured document store.
+func Documents() DocumentStore {
+	return documents
+}
+
+// OpenFromEnv opens the backend selected by STORE_BACKEND.
+func OpenFromEnv() (*Backend, error) {
+	switch backend := strings.ToLower(os.Getenv(utils.StoreBackendEnv)); backend {
+	case "", FileBackendName:
+		return &Backend{
+			Name:      FileBackendName,
+			Users:     NewCSVUserStore(utils.DataFilePath(utils.UsersFileName)),
+			Documents: NewFileDocumentStore(utils.UsersDataDir),
+			Close:     func() error { return nil },
+		}, nil
+	case BoltBackendName:
+		path := os.Getenv(utils.StoreDBFileEnv)
+		if path == "" {
+			path = utils.DataFilePath(utils.StoreDBFileName)
+		}
+		db, err := OpenBoltStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return &Backend{
+			Name:      BoltBackendName,
+			Users:     db,
+			Documents: db,
+			Audit:     db.AuditLog(utils.DefaultAuditMaxEntries),
+			Close:     db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q", utils.StoreBackendEnv, backend)
+	}
+}
+
+// CheckDefaultCredentials returns utils.ErrDefaultCredentials if the default admin account
+// still accepts the shipped default password.
+func CheckDefaultCredentials(s UserStore) error {
+	user, err := s.GetUser(utils.DefaultAdminUsername)
+	if err != nil {
+		if errors.Is(err, utils.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if ok, _ := utils.VerifyPassword(user.Password, utils.DefaultAdminPassword); ok && !user.Disabled {
+		return utils.ErrDefaultCredentials
+	}
+	return nil
+}
+
+// defaultAdmin returns the admin account seeded into an empty store, using the password from
+// the environment when provided.
+func defaultAdmin() (User, error) {
+	adminPassword := os.Getenv(utils.AdminPasswordEnv)
+	if adminPassword == "" {
+		adminPassword = utils.DefaultAdminPassword
+	}
+	hash, err := utils.HashPassword(adminPassword)
+	if err != nil {
+		return User{}, err
+	}
+	return User{Username: utils.DefaultAdminUsername, Password: hash, Role: utils.RoleAdmin}, nil
+}
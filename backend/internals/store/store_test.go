@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/internals/utils"
+)
+
+func TestCheckDefaultCredentials(t *testing.T) {
+	defaultHash, err := utils.HashPassword(utils.DefaultAdminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHash, err := utils.HashPassword("changed-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		csv     string
+		wantErr error
+	}{
+		{"default password", "username,password,role,disabled\nadmin," + defaultHash + ",admin,false\n", utils.ErrDefaultCredentials},
+		{"legacy plain-text default password", "username,password,role,disabled\nadmin,password,admin,false\n", utils.ErrDefaultCredentials},
+		{"changed password", "username,password,role,disabled\nadmin," + otherHash + ",admin,false\n", nil},
+		{"disabled admin", "username,password,role,disabled\nadmin," + defaultHash + ",admin,true\n", nil},
+		{"no admin account", "username,password,role,disabled\nbob," + defaultHash + ",admin,false\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), utils.UsersFileName)
+			if err := os.WriteFile(path, []byte(tt.csv), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckDefaultCredentials(NewCSVUserStore(path)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateUserRejectsInvalidNames(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, utils.UsersFileName)
+	if err := os.WriteFile(csvPath, []byte("username,password,role,disabled\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bolt, err := openBoltStore(filepath.Join(dir, "kafka-ui.db"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	stores := map[string]UserStore{"csv": NewCSVUserStore(csvPath), "bolt": bolt}
+	for name, s := range stores {
+		before, err := s.ListUsers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, username := range []string{"", "eve,admin", "eve\nadmin,password,admin,false"} {
+			if err := s.CreateUser(username, "secret-pass", utils.RoleViewer); !errors.Is(err, utils.ErrInvalidUsername) {
+				t.Errorf("%s: CreateUser(%q) = %v, want %v", name, username, err, utils.ErrInvalidUsername)
+			}
+		}
+		after, err := s.ListUsers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(after) != len(before) {
+			t.Errorf("%s: rejected names were stored: %v", name, after)
+		}
+	}
+}
@@ -21,6 +21,171 @@ const (
 	// DefaultAdminPassword is the default admin password
 	DefaultAdminPassword = "password"
 
+	// AdminPasswordEnv is the environment variable used to seed the admin password when the user store is created
+	AdminPasswordEnv = "ADMIN_PASSWORD"
+
+	// InsecureDevEnv is the environment variable that allows starting with the default admin credentials
+	InsecureDevEnv = "INSECURE_DEV"
+
+	// PolicyFileEnv is the environment variable that overrides the topic policy file location
+	PolicyFileEnv = "POLICY_FILE"
+
+	// PolicyFileName is the name of the topic policy file in the data directory
+	PolicyFileName = "policies.json"
+
+	// OIDCIssuerEnv enables OIDC login against the issuer URL it names
+	OIDCIssuerEnv = "OIDC_ISSUER"
+
+	// OIDCClientIDEnv is the environment variable for the OIDC client ID
+	OIDCClientIDEnv = "OIDC_CLIENT_ID"
+
+	// OIDCClientSecretEnv is the environment variable for the OIDC client secret
+	OIDCClientSecretEnv = "OIDC_CLIENT_SECRET"
+
+	// OIDCRedirectURLEnv is the callback URL registered with the identity provider
+	OIDCRedirectURLEnv = "OIDC_REDIRECT_URL"
+
+	// OIDCScopesEnv lists extra scopes to request (space or comma separated)
+	OIDCScopesEnv = "OIDC_SCOPES"
+
+	// OIDCGroupsClaimEnv names the ID token claim holding group membership (default "groups")
+	OIDCGroupsClaimEnv = "OIDC_GROUPS_CLAIM"
+
+	// OIDCRoleMapEnv maps groups to roles as comma-separated group=role pairs
+	OIDCRoleMapEnv = "OIDC_ROLE_MAP"
+
+	// OIDCDefaultRoleEnv is the role for OIDC users without a mapped group (empty denies login)
+	OIDCDefaultRoleEnv = "OIDC_DEFAULT_ROLE"
+
+	// OIDCPostLoginRedirectEnv is the frontend URL the OIDC callback redirects to
+	OIDCPostLoginRedirectEnv = "OIDC_POST_LOGIN_REDIRECT"
+
+	// DefaultOIDCPostLoginRedirect is the fallback frontend URL after OIDC login
+	DefaultOIDCPostLoginRedirect = "http://localhost:3000/"
+
+	// AuthBackendEnv selects the login backend: csv (default), static or ldap
+	AuthBackendEnv = "AUTH_BACKEND"
+
+	// AuthStaticFileEnv overrides the location of the static users file
+	AuthStaticFileEnv = "AUTH_STATIC_FILE"
+
+	// StaticUsersFileName is the name of the static users file in the data directory
+	StaticUsersFileName = "static_users.json"
+
+	// LDAPURLEnv is the ldap:// or ldaps:// URL of the directory server
+	LDAPURLEnv = "LDAP_URL"
+
+	// LDAPStartTLSEnv upgrades ldap:// connections with StartTLS when "true"
+	LDAPStartTLSEnv = "LDAP_START_TLS"
+
+	// LDAPInsecureSkipVerifyEnv disables TLS certificate verification when "true"
+	LDAPInsecureSkipVerifyEnv = "LDAP_INSECURE_SKIP_VERIFY"
+
+	// LDAPBindDNEnv is the DN of the service account used to search for users
+	LDAPBindDNEnv = "LDAP_BIND_DN"
+
+	// LDAPBindPasswordEnv is the password of the service account
+	LDAPBindPasswordEnv = "LDAP_BIND_PASSWORD"
+
+	// LDAPBaseDNEnv is the base DN for user searches
+	LDAPBaseDNEnv = "LDAP_BASE_DN"
+
+	// LDAPUserFilterEnv is the user search filter, with %s replaced by the username (default "(uid=%s)")
+	LDAPUserFilterEnv = "LDAP_USER_FILTER"
+
+	// LDAPGroupAttributeEnv is the user attribute listing group DNs (default "memberOf")
+	LDAPGroupAttributeEnv = "LDAP_GROUP_ATTRIBUTE"
+
+	// LDAPGroupBaseDNEnv is the base DN for group searches
+	LDAPGroupBaseDNEnv = "LDAP_GROUP_BASE_DN"
+
+	// LDAPGroupFilterEnv is the group search filter, with %s replaced by the user DN
+	LDAPGroupFilterEnv = "LDAP_GROUP_FILTER"
+
+	// LDAPRoleMapEnv maps group CNs or DNs to roles as comma-separated group=role pairs
+	LDAPRoleMapEnv = "LDAP_ROLE_MAP"
+
+	// LDAPDefaultRoleEnv is the role for LDAP users without a mapped group (empty denies login)
+	LDAPDefaultRoleEnv = "LDAP_DEFAULT_ROLE"
+
+	// RevocationsFileName is the name of the token revocation list in the data directory
+	RevocationsFileName = "revocations.json"
+
+	// RefreshTokensFileName is the name of the refresh token store in the data directory
+	RefreshTokensFileName = "refresh_tokens.json"
+
+	// APIKeysFileName is the name of the API key store in the data directory
+	APIKeysFileName = "api_keys.json"
+
+	// AuditLogFileEnv is the environment variable overriding the audit log path
+	AuditLogFileEnv = "AUDIT_LOG_FILE"
+
+	// AuditLogFileName is the name of the audit log in the data directory
+	AuditLogFileName = "audit.log"
+
+	// AuditLogMaxSizeEnv is the environment variable for the audit log rotation size in megabytes
+	AuditLogMaxSizeEnv = "AUDIT_LOG_MAX_SIZE_MB"
+
+	// DefaultAuditLogMaxSizeMB is the default audit log rotation size in megabytes
+	DefaultAuditLogMaxSizeMB = 10
+
+	// AuditLogMaxFilesEnv is the environment variable for the number of rotated audit logs to keep
+	AuditLogMaxFilesEnv = "AUDIT_LOG_MAX_FILES"
+
+	// DefaultAuditLogMaxFiles is the default number of rotated audit logs to keep
+	DefaultAuditLogMaxFiles = 5
+
+	// LoginMaxFailuresEnv is the environment variable for failed logins per username before a lockout
+	LoginMaxFailuresEnv = "LOGIN_MAX_FAILURES"
+
+	// LoginIPMaxFailuresEnv is the environment variable for failed logins per client IP before throttling
+	LoginIPMaxFailuresEnv = "LOGIN_IP_MAX_FAILURES"
+
+	// LoginFailureWindowEnv is the environment variable for the failed login counting window (e.g. "15m")
+	LoginFailureWindowEnv = "LOGIN_FAILURE_WINDOW"
+
+	// LoginLockoutDurationEnv is the environment variable for how long a username stays locked (e.g. "15m")
+	LoginLockoutDurationEnv = "LOGIN_LOCKOUT_DURATION"
+
+	// MFAFileName is the name of the two-factor authentication store in the data directory
+	MFAFileName = "mfa.json"
+
+	// MFAIssuer is the issuer shown by authenticator apps for TOTP codes
+	MFAIssuer = "Kafka UI"
+
+	// ClustersFileName is the name of the cluster definitions document in the data directory
+	ClustersFileName = "clusters.json"
+
+	// KafkaClientIdleTTLEnv is the environment variable for how long an unused Kafka client stays open (e.g. "10m")
+	KafkaClientIdleTTLEnv = "KAFKA_CLIENT_IDLE_TTL"
+
+	// BrokerPollIntervalEnv is the environment variable for how often broker liveness is polled in the background ("0" disables polling)
+	BrokerPollIntervalEnv = "BROKER_POLL_INTERVAL"
+
+	// KafkaTimeoutEnv is the environment variable for the default deadline of Kafka requests (e.g. "30s")
+	KafkaTimeoutEnv = "KAFKA_TIMEOUT"
+
+	// KafkaRouteTimeoutsEnv overrides the deadline of individual Kafka routes as comma-separated route=duration pairs
+	KafkaRouteTimeoutsEnv = "KAFKA_ROUTE_TIMEOUTS"
+
+	// SecretsKeyEnv is the passphrase used to encrypt stored credentials such as cluster SASL passwords
+	SecretsKeyEnv = "SECRETS_KEY"
+
+	// SecretsKeyFileName is the generated encryption key in the data directory, used when SECRETS_KEY is unset
+	SecretsKeyFileName = "secret.key"
+
+	// StoreBackendEnv selects the storage backend: file (default; users.csv and JSON files) or bolt
+	StoreBackendEnv = "STORE_BACKEND"
+
+	// StoreDBFileEnv overrides the location of the bolt database
+	StoreDBFileEnv = "STORE_DB_FILE"
+
+	// StoreDBFileName is the name of the bolt database in the data directory
+	StoreDBFileName = "kafka-ui.db"
+
+	// DefaultAuditMaxEntries is the number of audit entries kept by the bolt backend
+	DefaultAuditMaxEntries = 100000
+
 	// DefaultPort is the default port for the server
 	DefaultPort = "8080"
 
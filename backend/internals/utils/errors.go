@@ -18,4 +18,11 @@ var (
 	ErrBootstrapServerRequired  = errors.New("bootstrapServer parameter is required")
 	ErrTopicCreationFailed      = errors.New("failed to create topic")
 	ErrMessageProductionFailed  = errors.New("failed to produce message")
+	ErrDefaultCredentials       = errors.New("default admin credentials are still in use")
+	ErrUserExists               = errors.New("user already exists")
+	ErrInvalidRole              = errors.New("invalid role")
+	ErrUserDisabled             = errors.New("user is disabled")
+	ErrForbidden                = errors.New("insufficient permissions")
+	ErrInvalidPassword          = errors.New("invalid password")
+	ErrInvalidUsername          = errors.New("invalid username")
 )
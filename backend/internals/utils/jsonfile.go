@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// jsonfile.go - Provides helpers for small JSON documents persisted in the data directory.
+
+// DataFilePath returns the path of a file in the data directory.
+func DataFilePath(name string) string {
+	return filepath.Join(UsersDataDir, name)
+}
+
+// ReadJSONFile decodes the JSON file at path into v.
+// A missing file is not an error and leaves v unchanged.
+func ReadJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return nil
+}
+
+// WriteJSONFile encodes v as JSON and atomically replaces the file at path.
+// The file is created with owner-only permissions since it may hold credentials.
+func WriteJSONFile(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// password.go - Provides password hashing and verification helpers for stored user credentials.
+// Passwords are hashed with bcrypt; legacy plain-text values are still accepted so they can be
+// rehashed transparently on the next successful login.
+
+// missingUserHash is a bcrypt hash (at bcrypt.DefaultCost) no password matches. Checking passwords of
+// unknown users against it makes them take as long to reject as wrong passwords, so response times do
+// not reveal which usernames exist.
+const missingUserHash = "$2a$10$pBtJ.5zrOQIUdoF89FagROCcN4SH6t.1Cohca5pCJlQ0x/PDhHrsS"
+
+const (
+	MinPasswordLength = 6  // Minimum number of characters of a new password
+	MaxPasswordBytes  = 72 // bcrypt ignores (and newer versions reject) anything longer
+)
+
+// ValidatePassword checks a new password against the length limits, returning an error wrapping
+// ErrInvalidPassword. Existing passwords are not checked, so legacy accounts can still log in.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrInvalidPassword, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("%w: must be at most %d bytes long", ErrInvalidPassword, MaxPasswordBytes)
+	}
+	return nil
+}
+
+// HashPassword returns a bcrypt hash of the given plain-text password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// IsPasswordHash reports whether a stored password value is a bcrypt hash.
+func IsPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// VerifyPassword checks a plain-text password against a stored value.
+// It returns whether the password matches and whether the stored value is a legacy
+// plain-text password that should be rehashed. Both paths compare in constant time.
+func VerifyPassword(stored, password string) (ok bool, needsRehash bool) {
+	if IsPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+	}
+
+	// Legacy plain-text row: compare digests so the length of the stored value is not leaked
+	storedSum := sha256.Sum256([]byte(stored))
+	givenSum := sha256.Sum256([]byte(password))
+	if subtle.ConstantTimeCompare(storedSum[:], givenSum[:]) != 1 {
+		return false, false
+	}
+	return true, true
+}
+
+// RejectMissingUser spends the time of a password check on a login for a user that does not exist.
+// The password never matches.
+func RejectMissingUser(password string) {
+	_ = bcrypt.CompareHashAndPassword([]byte(missingUserHash), []byte(password))
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"empty", "", false},
+		{"too short", "abc12", false},
+		{"minimum length", "abc123", true},
+		{"multibyte characters count once", "pässwö", true},
+		{"bcrypt limit", strings.Repeat("a", MaxPasswordBytes), true},
+		{"over the bcrypt limit", strings.Repeat("a", MaxPasswordBytes+1), false},
+		{"multibyte over the bcrypt limit", strings.Repeat("ä", MaxPasswordBytes/2+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.password)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidPassword) {
+				t.Errorf("error %v, want %v", err, ErrInvalidPassword)
+			}
+		})
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		stored      string
+		password    string
+		ok          bool
+		needsRehash bool
+	}{
+		{"hash matches", hash, "s3cret-pass", true, false},
+		{"hash does not match", hash, "s3cret-pasS", false, false},
+		{"legacy plain text matches", "s3cret-pass", "s3cret-pass", true, true},
+		{"legacy plain text does not match", "s3cret-pass", "s3cret", false, false},
+		{"legacy empty value rejects a password", "", "s3cret-pass", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := VerifyPassword(tt.stored, tt.password)
+			if ok != tt.ok || needsRehash != tt.needsRehash {
+				t.Errorf("VerifyPassword() = %v, %v; want %v, %v", ok, needsRehash, tt.ok, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPasswordHash(hash) || IsPasswordHash("s3cret-pass") {
+		t.Error("IsPasswordHash does not tell hashes from plain text")
+	}
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordBytes+1)); err == nil {
+		t.Error("a password over the bcrypt limit was hashed")
+	}
+}
+
+func TestMissingUserHash(t *testing.T) {
+	// Rejecting an unknown user must cost as much as checking a real password
+	cost, err := bcrypt.Cost([]byte(missingUserHash))
+	if err != nil || cost != bcrypt.DefaultCost {
+		t.Fatalf("cost %d (%v), want %d", cost, err, bcrypt.DefaultCost)
+	}
+	for _, password := range []string{"", DefaultAdminPassword, "missing"} {
+		if ok, _ := VerifyPassword(missingUserHash, password); ok {
+			t.Errorf("%q matches the missing user hash", password)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+// roles.go - Defines the user roles understood by the backend.
+// Roles are ordered from least to most privileged.
+
+const (
+	RoleViewer   = "viewer"   // Can browse topics, messages, brokers and consumers
+	RoleProducer = "producer" // Viewer plus producing messages
+	RoleOperator = "operator" // Producer plus topic administration
+	RoleAdmin    = "admin"    // Full access, including user and cluster administration
+)
+
+// Roles lists all valid roles from least to most privileged.
+var Roles = []string{RoleViewer, RoleProducer, RoleOperator, RoleAdmin}
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// username.go - Defines which names local user accounts may have.
+// Usernames are keys of users.csv rows and bbolt buckets, so the charset excludes separators
+// such as commas, line breaks and the ':' external identities are namespaced with.
+
+const MaxUsernameLength = 64 // Maximum length of a local username
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+
+// ValidateUsername checks a new username against the charset rule, returning an error wrapping
+// ErrInvalidUsername. Names start with a letter or digit, followed by letters, digits, '.', '_', '@' or '-'.
+func ValidateUsername(username string) error {
+	if username == "" {
+		return fmt.Errorf("%w: must not be empty", ErrInvalidUsername)
+	}
+	if len(username) > MaxUsernameLength {
+		return fmt.Errorf("%w: must be at most %d characters long", ErrInvalidUsername, MaxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: may only contain letters, digits, '.', '_', '@' and '-', starting with a letter or digit", ErrInvalidUsername)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"admin", true},
+		{"alice.smith", true},
+		{"bob_2", true},
+		{"carol-ops", true},
+		{"dave@example.com", true},
+		{"7up", true},
+		{strings.Repeat("a", MaxUsernameLength), true},
+		{"", false},
+		{strings.Repeat("a", MaxUsernameLength+1), false},
+		{"alice,admin", false},
+		{"alice\nadmin,password,admin,false", false},
+		{"alice\r", false},
+		{"oidc:https://idp|admin", false},
+		{"alice smith", false},
+		{"alice\"", false},
+		{".alice", false},
+		{"-alice", false},
+		{"ålice", false},
+	}
+	for _, tt := range tests {
+		err := ValidateUsername(tt.username)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateUsername(%q) = %v, want nil", tt.username, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidUsername) {
+			t.Errorf("ValidateUsername(%q) = %v, want %v", tt.username, err, ErrInvalidUsername)
+		}
+	}
+}
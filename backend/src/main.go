@@ -1,11 +1,18 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
 	"backend/internals/api"
+	"backend/internals/audit"
+	"backend/internals/auth"
+	"backend/internals/cluster"
 	"backend/internals/middleware"
+	"backend/internals/policy"
+	"backend/internals/secrets"
+	"backend/internals/store"
 	"backend/internals/utils"
 
 	"github.com/gin-contrib/cors"
@@ -20,9 +27,97 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Initialize API and middleware
-	api.Initialize(nil)             // Initialize with nil since we don't have a default broker
-	middleware.SetKafkaService(nil) // Set nil initially
+	// Open the storage backend holding users, API keys, 2FA state and sessions
+	backend, err := store.OpenFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to open storage backend: %v", err)
+	}
+	store.Initialize(backend)
+	defer backend.Close()
+	log.Printf("Using %s storage backend", backend.Name)
+
+	// Refuse to start with the shipped admin/password account unless explicitly allowed
+	if err := store.CheckDefaultCredentials(store.Users()); err != nil {
+		if !errors.Is(err, utils.ErrDefaultCredentials) {
+			log.Fatalf("Failed to check user credentials: %v", err)
+		}
+		if os.Getenv(utils.InsecureDevEnv) != "true" {
+			log.Fatalf("%v: change the admin password or set %s=true for local development", err, utils.InsecureDevEnv)
+		}
+		log.Printf("WARNING: %v (allowed because %s=true)", err, utils.InsecureDevEnv)
+	}
+
+	// Load per-topic access policies, if configured
+	if err := policy.LoadDefault(); err != nil {
+		log.Fatalf("Failed to load topic policies: %v", err)
+	}
+
+	// Select the username/password authentication backend
+	authenticator, err := auth.NewAuthenticatorFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid authentication backend: %v", err)
+	}
+	api.InitializeAuthenticator(authenticator)
+
+	// Throttle repeated failed logins
+	loginGuardConfig, err := auth.LoginGuardConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid login rate limit configuration: %v", err)
+	}
+	api.InitializeLoginGuard(auth.NewLoginGuard(loginGuardConfig))
+
+	// Enable OIDC login when an issuer is configured
+	if oidcConfig, err := auth.OIDCConfigFromEnv(); err == nil {
+		api.InitializeOIDC(auth.NewOIDCProvider(oidcConfig))
+	} else if !errors.Is(err, auth.ErrOIDCNotConfigured) {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+
+	// Record mutating operations in the audit log, kept in the database when the backend provides one
+	auditSink := backend.Audit
+	if auditSink == nil {
+		auditLogger, err := audit.NewLoggerFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid audit log configuration: %v", err)
+		}
+		auditSink = auditLogger
+	}
+	audit.Initialize(auditSink)
+	defer auditSink.Close()
+
+	// Load the named cluster definitions; clients are pooled by connection parameters and closed when idle
+	secretsBox, err := secrets.NewBoxFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load secrets key: %v", err)
+	}
+	if os.Getenv(utils.SecretsKeyEnv) == "" {
+		log.Printf("WARNING: %s is not set; stored credentials are encrypted with data/%s, which is kept next to them", utils.SecretsKeyEnv, utils.SecretsKeyFileName)
+	}
+	idleTTL, err := cluster.IdleTTLFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid Kafka client pool configuration: %v", err)
+	}
+	clusterRegistry, err := cluster.NewRegistry(store.Documents(), secretsBox, cluster.NewPool(cluster.Connect, idleTTL))
+	if err != nil {
+		log.Fatalf("Failed to load cluster definitions: %v", err)
+	}
+	defer clusterRegistry.Close()
+	api.InitializeClusters(clusterRegistry)
+
+	// Probe broker liveness in the background and keep a status history for the UI
+	pollInterval, err := cluster.BrokerPollIntervalFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid broker monitoring configuration: %v", err)
+	}
+	brokerMonitor := cluster.NewBrokerMonitor(clusterRegistry, pollInterval)
+	defer brokerMonitor.Close()
+	api.InitializeBrokerMonitor(brokerMonitor)
+
+	// Deadlines of Kafka requests, per route
+	timeouts, err := middleware.TimeoutConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid Kafka timeout configuration: %v", err)
+	}
 
 	r := gin.Default()
 
@@ -30,29 +125,16 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", middleware.APIKeyHeader}
 	config.AllowCredentials = true
 	r.Use(cors.New(config))
+	r.Use(middleware.AuditMiddleware())
 
-	// Public routes (no bootstrap server required)
-	r.POST("/api/login", api.Login)
+	setupRoutes(r, timeouts, clusterRegistry)
 
-	// Protected routes that require bootstrap server configuration
-	apiRoutes := r.Group("/api")
-	apiRoutes.Use(middleware.JWTMiddleware())
-	apiRoutes.Use(middleware.BootstrapMiddleware())
-	{
-		apiRoutes.GET("/check-connection", api.CheckConnection)
-		apiRoutes.GET("/topics", api.GetTopics)
-		apiRoutes.GET("/topics/:name/messages", api.GetMessages)
-		apiRoutes.GET("/topics/:name/partitions", api.GetPartitionInfo)
-		apiRoutes.POST("/produce", api.ProduceMessage)
-		apiRoutes.DELETE("/topics/:name/messages", api.DeleteMessages)
-		apiRoutes.POST("/topics", api.CreateTopic)
-		apiRoutes.GET("/consumers", api.GetConsumers)
-		apiRoutes.GET("/brokers", api.GetBrokers)
-		apiRoutes.POST("/change-password", api.ChangePassword)
-		apiRoutes.DELETE("/topics/:name", api.DeleteTopic)
+	// Every protected route must have an entry in the permission table
+	if err := middleware.ValidateRoutePermissions(r.Routes()); err != nil {
+		log.Fatalf("Invalid route permissions: %v", err)
 	}
 
 	// Start server
@@ -64,3 +146,94 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// setupRoutes registers the public and protected API routes with their middleware on r.
+func setupRoutes(r *gin.Engine, timeouts middleware.TimeoutConfig, clusterRegistry *cluster.Registry) {
+	// Public routes
+	r.POST("/api/login", api.Login)
+	r.POST("/api/login/mfa", api.LoginMFA)
+	r.POST("/api/login/mfa/enroll", api.LoginMFAEnroll)
+	r.POST("/api/token/refresh", api.RefreshToken)
+	r.GET("/api/auth/providers", api.GetAuthProviders)
+	r.GET("/api/auth/oidc/login", api.OIDCLogin)
+	r.GET("/api/auth/oidc/callback", api.OIDCCallback)
+
+	// Protected routes
+	apiRoutes := r.Group("/api")
+	apiRoutes.Use(middleware.JWTMiddleware())
+	apiRoutes.Use(middleware.PermissionMiddleware())
+	apiRoutes.Use(middleware.TopicPolicyMiddleware())
+	{
+		apiRoutes.POST("/change-password", api.ChangePassword)
+		apiRoutes.POST("/logout", api.Logout)
+		apiRoutes.GET("/mfa", api.GetMFAStatus)
+		apiRoutes.POST("/mfa/enroll", api.EnrollMFA)
+		apiRoutes.POST("/mfa/activate", api.ActivateMFA)
+		apiRoutes.POST("/mfa/recovery-codes", api.RegenerateRecoveryCodes)
+		apiRoutes.POST("/mfa/disable", api.DisableMFA)
+		apiRoutes.GET("/api-keys", api.ListAPIKeys)
+		apiRoutes.POST("/api-keys", api.CreateAPIKey)
+		apiRoutes.DELETE("/api-keys/:id", api.DeleteAPIKey)
+	}
+
+	// Cluster registry
+	apiRoutes.GET("/clusters", api.ListClusters)
+	apiRoutes.POST("/clusters", api.CreateCluster)
+	apiRoutes.GET("/clusters/:cluster", api.GetCluster)
+	apiRoutes.PUT("/clusters/:cluster", api.UpdateCluster)
+	apiRoutes.DELETE("/clusters/:cluster", api.DeleteCluster)
+	apiRoutes.GET("/clusters/:cluster/brokers/history", api.GetBrokerHistory)
+
+	// Kafka routes, served per cluster
+	clusterRoutes := apiRoutes.Group("/clusters/:cluster")
+	clusterRoutes.Use(middleware.TimeoutMiddleware(timeouts))
+	clusterRoutes.Use(middleware.ClusterMiddleware(clusterRegistry))
+	{
+		clusterRoutes.GET("/check-connection", api.CheckConnection)
+		clusterRoutes.GET("/capabilities", api.GetCapabilities)
+		clusterRoutes.GET("/overview", api.GetClusterOverview)
+		clusterRoutes.GET("/topics", api.GetTopics)
+		clusterRoutes.GET("/topics/:name/messages", api.GetMessages)
+		clusterRoutes.GET("/topics/:name/partitions", api.GetPartitionInfo)
+		clusterRoutes.POST("/topics/:name/partitions", api.AddPartitions)
+		clusterRoutes.GET("/topics/:name/config", api.GetTopicConfig)
+		clusterRoutes.PUT("/topics/:name/config", api.UpdateTopicConfig)
+		clusterRoutes.POST("/produce", api.ProduceMessage)
+		clusterRoutes.DELETE("/topics/:name/messages", api.DeleteMessages)
+		clusterRoutes.POST("/topics", api.CreateTopic)
+		clusterRoutes.GET("/consumers", api.GetConsumers)
+		clusterRoutes.GET("/brokers", api.GetBrokers)
+		clusterRoutes.GET("/brokers/:id/config", api.GetBrokerConfig)
+		clusterRoutes.PUT("/brokers/:id/config", api.UpdateBrokerConfig)
+		clusterRoutes.GET("/log-dirs", api.GetLogDirs)
+		clusterRoutes.GET("/largest-topics", api.GetLargestTopics)
+		clusterRoutes.POST("/reassignments/plan", api.PlanReassignment)
+		clusterRoutes.POST("/reassignments", api.ExecuteReassignment)
+		clusterRoutes.GET("/reassignments", api.GetReassignments)
+		clusterRoutes.POST("/reassignments/cancel", api.CancelReassignments)
+		clusterRoutes.DELETE("/reassignments/throttle", api.RemoveReplicationThrottle)
+		clusterRoutes.POST("/leader-elections/preferred", api.ElectPreferredLeaders)
+		clusterRoutes.POST("/leader-elections/unclean", api.ElectUncleanLeaders)
+		clusterRoutes.DELETE("/topics/:name", api.DeleteTopic)
+	}
+
+	// Admin-only user management routes
+	userRoutes := apiRoutes.Group("/users")
+	{
+		userRoutes.GET("", api.ListUsers)
+		userRoutes.POST("", api.CreateUser)
+		userRoutes.PUT("/:username", api.UpdateUser)
+		userRoutes.DELETE("/:username", api.DeleteUser)
+		userRoutes.POST("/:username/reset-password", api.ResetUserPassword)
+		userRoutes.DELETE("/:username/mfa", api.ResetUserMFA)
+	}
+
+	// Admin-only audit log, login lockouts, 2FA policy and Kafka connection pool
+	apiRoutes.GET("/audit", api.GetAuditLog)
+	apiRoutes.GET("/mfa/policy", api.GetMFAPolicy)
+	apiRoutes.PUT("/mfa/policy", api.UpdateMFAPolicy)
+	apiRoutes.GET("/login-lockouts", api.ListLoginLockouts)
+	apiRoutes.DELETE("/login-lockouts/users/:username", api.ClearUserLockout)
+	apiRoutes.DELETE("/login-lockouts/ips/:ip", api.ClearIPLockout)
+	apiRoutes.GET("/connections", api.GetConnectionPool)
+}
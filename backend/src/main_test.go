@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"backend/internals/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesMatchPermissionTable checks that every registered API route has a permission or is
+// public, and that the permission table has no entries for routes that do not exist.
+func TestRoutesMatchPermissionTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setupRoutes(r, middleware.TimeoutConfig{}, nil)
+
+	if err := middleware.ValidateRoutePermissions(r.Routes()); err != nil {
+		t.Fatal(err)
+	}
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for route := range middleware.RoutePermissions {
+		if !registered[route] {
+			t.Errorf("RoutePermissions has an entry for unregistered route %s", route)
+		}
+	}
+	for route := range middleware.PublicRoutes {
+		if !registered[route] {
+			t.Errorf("PublicRoutes has an entry for unregistered route %s", route)
+		}
+	}
+}
@@ -32,7 +32,7 @@ import {
   Brightness7 as Brightness7Icon
 } from '@mui/icons-material';
 import Tooltip from '@mui/material/Tooltip';
-import API from './api';
+import API, { setAuthTokens, clearAuthTokens, setCluster, clusterPath } from './api';
 import { TopicsSection } from './components/TopicsSection';
 import { OverviewSection } from './components/OverviewSection';
 import { BrokersSection } from './components/BrokersSection';
@@ -69,10 +69,14 @@ function App() {
   const [brokers, setBrokers] = useState([]);
   const [consumers, setConsumers] = useState([]);
   const [loginError, setLoginError] = useState(null);
+  // Second login step for accounts with two-factor authentication
+  const [mfaChallenge, setMfaChallenge] = useState(null);
+  const [mfaCode, setMfaCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState(null);
   const [isConfigured, setIsConfigured] = useState(false);
   const [changePasswordOpen, setChangePasswordOpen] = useState(false);
   const [currentToken, setCurrentToken] = useState(null);
-  const [currentBootstrapServer, setCurrentBootstrapServer] = useState(null);
+  const [currentCluster, setCurrentCluster] = useState(null);
   const [mode, setMode] = useState('light');
   const colorMode = {
     toggleColorMode: () => {
@@ -91,7 +95,7 @@ function App() {
       setLoading(true);
       setError(null);
       const limit = messageLimit === 'all' ? 1000 : messageLimit;
-      const res = await API.get(`/topics/${selectedTopic}/messages?limit=${limit}&sort=${sortOrder || 'newest'}`);
+      const res = await API.get(clusterPath(`/topics/${encodeURIComponent(selectedTopic)}/messages?limit=${limit}&sort=${sortOrder || 'newest'}`));
       
       const formattedMessages = Array.isArray(res.data) ? res.data.map((msg, index) => {
         const messageObj = typeof msg === 'string' ? { value: msg } : msg;
@@ -139,9 +143,7 @@ function App() {
     try {
       setLoading(true);
       setError(null);
-      const res = await API.get('/brokers', {
-        params: { bootstrapServer: currentBootstrapServer }
-      });
+      const res = await API.get(clusterPath('/brokers'));
       setBrokers(res.data);
     } catch (err) {
       setError('Error fetching brokers: ' + err.message);
@@ -154,9 +156,7 @@ function App() {
     try {
       setLoading(true);
       setError(null);
-      const res = await API.get('/consumers', {
-        params: { bootstrapServer: currentBootstrapServer }
-      });
+      const res = await API.get(clusterPath('/consumers'));
       setConsumers(res.data || []);
     } catch (err) {
       setError('Error fetching consumers: ' + err.message);
@@ -172,13 +172,18 @@ function App() {
     setLoginOpen(true);
     setIsConfigured(false);
     setCurrentToken(null);
-    setCurrentBootstrapServer(null);
+    setCurrentCluster(null);
   }, []);
 
   const handleLogout = () => {
+    // Revoke the session on the server; the local state is cleared regardless
+    const authorization = API.defaults.headers.common['Authorization'];
+    const refreshToken = clearAuthTokens();
+    if (authorization) {
+      API.post('/logout', { refreshToken }, { headers: { Authorization: authorization } }).catch(() => {});
+    }
     setCurrentToken(null);
-    setCurrentBootstrapServer(null);
-    delete API.defaults.headers.common['Authorization'];
+    setCurrentCluster(null);
     setIsLoggedIn(false);
     setLoginOpen(true);
     setTopics([]);
@@ -191,34 +196,68 @@ function App() {
     setSelectedSection('config');
   };
 
+  const completeLogin = (data) => {
+    setCurrentToken(data.token);
+    setAuthTokens(data);
+    setIsLoggedIn(true);
+    setLoginOpen(false);
+    setMfaChallenge(null);
+    setMfaCode('');
+    // Recovery codes are only shown once, right after enrolling during login
+    setRecoveryCodes(data.recoveryCodes || null);
+
+    // Always start with configuration section after login
+    setIsConfigured(false);
+    setSelectedSection('config');
+  };
+
   const handleLogin = async () => {
     try {
       setLoading(true);
       setError(null);
       setLoginError(null);
       const response = await API.post('/login', loginData);
-      const token = response.data.token;
-      setCurrentToken(token);
-      API.defaults.headers.common['Authorization'] = `Bearer ${token}`;
-      setIsLoggedIn(true);
-      setLoginOpen(false);
-      
-      // Always start with configuration section after login
-      setIsConfigured(false);
-      setSelectedSection('config');
+      if (response.data.mfaRequired) {
+        setMfaChallenge({ token: response.data.mfaToken });
+        return;
+      }
+      if (response.data.mfaEnrollmentRequired) {
+        // The role requires 2FA: enroll now, then confirm with the first code
+        const enrollment = await API.post('/login/mfa/enroll', { mfaToken: response.data.mfaToken });
+        setMfaChallenge({ token: response.data.mfaToken, ...enrollment.data });
+        return;
+      }
+      completeLogin(response.data);
     } catch (err) {
-      setLoginError('Invalid username or password');
+      setLoginError(err.response?.status === 429 ? err.response.data.error : 'Invalid username or password');
       handleLogout();
     } finally {
       setLoading(false);
     }
   };
 
+  const handleMfaLogin = async () => {
+    try {
+      setLoading(true);
+      setLoginError(null);
+      const response = await API.post('/login/mfa', { mfaToken: mfaChallenge.token, code: mfaCode });
+      completeLogin(response.data);
+    } catch (err) {
+      setLoginError(err.response?.data?.error || 'Invalid authentication code');
+      if (err.response?.data?.error === 'Invalid or expired challenge') {
+        setMfaChallenge(null);
+      }
+    } finally {
+      setLoading(false);
+      setMfaCode('');
+    }
+  };
+
   const fetchTopics = async () => {
     try {
       setLoading(true);
       setError(null);
-      const res = await API.get('/topics');
+      const res = await API.get(clusterPath('/topics?withSize=true'));
       setTopics(res.data);
     } catch (err) {
       setError('Error fetching topics: ' + err.message);
@@ -231,7 +270,7 @@ function App() {
     try {
       setLoading(true);
       setError(null);
-      await API.post('/topics', topicData);
+      await API.post(clusterPath('/topics'), topicData);
       await fetchTopics();
     } catch (err) {
       setError('Error creating topic: ' + err.message);
@@ -248,7 +287,7 @@ function App() {
     try {
       setLoading(true);
       setError(null);
-      await API.delete(`/topics/${selectedTopic}`);
+      await API.delete(clusterPath(`/topics/${encodeURIComponent(selectedTopic)}`));
       setSelectedTopic('');
       setMessages([]);
       await fetchTopics();
@@ -268,7 +307,7 @@ function App() {
       setError(null);
       console.log('Deleting messages for topic:', selectedTopic);
       console.log('Token:', currentToken);
-      const response = await API.delete(`/topics/${selectedTopic}/messages`);
+      const response = await API.delete(clusterPath(`/topics/${encodeURIComponent(selectedTopic)}/messages`));
       console.log('Delete response:', response);
       setMessages([]);
     } catch (err) {
@@ -293,7 +332,7 @@ function App() {
         partition: formData.partition
       };
       console.log('Sending message:', messageData);
-      await API.post('/produce', messageData);
+      await API.post(clusterPath('/produce'), messageData);
       await loadMessages();
     } catch (err) {
       setError('Error sending message: ' + err.message);
@@ -323,8 +362,8 @@ function App() {
     setSortOrder(event.target.value);
   };
 
-  const handleBootstrapChange = async (newBootstrapServer) => {
-    // Reset state when bootstrap server changes
+  const handleClusterChange = async (clusterName) => {
+    // Reset state when the selected cluster changes
     setTopics([]);
     setMessages([]);
     setBrokers([]);
@@ -332,7 +371,8 @@ function App() {
     setSelectedTopic('');
     setAutoRefresh(false);
     setError(null);
-    setCurrentBootstrapServer(newBootstrapServer);
+    setCluster(clusterName);
+    setCurrentCluster(clusterName);
     
     try {
       // Try to connect and fetch initial data
@@ -476,7 +516,7 @@ function App() {
               )}
               
               {selectedSection === 'config' && (
-                <BootstrapConfig onConfigChange={handleBootstrapChange} />
+                <BootstrapConfig currentCluster={currentCluster} onConfigChange={handleClusterChange} />
               )}
               
               {selectedSection === 'overview' && isConfigured && (
@@ -490,6 +530,7 @@ function App() {
               {selectedSection === 'brokers' && isConfigured && (
                 <BrokersSection
                   brokers={brokers}
+                  topics={topics}
                   onRefresh={fetchBrokers}
                 />
               )}
@@ -606,48 +647,116 @@ function App() {
                 {loginError}
               </Alert>
             )}
-            <TextField
-              autoFocus
-              margin="dense"
-              label="Username"
-              fullWidth
-              value={loginData.username}
-              onChange={(e) => {
-                setLoginData({ ...loginData, username: e.target.value });
-                setLoginError(null);
-              }}
-              onKeyPress={(e) => {
-                if (e.key === 'Enter') {
-                  handleLogin();
-                }
-              }}
-              error={!!loginError}
-            />
-            <TextField
-              margin="dense"
-              label="Password"
-              type="password"
-              fullWidth
-              value={loginData.password}
-              onChange={(e) => {
-                setLoginData({ ...loginData, password: e.target.value });
-                setLoginError(null);
-              }}
-              onKeyPress={(e) => {
-                if (e.key === 'Enter') {
-                  handleLogin();
-                }
-              }}
-              error={!!loginError}
-            />
+            {mfaChallenge ? (
+              <>
+                {mfaChallenge.secret && (
+                  <Box sx={{ mb: 2 }}>
+                    <Typography variant="body2" gutterBottom>
+                      Two-factor authentication is required for your account. Add this key to your authenticator app, then enter the code it shows.
+                    </Typography>
+                    <Typography variant="body2" sx={{ fontFamily: 'monospace', wordBreak: 'break-all' }}>
+                      {mfaChallenge.secret}
+                    </Typography>
+                    <Typography variant="caption" sx={{ wordBreak: 'break-all' }}>
+                      {mfaChallenge.otpauthUri}
+                    </Typography>
+                  </Box>
+                )}
+                <TextField
+                  autoFocus
+                  margin="dense"
+                  label="Authentication code or recovery code"
+                  fullWidth
+                  value={mfaCode}
+                  onChange={(e) => {
+                    setMfaCode(e.target.value);
+                    setLoginError(null);
+                  }}
+                  onKeyPress={(e) => {
+                    if (e.key === 'Enter' && mfaCode) {
+                      handleMfaLogin();
+                    }
+                  }}
+                  error={!!loginError}
+                />
+              </>
+            ) : (
+              <>
+                <TextField
+                  autoFocus
+                  margin="dense"
+                  label="Username"
+                  fullWidth
+                  value={loginData.username}
+                  onChange={(e) => {
+                    setLoginData({ ...loginData, username: e.target.value });
+                    setLoginError(null);
+                  }}
+                  onKeyPress={(e) => {
+                    if (e.key === 'Enter') {
+                      handleLogin();
+                    }
+                  }}
+                  error={!!loginError}
+                />
+                <TextField
+                  margin="dense"
+                  label="Password"
+                  type="password"
+                  fullWidth
+                  value={loginData.password}
+                  onChange={(e) => {
+                    setLoginData({ ...loginData, password: e.target.value });
+                    setLoginError(null);
+                  }}
+                  onKeyPress={(e) => {
+                    if (e.key === 'Enter') {
+                      handleLogin();
+                    }
+                  }}
+                  error={!!loginError}
+                />
+              </>
+            )}
           </DialogContent>
           <DialogActions>
-            <Button 
-              onClick={handleLogin} 
-              variant="contained"
-              disabled={!loginData.username || !loginData.password}
-            >
-              Login
+            {mfaChallenge ? (
+              <>
+                <Button onClick={() => { setMfaChallenge(null); setLoginError(null); }}>
+                  Back
+                </Button>
+                <Button onClick={handleMfaLogin} variant="contained" disabled={!mfaCode}>
+                  Verify
+                </Button>
+              </>
+            ) : (
+              <Button 
+                onClick={handleLogin} 
+                variant="contained"
+                disabled={!loginData.username || !loginData.password}
+              >
+                Login
+              </Button>
+            )}
+          </DialogActions>
+        </Dialog>
+
+        {/* Recovery codes issued when enrolling in 2FA during login */}
+        <Dialog open={!!recoveryCodes} onClose={() => setRecoveryCodes(null)}>
+          <DialogTitle>Save your recovery codes</DialogTitle>
+          <DialogContent>
+            <Typography variant="body2" gutterBottom>
+              Each code can be used once instead of an authentication code if you lose your device. They will not be shown again.
+            </Typography>
+            {(recoveryCodes || []).map((code) => (
+              <Typography key={code} variant="body2" sx={{ fontFamily: 'monospace' }}>
+                {code}
+              </Typography>
+            ))}
+          </DialogContent>
+          <DialogActions>
+            <Button onClick={() => setRecoveryCodes(null)} variant="contained">
+              Done
             </Button>
           </DialogActions>
         </Dialog>
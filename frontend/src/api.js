@@ -11,10 +11,68 @@ const API = axios.create({
   }
 });
 
+// Name of the cluster the dashboard is working with; Kafka routes are served per cluster
+let currentCluster = null;
+
+// setCluster selects the cluster used by clusterPath.
+export const setCluster = (name) => {
+  currentCluster = name;
+};
+
+// clusterPath prefixes a Kafka route with the selected cluster, e.g. /topics -> /clusters/prod/topics.
+export const clusterPath = (path) => `/clusters/${encodeURIComponent(currentCluster)}${path}`;
+
+// Refresh token for the current session; access tokens are short-lived
+let refreshToken = null;
+let refreshRequest = null;
+
+// setAuthTokens stores the tokens returned by login, refresh or password change.
+export const setAuthTokens = ({ token, refreshToken: newRefreshToken }) => {
+  API.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+  refreshToken = newRefreshToken || null;
+};
+
+// clearAuthTokens forgets the current session, returning its refresh token.
+export const clearAuthTokens = () => {
+  const previous = refreshToken;
+  delete API.defaults.headers.common['Authorization'];
+  refreshToken = null;
+  return previous;
+};
+
+// refreshSession exchanges the refresh token once, even if several requests fail together.
+const refreshSession = () => {
+  if (!refreshRequest) {
+    refreshRequest = axios
+      .post(`${API.defaults.baseURL}/token/refresh`, { refreshToken })
+      .then((response) => {
+        setAuthTokens(response.data);
+        return response.data.token;
+      })
+      .finally(() => {
+        refreshRequest = null;
+      });
+  }
+  return refreshRequest;
+};
+
 // Handle response errors
 API.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const original = error.config;
+    // Retry once with a fresh access token when the current one has expired
+    if (error.response && error.response.status === 401 && refreshToken && original && !original._retried) {
+      original._retried = true;
+      try {
+        const token = await refreshSession();
+        original.headers['Authorization'] = `Bearer ${token}`;
+        return API(original);
+      } catch (refreshError) {
+        clearAuthTokens();
+      }
+    }
+
     if (error.response) {
       // The request was made and the server responded with a status code
       // that falls out of the range of 2xx
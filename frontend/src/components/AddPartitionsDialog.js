@@ -0,0 +1,80 @@
+import React, { useEffect, useState } from 'react';
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Button,
+  TextField,
+  Alert
+} from '@mui/material';
+import API, { clusterPath } from '../api';
+
+// AddPartitionsDialog.js - Raises the partition count of a topic, showing the warnings of a validate-only run first.
+
+export const AddPartitionsDialog = ({ open, onClose, topic, currentCount, onAdded }) => {
+  const [partitionCount, setPartitionCount] = useState(currentCount + 1);
+  const [result, setResult] = useState(null);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    if (!open) return;
+    setPartitionCount(currentCount + 1);
+    setResult(null);
+    setError(null);
+  }, [open, currentCount]);
+
+  const submit = async (validateOnly) => {
+    setError(null);
+    try {
+      const res = await API.post(clusterPath(`/topics/${encodeURIComponent(topic)}/partitions`), {
+        partitionCount: parseInt(partitionCount),
+        validateOnly,
+        // The keyed message warning was already shown by the validate run
+        keysAcknowledged: !validateOnly && Boolean(result?.validateOnly && result.keysChecked)
+      });
+      setResult(res.data);
+      if (!validateOnly) {
+        onAdded(res.data.partitionCount);
+      }
+    } catch (err) {
+      setError(err.response?.data?.error || err.message);
+    }
+  };
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+      <DialogTitle>Add Partitions to {topic}</DialogTitle>
+      <DialogContent>
+        {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
+        {result?.warnings.map((warning) => (
+          <Alert key={warning} severity="warning" sx={{ mb: 2 }}>{warning}</Alert>
+        ))}
+        {result && (
+          <Alert severity="success" sx={{ mb: 2 }}>
+            {result.validateOnly
+              ? `The topic can grow from ${result.previousCount} to ${result.partitionCount} partitions.`
+              : `The topic now has ${result.partitionCount} partitions.`}
+          </Alert>
+        )}
+        <TextField
+          label="New Partition Count"
+          type="number"
+          value={partitionCount}
+          onChange={(e) => setPartitionCount(e.target.value)}
+          inputProps={{ min: currentCount + 1, step: 1 }}
+          fullWidth
+          sx={{ mt: 1 }}
+          helperText={`The topic has ${currentCount} partitions; partitions cannot be removed later`}
+        />
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>Close</Button>
+        <Button onClick={() => submit(true)}>Validate</Button>
+        <Button onClick={() => submit(false)} variant="contained">
+          Add Partitions
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
@@ -1,4 +1,4 @@
-import React, { useState } from 'react';
+import React, { useCallback, useEffect, useState } from 'react';
 import {
   Box,
   TextField,
@@ -6,46 +6,59 @@ import {
   Paper,
   Typography,
   Alert,
-  CircularProgress
+  CircularProgress,
+  FormControl,
+  InputLabel,
+  Select,
+  MenuItem,
+  FormControlLabel,
+  Checkbox
 } from '@mui/material';
-import { Save as SaveIcon } from '@mui/icons-material';
+import { Save as SaveIcon, Link as LinkIcon } from '@mui/icons-material';
 import API from '../api';
 
-// BootstrapConfig.js - Provides the UI for configuring the Kafka bootstrap server in the dashboard.
+// BootstrapConfig.js - Provides the UI for choosing a named Kafka cluster and adding new clusters.
 
-export const BootstrapConfig = ({ onConfigChange }) => {
+export const BootstrapConfig = ({ currentCluster, onConfigChange }) => {
+  const [clusters, setClusters] = useState([]);
+  const [selectedCluster, setSelectedCluster] = useState(currentCluster || '');
+  const [newClusterName, setNewClusterName] = useState('');
   const [bootstrapServer, setBootstrapServer] = useState('');
+  const [saslMechanism, setSaslMechanism] = useState('');
+  const [saslUsername, setSaslUsername] = useState('');
+  const [saslPassword, setSaslPassword] = useState('');
+  const [useTls, setUseTls] = useState(false);
   const [error, setError] = useState(null);
   const [success, setSuccess] = useState(false);
   const [isChecking, setIsChecking] = useState(false);
 
-  const checkConnection = async () => {
+  const loadClusters = useCallback(async () => {
+    try {
+      const response = await API.get('/clusters');
+      setClusters(response.data || []);
+    } catch (err) {
+      setError('Failed to load clusters: ' + (err.response?.data?.error || err.message));
+    }
+  }, []);
+
+  useEffect(() => {
+    loadClusters();
+  }, [loadClusters]);
+
+  const checkConnection = async (name) => {
     try {
       setIsChecking(true);
       setError(null);
       setSuccess(false);
 
-      // Validate the address format
-      if (!bootstrapServer.includes(':')) {
-        setError('Invalid address format. Please use host:port format (e.g., localhost:9092)');
-        return false;
-      }
-
-      console.log('Checking connection for:', bootstrapServer);
-
-      // Update API base URL with the new bootstrap server
-      API.defaults.baseURL = `http://localhost:8080/api`;
+      // Test connection using the dedicated endpoint of the cluster
+      const response = await API.get(`/clusters/${encodeURIComponent(name)}/check-connection`);
 
-      // Test connection using the dedicated endpoint
-      const response = await API.get('/check-connection', {
-        params: { bootstrapServer }
-      });
-      
       if (response.data.status === 'connected') {
         setSuccess(true);
-        
+
         if (onConfigChange) {
-          onConfigChange(bootstrapServer);
+          onConfigChange(name);
         }
         return true;
       } else {
@@ -54,15 +67,12 @@ export const BootstrapConfig = ({ onConfigChange }) => {
       }
     } catch (err) {
       console.error('Connection check error:', err);
-      // Handle specific error cases
-      if (err.response?.status === 503) {
-        // Service Unavailable - Connection refused or timeout
+      if (err.response?.status === 502) {
+        // Bad Gateway - the brokers could not be reached
         setError(err.response.data.error || 'Failed to connect to Kafka broker. Please check if Kafka is running and the address is correct.');
-      } else if (err.response?.status === 400) {
-        // Bad Request - Invalid format
-        setError(err.response.data.error || 'Invalid broker address format');
+      } else if (err.response?.status === 404) {
+        setError('Cluster not found');
       } else {
-        // Other errors
         setError('Failed to connect to Kafka cluster: ' + (err.response?.data?.error || err.message));
       }
       return false;
@@ -71,46 +81,150 @@ export const BootstrapConfig = ({ onConfigChange }) => {
     }
   };
 
-  const handleSave = async () => {
-    const isConnected = await checkConnection();
-    if (isConnected) {
-      // The connection was successful and onConfigChange was called
-      // Additional success handling can be done here if needed
+  const handleConnect = async () => {
+    if (selectedCluster) {
+      await checkConnection(selectedCluster);
     }
   };
 
-  const handleBootstrapServerChange = (e) => {
-    const newValue = e.target.value;
-    setBootstrapServer(newValue);
-    // Reset states when bootstrap server changes
+  const handleAddCluster = async () => {
     setError(null);
     setSuccess(false);
+
+    // Validate the address format
+    const servers = bootstrapServer.split(',').map((s) => s.trim()).filter(Boolean);
+    if (servers.length === 0 || servers.some((s) => !s.includes(':'))) {
+      setError('Invalid address format. Please use host:port format (e.g., localhost:9092)');
+      return;
+    }
+
+    try {
+      setIsChecking(true);
+      const definition = { name: newClusterName.trim(), bootstrapServers: servers };
+      if (saslMechanism) {
+        definition.sasl = { mechanism: saslMechanism, username: saslUsername, password: saslPassword };
+      }
+      if (useTls) {
+        definition.tls = {};
+      }
+      await API.post('/clusters', definition);
+    } catch (err) {
+      setError('Failed to add cluster: ' + (err.response?.data?.error || err.message));
+      setIsChecking(false);
+      return;
+    }
+    const name = newClusterName.trim();
+    setNewClusterName('');
+    setBootstrapServer('');
+    setSaslMechanism('');
+    setSaslUsername('');
+    setSaslPassword('');
+    setUseTls(false);
+    setSelectedCluster(name);
+    await loadClusters();
+    await checkConnection(name);
   };
 
   return (
     <Box sx={{ p: 3 }}>
       <Paper sx={{ p: 3 }}>
         <Typography variant="h6" gutterBottom>
-          Kafka Bootstrap Server Configuration
+          Kafka Cluster
+        </Typography>
+        <Box sx={{ display: 'flex', gap: 2, alignItems: 'center' }}>
+          <FormControl fullWidth disabled={isChecking || clusters.length === 0}>
+            <InputLabel>Cluster</InputLabel>
+            <Select
+              value={selectedCluster}
+              label="Cluster"
+              onChange={(e) => {
+                setSelectedCluster(e.target.value);
+                setError(null);
+                setSuccess(false);
+              }}
+            >
+              {clusters.map((cluster) => (
+                <MenuItem key={cluster.name} value={cluster.name}>
+                  {cluster.name} ({cluster.bootstrapServers.join(', ')})
+                </MenuItem>
+              ))}
+            </Select>
+          </FormControl>
+          <Button
+            variant="contained"
+            startIcon={isChecking ? <CircularProgress size={20} color="inherit" /> : <LinkIcon />}
+            onClick={handleConnect}
+            sx={{ minWidth: 140 }}
+            disabled={isChecking || !selectedCluster}
+          >
+            {isChecking ? 'Checking...' : 'Connect'}
+          </Button>
+        </Box>
+
+        <Typography variant="subtitle1" sx={{ mt: 3, mb: 1 }}>
+          Add Cluster
         </Typography>
         <Box sx={{ display: 'flex', flexDirection: 'column', gap: 2 }}>
           <TextField
-            label="Bootstrap Server"
+            label="Name"
+            value={newClusterName}
+            onChange={(e) => setNewClusterName(e.target.value)}
+            placeholder="e.g., production"
+            fullWidth
+            disabled={isChecking}
+          />
+          <TextField
+            label="Bootstrap Servers"
             value={bootstrapServer}
-            onChange={handleBootstrapServerChange}
+            onChange={(e) => setBootstrapServer(e.target.value)}
             placeholder="e.g., localhost:9092"
             fullWidth
-            helperText="Enter the bootstrap server address (host:port)"
+            helperText="Comma-separated broker addresses (host:port). Adding clusters requires the admin role."
             disabled={isChecking}
           />
+          <Box sx={{ display: 'flex', gap: 2, alignItems: 'center' }}>
+            <FormControl sx={{ minWidth: 200 }} disabled={isChecking}>
+              <InputLabel>SASL</InputLabel>
+              <Select
+                value={saslMechanism}
+                label="SASL"
+                onChange={(e) => setSaslMechanism(e.target.value)}
+              >
+                <MenuItem value="">None</MenuItem>
+                <MenuItem value="PLAIN">PLAIN</MenuItem>
+                <MenuItem value="SCRAM-SHA-256">SCRAM-SHA-256</MenuItem>
+                <MenuItem value="SCRAM-SHA-512">SCRAM-SHA-512</MenuItem>
+              </Select>
+            </FormControl>
+            <TextField
+              label="Username"
+              value={saslUsername}
+              onChange={(e) => setSaslUsername(e.target.value)}
+              fullWidth
+              disabled={isChecking || !saslMechanism}
+            />
+            <TextField
+              label="Password"
+              type="password"
+              value={saslPassword}
+              onChange={(e) => setSaslPassword(e.target.value)}
+              fullWidth
+              disabled={isChecking || !saslMechanism}
+            />
+            <FormControlLabel
+              control={<Checkbox checked={useTls} onChange={(e) => setUseTls(e.target.checked)} />}
+              label="TLS"
+              disabled={isChecking}
+            />
+          </Box>
           <Button
-            variant="contained"
-            startIcon={isChecking ? <CircularProgress size={20} color="inherit" /> : <SaveIcon />}
-            onClick={handleSave}
-            sx={{ minWidth: 120 }}
-            disabled={isChecking}
+            variant="outlined"
+            startIcon={<SaveIcon />}
+            onClick={handleAddCluster}
+            sx={{ minWidth: 120, alignSelf: 'flex-start' }}
+            disabled={isChecking || !newClusterName.trim() || !bootstrapServer.trim()}
           >
-            {isChecking ? 'Checking...' : 'Save'}
+            Save
           </Button>
         </Box>
         {error && (
@@ -126,4 +240,4 @@ export const BootstrapConfig = ({ onConfigChange }) => {
       </Paper>
     </Box>
   );
-}; 
\ No newline at end of file
+};
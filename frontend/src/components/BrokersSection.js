@@ -1,4 +1,4 @@
-import React from 'react';
+import React, { useEffect, useState } from 'react';
 import {
   Box,
   Typography,
@@ -10,24 +10,91 @@ import {
   TableContainer,
   TableHead,
   TableRow,
-  Tooltip
+  Tooltip,
+  Chip,
+  Alert
 } from '@mui/material';
 import { Refresh as RefreshIcon } from '@mui/icons-material';
+import { ConfigDialog } from './ConfigDialog';
+import { ReassignmentDialog } from './ReassignmentDialog';
+import API, { clusterPath } from '../api';
+import { formatBytes } from '../format';
 
 // BrokersSection.js - Displays a list of Kafka brokers and their details in the dashboard.
-export const BrokersSection = ({ brokers, onRefresh }) => {
+const statusColors = { online: 'success', degraded: 'warning', unreachable: 'error' };
+
+export const BrokersSection = ({ brokers, topics, onRefresh }) => {
+  const [configBrokerId, setConfigBrokerId] = useState(null);
+  const [drainBrokerId, setDrainBrokerId] = useState(null);
+  const [diskUsage, setDiskUsage] = useState({});
+  const [reassignments, setReassignments] = useState([]);
+  const [reassignmentError, setReassignmentError] = useState(null);
+  const [election, setElection] = useState(null);
+
+  // Reassignments need Kafka 2.4; older clusters simply show none
+  const fetchReassignments = () => {
+    API.get(clusterPath('/reassignments'))
+      .then((res) => setReassignments(res.data))
+      .catch(() => setReassignments([]));
+  };
+
+  const reassignmentAction = async (request) => {
+    setReassignmentError(null);
+    try {
+      await request();
+      fetchReassignments();
+    } catch (err) {
+      setReassignmentError(err.response?.data?.error || err.message);
+    }
+  };
+
+  // Disk usage comes from the log directories; clusters before Kafka 1.0 cannot report it
+  useEffect(() => {
+    API.get(clusterPath('/log-dirs'))
+      .then((res) => setDiskUsage(Object.fromEntries(
+        res.data.filter((broker) => !broker.error).map((broker) => [broker.brokerId, broker.sizeBytes])
+      )))
+      .catch(() => setDiskUsage({}));
+    fetchReassignments();
+  }, [brokers]);
+
+  // Moves leadership back to the preferred replicas, e.g. after a broker restart
+  const electPreferredLeaders = async () => {
+    try {
+      const res = await API.post(clusterPath('/leader-elections/preferred'));
+      const partitions = res.data.partitions;
+      const failed = partitions.filter((partition) => partition.error);
+      setElection({
+        severity: failed.length ? 'warning' : 'success',
+        message: `Leadership moved for ${partitions.filter((partition) => partition.elected).length} of ${partitions.length} partitions.`
+          + failed.map((partition) => ` ${partition.topic}-${partition.partition}: ${partition.error}`).join(';')
+      });
+      onRefresh();
+    } catch (err) {
+      setElection({ severity: 'error', message: err.response?.data?.error || err.message });
+    }
+  };
+
   return (
     <Box sx={{ p: 3 }}>
       <Box sx={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', mb: 3 }}>
         <Typography variant="h4">Brokers</Typography>
-        <Button
-          variant="outlined"
-          startIcon={<RefreshIcon />}
-          onClick={onRefresh}
-        >
-          Refresh
-        </Button>
+        <Box sx={{ display: 'flex', gap: 1 }}>
+          <Button variant="outlined" onClick={electPreferredLeaders}>
+            Elect Preferred Leaders
+          </Button>
+          <Button
+            variant="outlined"
+            startIcon={<RefreshIcon />}
+            onClick={onRefresh}
+          >
+            Refresh
+          </Button>
+        </Box>
       </Box>
+      {election && (
+        <Alert severity={election.severity} onClose={() => setElection(null)} sx={{ mb: 2 }}>{election.message}</Alert>
+      )}
       <TableContainer component={Paper}>
         <Table>
           <TableHead>
@@ -35,16 +102,18 @@ export const BrokersSection = ({ brokers, onRefresh }) => {
               <TableCell>ID</TableCell>
               <TableCell>Address</TableCell>
               <TableCell>Status</TableCell>
-              {/* <TableCell>Segment Size</TableCell> */}
+              <TableCell>Last Seen</TableCell>
+              <TableCell>Disk Usage</TableCell>
               <TableCell>Segment Count</TableCell>
               <TableCell>Replicas</TableCell>
               <TableCell>Leaders</TableCell>
+              <TableCell />
             </TableRow>
           </TableHead>
           <TableBody>
             {brokers.length === 0 ? (
               <TableRow>
-                <TableCell colSpan={7} align="center">
+                <TableCell colSpan={10} align="center">
                   <Typography color="text.secondary">No brokers available</Typography>
                 </TableCell>
               </TableRow>
@@ -53,8 +122,13 @@ export const BrokersSection = ({ brokers, onRefresh }) => {
                 <TableRow key={broker.id}>
                   <TableCell>{broker.id}</TableCell>
                   <TableCell>{broker.address}</TableCell>
-                  <TableCell>{broker.status}</TableCell>
-                  {/* <TableCell>{formatBytes(broker.segmentSize)}</TableCell> */}
+                  <TableCell>
+                    <Tooltip title={broker.statusDetail || `${broker.latencyMs} ms`}>
+                      <Chip size="small" label={broker.status} color={statusColors[broker.status] || 'default'} />
+                    </Tooltip>
+                  </TableCell>
+                  <TableCell>{broker.lastSeen ? new Date(broker.lastSeen).toLocaleString() : 'Never'}</TableCell>
+                  <TableCell>{formatBytes(diskUsage[broker.id])}</TableCell>
                   <TableCell>{broker.segmentCount}</TableCell>
                   <TableCell>
                     <Tooltip title={(broker.replicas || []).join(', ')}>
@@ -66,12 +140,83 @@ export const BrokersSection = ({ brokers, onRefresh }) => {
                       <Typography>{(broker.leaders || []).length}</Typography>
                     </Tooltip>
                   </TableCell>
+                  <TableCell>
+                    <Button size="small" onClick={() => setConfigBrokerId(broker.id)} disabled={!broker.address}>
+                      Config
+                    </Button>
+                    <Button size="small" onClick={() => setDrainBrokerId(broker.id)} disabled={brokers.length < 2}>
+                      Move Replicas
+                    </Button>
+                  </TableCell>
+                </TableRow>
+              ))
+            )}
+          </TableBody>
+        </Table>
+      </TableContainer>
+      <Box sx={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', mt: 4, mb: 2 }}>
+        <Typography variant="h5">Reassignments in Progress</Typography>
+        <Box>
+          <Button onClick={fetchReassignments}>Refresh</Button>
+          <Button
+            color="error"
+            disabled={reassignments.length === 0}
+            onClick={() => reassignmentAction(() => API.post(clusterPath('/reassignments/cancel')))}
+          >
+            Cancel All
+          </Button>
+          <Button onClick={() => reassignmentAction(() => API.delete(clusterPath('/reassignments/throttle')))}>
+            Remove Throttle
+          </Button>
+        </Box>
+      </Box>
+      {reassignmentError && <Alert severity="error" sx={{ mb: 2 }}>{reassignmentError}</Alert>}
+      <TableContainer component={Paper}>
+        <Table size="small">
+          <TableHead>
+            <TableRow>
+              <TableCell>Partition</TableCell>
+              <TableCell>Replicas</TableCell>
+              <TableCell>Adding</TableCell>
+              <TableCell>Removing</TableCell>
+              <TableCell>Progress</TableCell>
+            </TableRow>
+          </TableHead>
+          <TableBody>
+            {reassignments.length === 0 ? (
+              <TableRow>
+                <TableCell colSpan={5} align="center">
+                  <Typography color="text.secondary">No reassignments in progress</Typography>
+                </TableCell>
+              </TableRow>
+            ) : (
+              reassignments.map((reassignment) => (
+                <TableRow key={`${reassignment.topic}-${reassignment.partition}`}>
+                  <TableCell>{reassignment.topic}-{reassignment.partition}</TableCell>
+                  <TableCell>{reassignment.replicas.join(', ')}</TableCell>
+                  <TableCell>{reassignment.addingReplicas.join(', ')}</TableCell>
+                  <TableCell>{reassignment.removingReplicas.join(', ')}</TableCell>
+                  <TableCell>{reassignment.progress !== null ? `${Math.round(reassignment.progress * 100)}%` : 'Unknown'}</TableCell>
                 </TableRow>
               ))
             )}
           </TableBody>
         </Table>
       </TableContainer>
+      <ReassignmentDialog
+        open={drainBrokerId !== null}
+        onClose={() => setDrainBrokerId(null)}
+        broker={drainBrokerId}
+        brokers={brokers}
+        topics={topics || []}
+        onStarted={fetchReassignments}
+      />
+      <ConfigDialog
+        open={configBrokerId !== null}
+        onClose={() => setConfigBrokerId(null)}
+        title={`Broker ${configBrokerId} Configuration`}
+        path={configBrokerId !== null ? `/brokers/${configBrokerId}/config` : null}
+      />
     </Box>
   );
 }; 
\ No newline at end of file
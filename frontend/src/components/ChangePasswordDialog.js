@@ -8,7 +8,7 @@ import {
   TextField,
   Alert
 } from '@mui/material';
-import API from '../api';
+import API, { setAuthTokens } from '../api';
 
 // ChangePasswordDialog.js - Provides a dialog UI for users to change their password in the dashboard.
 
@@ -43,10 +43,13 @@ export const ChangePasswordDialog = ({ open, onClose }) => {
     }
 
     try {
-      await API.post('/change-password', {
+      const response = await API.post('/change-password', {
         currentPassword: passwords.currentPassword,
         newPassword: passwords.newPassword
       });
+
+      // Existing sessions are revoked; continue with the tokens issued for this one
+      setAuthTokens(response.data);
       
       setSuccess(true);
       setPasswords({
@@ -0,0 +1,127 @@
+import React, { useEffect, useState } from 'react';
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Button,
+  TextField,
+  Alert,
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableRow
+} from '@mui/material';
+import API, { clusterPath } from '../api';
+
+// ConfigDialog.js - Shows the configs of a broker or topic with their sources and defaults and edits them.
+// path is the config endpoint below the cluster, e.g. /brokers/1/config or /topics/orders/config.
+
+export const ConfigDialog = ({ open, onClose, title, path }) => {
+  const [configs, setConfigs] = useState([]);
+  const [edits, setEdits] = useState({});
+  const [filter, setFilter] = useState('');
+  const [error, setError] = useState(null);
+  const [notice, setNotice] = useState(null);
+
+  useEffect(() => {
+    if (!open || !path) return;
+    setEdits({});
+    setError(null);
+    setNotice(null);
+    API.get(clusterPath(path))
+      .then((res) => setConfigs(res.data))
+      .catch((err) => setError(err.response?.data?.error || err.message));
+  }, [open, path]);
+
+  const submit = async (validateOnly) => {
+    const changes = Object.entries(edits).map(([name, value]) =>
+      value === '' ? { name, operation: 'delete' } : { name, value, operation: 'set' }
+    );
+    setError(null);
+    setNotice(null);
+    try {
+      const res = await API.put(clusterPath(path), { configs: changes, validateOnly });
+      setConfigs(res.data.configs);
+      if (validateOnly) {
+        setNotice('The changes are valid.');
+      } else {
+        setEdits({});
+        setNotice('The changes were applied.');
+      }
+    } catch (err) {
+      setError(err.response?.data?.error || err.message);
+    }
+  };
+
+  const visible = configs.filter((config) => config.name.includes(filter));
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+      <DialogTitle>{title}</DialogTitle>
+      <DialogContent>
+        {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
+        {notice && <Alert severity="success" sx={{ mb: 2 }}>{notice}</Alert>}
+        <TextField
+          label="Filter"
+          value={filter}
+          onChange={(e) => setFilter(e.target.value)}
+          size="small"
+          fullWidth
+          sx={{ my: 1 }}
+        />
+        <Table size="small">
+          <TableHead>
+            <TableRow>
+              <TableCell>Name</TableCell>
+              <TableCell>Value</TableCell>
+              <TableCell>Default</TableCell>
+              <TableCell>Source</TableCell>
+              <TableCell />
+            </TableRow>
+          </TableHead>
+          <TableBody>
+            {visible.map((config) => (
+              <TableRow key={config.name}>
+                <TableCell>{config.name}</TableCell>
+                <TableCell>
+                  {config.readOnly || config.sensitive ? (
+                    config.sensitive ? '******' : config.value
+                  ) : (
+                    <TextField
+                      value={edits[config.name] ?? config.value ?? ''}
+                      onChange={(e) => setEdits({ ...edits, [config.name]: e.target.value })}
+                      size="small"
+                      variant="standard"
+                      fullWidth
+                      helperText={edits[config.name] === '' ? 'Reset to the next source' : ''}
+                    />
+                  )}
+                </TableCell>
+                <TableCell>{config.sensitive ? '' : config.defaultValue}</TableCell>
+                <TableCell>{config.source}</TableCell>
+                <TableCell>
+                  {!config.readOnly && !config.sensitive && config.source !== 'default' && (
+                    <Button size="small" onClick={() => setEdits({ ...edits, [config.name]: '' })}>
+                      Reset
+                    </Button>
+                  )}
+                </TableCell>
+              </TableRow>
+            ))}
+          </TableBody>
+        </Table>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>Close</Button>
+        <Button onClick={() => submit(true)} disabled={Object.keys(edits).length === 0}>
+          Validate
+        </Button>
+        <Button onClick={() => submit(false)} variant="contained" disabled={Object.keys(edits).length === 0}>
+          Apply
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
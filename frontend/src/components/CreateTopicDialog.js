@@ -6,15 +6,29 @@ import {
   DialogActions,
   Button,
   TextField,
-  Box
+  Box,
+  MenuItem
 } from '@mui/material';
 
 // CreateTopicDialog.js - Provides a dialog UI for creating new Kafka topics in the dashboard.
+// Topic configs left empty are not sent, so the topic inherits the broker defaults.
+
+const cleanupPolicies = ['delete', 'compact', 'compact,delete'];
+const compressionTypes = ['producer', 'uncompressed', 'gzip', 'snappy', 'lz4', 'zstd'];
+const emptyConfigs = {
+  'cleanup.policy': '',
+  'retention.ms': '',
+  'min.insync.replicas': '',
+  'compression.type': ''
+};
 
 export const CreateTopicDialog = ({ open, onClose, onCreateTopic, brokerCount }) => {
   const [topicName, setTopicName] = useState('');
   const [partitions, setPartitions] = useState(1);
   const [replicationFactor, setReplicationFactor] = useState(1);
+  const [configs, setConfigs] = useState(emptyConfigs);
+
+  const setConfig = (name) => (e) => setConfigs({ ...configs, [name]: e.target.value });
 
   const handleSubmit = async (e) => {
     e.preventDefault();
@@ -22,13 +36,15 @@ export const CreateTopicDialog = ({ open, onClose, onCreateTopic, brokerCount })
       await onCreateTopic({
         name: topicName,
         partitions: parseInt(partitions),
-        replicationFactor: parseInt(replicationFactor)
+        replicationFactor: parseInt(replicationFactor),
+        configs: Object.fromEntries(Object.entries(configs).filter(([, value]) => value !== ''))
       });
       onClose();
       // Reset form
       setTopicName('');
       setPartitions(1);
       setReplicationFactor(1);
+      setConfigs(emptyConfigs);
     } catch (error) {
       console.error('Error creating topic:', error);
     }
@@ -73,6 +89,50 @@ export const CreateTopicDialog = ({ open, onClose, onCreateTopic, brokerCount })
               fullWidth
               helperText={`Enter replication factor (1-${brokerCount || 1})`}
             />
+            <TextField
+              select
+              label="Cleanup Policy"
+              value={configs['cleanup.policy']}
+              onChange={setConfig('cleanup.policy')}
+              fullWidth
+              helperText="Leave empty to use the broker default"
+            >
+              <MenuItem value="">Broker default</MenuItem>
+              {cleanupPolicies.map((policy) => (
+                <MenuItem key={policy} value={policy}>{policy}</MenuItem>
+              ))}
+            </TextField>
+            <TextField
+              label="Retention (ms)"
+              type="number"
+              value={configs['retention.ms']}
+              onChange={setConfig('retention.ms')}
+              inputProps={{ min: -1, step: 1 }}
+              fullWidth
+              helperText="-1 keeps messages forever; leave empty to use the broker default"
+            />
+            <TextField
+              label="Min In-Sync Replicas"
+              type="number"
+              value={configs['min.insync.replicas']}
+              onChange={setConfig('min.insync.replicas')}
+              inputProps={{ min: 1, max: replicationFactor, step: 1 }}
+              fullWidth
+              helperText="Leave empty to use the broker default"
+            />
+            <TextField
+              select
+              label="Compression Type"
+              value={configs['compression.type']}
+              onChange={setConfig('compression.type')}
+              fullWidth
+              helperText="Leave empty to use the broker default"
+            >
+              <MenuItem value="">Broker default</MenuItem>
+              {compressionTypes.map((type) => (
+                <MenuItem key={type} value={type}>{type}</MenuItem>
+              ))}
+            </TextField>
           </Box>
         </DialogContent>
         <DialogActions>
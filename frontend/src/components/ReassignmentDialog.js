@@ -0,0 +1,121 @@
+import React, { useEffect, useState } from 'react';
+import {
+  Dialog,
+  DialogTitle,
+  DialogContent,
+  DialogActions,
+  Button,
+  TextField,
+  Alert,
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableRow,
+  Typography
+} from '@mui/material';
+import API, { clusterPath } from '../api';
+
+// ReassignmentDialog.js - Plans moving every replica off a broker and starts the reassignment with an optional throttle.
+
+export const ReassignmentDialog = ({ open, onClose, broker, brokers, topics, onStarted }) => {
+  const [plan, setPlan] = useState(null);
+  const [throttle, setThrottle] = useState('');
+  const [results, setResults] = useState(null);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    if (!open) return;
+    setPlan(null);
+    setThrottle('');
+    setResults(null);
+    setError(null);
+    API.post(clusterPath('/reassignments/plan'), {
+      topics: topics.map((topic) => topic.name),
+      brokers: brokers.map((b) => b.id).filter((id) => id !== broker)
+    })
+      .then((res) => setPlan(res.data))
+      .catch((err) => setError(err.response?.data?.error || err.message));
+  }, [open, broker, brokers, topics]);
+
+  const execute = async () => {
+    setError(null);
+    try {
+      const res = await API.post(clusterPath('/reassignments'), {
+        partitions: plan.partitions,
+        throttleBytesPerSec: throttle ? Math.round(parseFloat(throttle) * 1024 * 1024) : 0
+      });
+      setResults(res.data.partitions);
+      onStarted();
+    } catch (err) {
+      setError(err.response?.data?.error || err.message);
+    }
+  };
+
+  const failed = (results || []).filter((result) => result.error);
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+      <DialogTitle>Move Replicas off Broker {broker}</DialogTitle>
+      <DialogContent>
+        {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
+        {plan?.warnings.map((warning) => (
+          <Alert key={warning} severity="warning" sx={{ mb: 2 }}>{warning}</Alert>
+        ))}
+        {results && (
+          <Alert severity={failed.length ? 'error' : 'success'} sx={{ mb: 2 }}>
+            {failed.length
+              ? failed.map((result) => `${result.topic}-${result.partition}: ${result.error}`).join('; ')
+              : `Started reassigning ${results.length} partitions.`}
+          </Alert>
+        )}
+        {plan && (
+          <>
+            <Typography sx={{ mb: 1 }}>
+              {plan.partitions.length} partitions move ({plan.movedReplicas} replicas copied), {plan.unchanged} stay
+              {plan.rackAware ? '; replicas are spread over racks' : ''}.
+            </Typography>
+            <Table size="small">
+              <TableHead>
+                <TableRow>
+                  <TableCell>Partition</TableCell>
+                  <TableCell>Current Replicas</TableCell>
+                  <TableCell>New Replicas</TableCell>
+                </TableRow>
+              </TableHead>
+              <TableBody>
+                {plan.partitions.map((move) => (
+                  <TableRow key={`${move.topic}-${move.partition}`}>
+                    <TableCell>{move.topic}-{move.partition}</TableCell>
+                    <TableCell>{(move.currentReplicas || []).join(', ')}</TableCell>
+                    <TableCell>{move.replicas.join(', ')}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+            <TextField
+              label="Throttle (MB/s)"
+              type="number"
+              value={throttle}
+              onChange={(e) => setThrottle(e.target.value)}
+              inputProps={{ min: 0 }}
+              fullWidth
+              sx={{ mt: 2 }}
+              helperText="Limits replication traffic per broker; remove the throttle once the reassignment is complete"
+            />
+          </>
+        )}
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>Close</Button>
+        <Button
+          onClick={execute}
+          variant="contained"
+          disabled={!plan || plan.partitions.length === 0 || results !== null}
+        >
+          Start Reassignment
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+};
@@ -15,13 +15,17 @@ import {
   MailOutline as MailOutlineIcon,
   Refresh as RefreshIcon,
   Add as AddIcon,
-  Delete as DeleteIcon
+  Delete as DeleteIcon,
+  Settings as SettingsIcon
 } from '@mui/icons-material';
 import { MessagesTable } from './MessagesTable';
 import { ProduceMessageForm } from './ProduceMessageForm';
 import { MessageFormProvider } from '../contexts/MessageFormContext';
 import { CreateTopicDialog } from './CreateTopicDialog';
-import API from '../api';
+import { ConfigDialog } from './ConfigDialog';
+import { AddPartitionsDialog } from './AddPartitionsDialog';
+import API, { clusterPath } from '../api';
+import { formatBytes } from '../format';
 
 // TopicsSection.js - Provides the UI and logic for displaying and managing Kafka topics in the dashboard.
 
@@ -45,6 +49,8 @@ export const TopicsSection = ({
   brokerCount
 }) => {
   const [isCreateDialogOpen, setIsCreateDialogOpen] = useState(false);
+  const [isConfigDialogOpen, setIsConfigDialogOpen] = useState(false);
+  const [isPartitionsDialogOpen, setIsPartitionsDialogOpen] = useState(false);
   const [partitionCount, setPartitionCount] = useState(1);
 
   const safeTopics = topics || [];
@@ -53,7 +59,7 @@ export const TopicsSection = ({
     const fetchPartitionInfo = async () => {
       if (selectedTopic) {
         try {
-          const response = await API.get(`/topics/${selectedTopic}/partitions`);
+          const response = await API.get(clusterPath(`/topics/${encodeURIComponent(selectedTopic)}/partitions`));
           setPartitionCount(response.data.length);
         } catch (error) {
           console.error('Error fetching partition info:', error);
@@ -112,20 +118,51 @@ export const TopicsSection = ({
         onCreateTopic={handleCreateTopic}
         brokerCount={brokerCount}
       />
+
+      <ConfigDialog
+        open={isConfigDialogOpen}
+        onClose={() => setIsConfigDialogOpen(false)}
+        title={`Topic ${selectedTopic} Configuration`}
+        path={selectedTopic ? `/topics/${encodeURIComponent(selectedTopic)}/config` : null}
+      />
+
+      <AddPartitionsDialog
+        open={isPartitionsDialogOpen}
+        onClose={() => setIsPartitionsDialogOpen(false)}
+        topic={selectedTopic}
+        currentCount={partitionCount}
+        onAdded={setPartitionCount}
+      />
       
       {/* Topic List - Full Width */}
       <Paper sx={{ p: 2, mb: 3 }}>
         <Box sx={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', mb: 2 }}>
           <Typography variant="h6">Topic List</Typography>
           {selectedTopic && (
-            <Button
-              variant="outlined"
-              color="error"
-              startIcon={<DeleteIcon />}
-              onClick={onDeleteTopic}
-            >
-              Delete Topic
-            </Button>
+            <Box sx={{ display: 'flex', gap: 2 }}>
+              <Button
+                variant="outlined"
+                startIcon={<SettingsIcon />}
+                onClick={() => setIsConfigDialogOpen(true)}
+              >
+                Configuration
+              </Button>
+              <Button
+                variant="outlined"
+                startIcon={<AddIcon />}
+                onClick={() => setIsPartitionsDialogOpen(true)}
+              >
+                Add Partitions
+              </Button>
+              <Button
+                variant="outlined"
+                color="error"
+                startIcon={<DeleteIcon />}
+                onClick={onDeleteTopic}
+              >
+                Delete Topic
+              </Button>
+            </Box>
           )}
         </Box>
         <FormControl fullWidth>
@@ -136,7 +173,9 @@ export const TopicsSection = ({
             label="Select Topic"
           >
             {safeTopics.map((topic) => (
-              <MenuItem key={topic.name} value={topic.name}>{topic.name}</MenuItem>
+              <MenuItem key={topic.name} value={topic.name}>
+                {topic.name}{topic.sizeBytes !== null && topic.sizeBytes !== undefined ? ` (${formatBytes(topic.sizeBytes)})` : ''}
+              </MenuItem>
             ))}
           </Select>
         </FormControl>
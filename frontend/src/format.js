@@ -0,0 +1,14 @@
+// format.js - Formatting helpers shared by the dashboard components.
+
+// formatBytes renders a byte count with a binary unit, e.g. 1536 -> "1.5 KiB".
+export const formatBytes = (bytes) => {
+  if (bytes === null || bytes === undefined) return '-';
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB', 'PiB'];
+  let value = bytes;
+  let unit = 0;
+  while (value >= 1024 && unit < units.length - 1) {
+    value /= 1024;
+    unit++;
+  }
+  return `${unit === 0 ? value : value.toFixed(1)} ${units[unit]}`;
+};